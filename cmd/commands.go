@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/llmback"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/monitor"
+
+	"github.com/pterm/pterm"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const usage = `usage:
+  ai-agentic-monitor                                run the configured monitors
+  ai-agentic-monitor monitors new "<request>"       author a monitor from a natural language request
+  ai-agentic-monitor monitors list                  list the configured monitors
+  ai-agentic-monitor sessions                       list the investigation sessions
+  ai-agentic-monitor resume <id> ["<hint>"]         resume an investigation session, optionally with an operator hint`
+
+// runCommand runs the sub command given on the command line
+func runCommand(command string, args []string) error {
+	switch command {
+	case "monitors":
+		return runMonitorsCommand(args)
+	case "sessions":
+		return listSessions()
+	case "resume":
+		if len(args) == 0 {
+			return fmt.Errorf("missing session id\n%s", usage)
+		}
+		return resumeSession(args[0], strings.Join(args[1:], " "))
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+}
+
+func runMonitorsCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing monitors sub command\n%s", usage)
+	}
+
+	switch args[0] {
+	case "new":
+		if len(args) < 2 {
+			return fmt.Errorf("missing monitor request\n%s", usage)
+		}
+		return newMonitor(strings.Join(args[1:], " "))
+	case "list":
+		return listMonitors()
+	default:
+		return fmt.Errorf("unknown monitors sub command %q\n%s", args[0], usage)
+	}
+}
+
+// newMonitor authors a monitor with the LLM, shows it for review and appends it to the monitors config
+func newMonitor(request string) error {
+	llmConfig, err := config.NewLLMBackendYamlConfig(llmConfigPath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	llmbak, err := llmback.NewLLMBackend(ctx, llmConfig)
+	if err != nil {
+		return err
+	}
+
+	registry, err := newToolRegistry()
+	if err != nil {
+		return err
+	}
+
+	spinner, _ := pterm.DefaultSpinner.Start("Authoring monitor...")
+	def, err := monitor.AuthorMonitor(ctx, llmbak, registry, request)
+	if err != nil {
+		spinner.Fail(err.Error())
+		return err
+	}
+	spinner.Success("Monitor authored")
+
+	out, err := yaml.Marshal(def)
+	if err != nil {
+		return err
+	}
+	pterm.DefaultBox.WithTitle(def.Name).Println(strings.TrimSpace(string(out)))
+
+	confirmed, err := pterm.DefaultInteractiveConfirm.Show(fmt.Sprintf("Append the monitor to %s?", monitorsConfigPath))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		pterm.Info.Println("Monitor discarded")
+		return nil
+	}
+
+	if err := config.AppendMonitor(monitorsConfigPath, *def); err != nil {
+		return err
+	}
+	pterm.Success.Printfln("Monitor %s appended to %s", def.Name, monitorsConfigPath)
+	return nil
+}
+
+func listMonitors() error {
+	monitorsConfig, err := config.NewMonitorsYamlConfig(monitorsConfigPath)
+	if err != nil {
+		return err
+	}
+
+	data := pterm.TableData{{"Name", "Schedule", "Tools"}}
+	for _, def := range monitorsConfig.Monitors {
+		data = append(data, []string{def.Name, def.Schedule, strings.Join(def.Tools, ", ")})
+	}
+	for _, def := range monitorsConfig.Checks {
+		if def.Certificates != nil {
+			targets := append(append([]string{}, def.Certificates.Paths...), def.Certificates.Endpoints...)
+			data = append(data, []string{def.Name, def.Schedule, "certificates of " + strings.Join(targets, ", ")})
+			continue
+		}
+		data = append(data, []string{def.Name, def.Schedule, fmt.Sprintf("%s probe of %s", def.Probe.Type, def.Probe.Target)})
+	}
+	// the rules are evaluated at every sample of the metrics
+	for _, def := range monitorsConfig.Rules {
+		data = append(data, []string{def.Name, "metrics", "rule " + def.Expr})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+func listSessions() error {
+	sessions, err := agents.DefaultSessionStore().List()
+	if err != nil {
+		return err
+	}
+
+	data := pterm.TableData{{"ID", "Monitor", "Profile", "Status", "Steps", "Updated"}}
+	for _, sess := range sessions {
+		data = append(data, []string{
+			sess.ID, sess.Monitor, sess.Profile, sess.Status,
+			fmt.Sprintf("%d", len(sess.Steps)), sess.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+// resumeSession continues the investigation of the session from its last step
+func resumeSession(id, hint string) error {
+	llmConfig, err := config.NewLLMBackendYamlConfig(llmConfigPath)
+	if err != nil {
+		return err
+	}
+	routing, err := llmConfig.GetRouting()
+	if err != nil {
+		return err
+	}
+
+	registry, err := newToolRegistry()
+	if err != nil {
+		return err
+	}
+
+	answer, err := monitor.ResumeSession(routing, registry, id, hint)
+	if err != nil {
+		return err
+	}
+	fmt.Println("ai-agentic-monitor: " + answer)
+	return nil
+}
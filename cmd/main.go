@@ -1,34 +1,165 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
 	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
 	"github.com/darmenliu/ai-agentic-monitor/pkg/monitor"
 )
 
+const (
+	llmConfigPath      = "./config/llm_config.yml"
+	monitorsConfigPath = "./config/monitors.yml"
+	agentConfigPath    = "./config/agent_config.yml"
+)
+
 func main() {
-	config, err := config.NewLLMBackendYamlConfig("./config/llm_config.yml")
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	config, err := config.NewLLMBackendYamlConfig(llmConfigPath)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 
-	monitor := monitor.NewMonitor(config, "check the status of the system if there are any issues like performance, memory, etc.")
-
 	manager := NewMonitorManager()
-	manager.AddMonitor("monitor", monitor)
-	err = manager.Run()
+	if err := addConfiguredMonitors(manager, config, alerts.NewAlertsManager()); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// The monitors and the watchers run until the terminate signal.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	err = manager.Run(ctx)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
+	<-ctx.Done()
+}
+
+// addConfiguredMonitors adds the monitors, the checks and the watchers of the
+// monitors config to the manager, the default monitor is added if no monitor
+// is configured.
+func addConfiguredMonitors(manager *MonitorManager, llmConfig *config.LLMBackendYamlConfig, alertsManager alerts.AlertsManager) error {
+	routing, err := llmConfig.GetRouting()
+	if err != nil {
+		return err
+	}
+
+	monitorsConfig, err := config.NewMonitorsYamlConfig(monitorsConfigPath)
+	if err != nil {
+		return err
+	}
+
+	registry, err := newToolRegistry()
+	if err != nil {
+		return err
+	}
+
+	manager.AddWatcher("metrics", registry.MetricsCollector())
+
+	definitions := monitorsConfig.Monitors
+	if len(definitions) == 0 {
+		definitions = []config.MonitorDefinition{{
+			Name:     config.DefaultMonitorName,
+			Prompt:   "check the status of the system if there are any issues like performance, memory, etc.",
+			Schedule: fmt.Sprintf("%dm", interval_of_monitors),
+		}}
+	}
+
+	for _, def := range definitions {
+		mon, err := monitor.NewMonitorFromDefinition(routing, registry, def, alertsManager)
+		if err != nil {
+			return err
+		}
+		schedule, err := def.GetSchedule()
+		if err != nil {
+			return err
+		}
+		manager.AddMonitor(def.Name, mon, schedule)
+	}
+
+	for _, def := range monitorsConfig.Checks {
+		check, err := monitor.NewCheck(def, alertsManager)
+		if err != nil {
+			return err
+		}
+		schedule, err := def.GetSchedule()
+		if err != nil {
+			return err
+		}
+		manager.AddMonitor(def.Name, check, schedule)
+	}
 
-	// Wait for terminate signal
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	<-sigs
+	if len(monitorsConfig.Rules) > 0 {
+		engine, err := monitor.NewRuleEngine(monitorsConfig.Rules, routing, registry, alertsManager)
+		if err != nil {
+			return err
+		}
+		manager.AddWatcher("rules", engine)
+	}
+
+	if monitorsConfig.KernelEvents != nil {
+		watcher, err := monitor.NewKernelWatcher(*monitorsConfig.KernelEvents, routing, registry, alertsManager)
+		if err != nil {
+			return err
+		}
+		manager.AddWatcher("kernel-events", watcher)
+	}
+
+	if monitorsConfig.Pressure != nil {
+		watcher, err := monitor.NewPressureWatcher(*monitorsConfig.Pressure, routing, registry, alertsManager)
+		if err != nil {
+			return err
+		}
+		manager.AddWatcher("pressure", watcher)
+	}
+
+	if monitorsConfig.Anomalies != nil {
+		watcher, err := monitor.NewAnomalyWatcher(*monitorsConfig.Anomalies, routing, registry, alertsManager)
+		if err != nil {
+			return err
+		}
+		manager.AddWatcher("anomalies", watcher)
+	}
+
+	if monitorsConfig.Forecasts != nil {
+		watcher, err := monitor.NewForecastWatcher(*monitorsConfig.Forecasts, routing, registry, alertsManager)
+		if err != nil {
+			return err
+		}
+		manager.AddWatcher("forecasts", watcher)
+	}
+
+	if monitorsConfig.Dependencies != nil {
+		watcher, err := monitor.NewDependencyWatcher(*monitorsConfig.Dependencies, routing, registry, alertsManager)
+		if err != nil {
+			return err
+		}
+		manager.AddWatcher("dependencies", watcher)
+	}
+	return nil
+}
+
+// newToolRegistry creates the tools of the agent from the agent config
+func newToolRegistry() (*agents.ToolRegistry, error) {
+	agentConfig, err := config.NewAgentYamlConfig(agentConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return agents.NewToolRegistry(agentConfig), nil
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/darmenliu/ai-agentic-monitor/pkg/monitor"
@@ -8,42 +10,118 @@ import (
 )
 
 const (
-	// interval of all the monitors to run
+	// default interval of the monitors to run
 	interval_of_monitors = 5 // in minutes
+
+	// delays before a failed watcher is restarted, doubled at every failure in a row
+	watcherMinBackoff = time.Second
+	watcherMaxBackoff = 5 * time.Minute
 )
 
 type AIMonitors interface {
 	run() error
 }
 
+type scheduledMonitor struct {
+	monitor  monitor.Monitor
+	interval time.Duration
+}
+
+// Watcher runs for the lifetime of the manager, like the kernel watcher
+type Watcher interface {
+	Run(ctx context.Context) error
+}
+
 type MonitorManager struct {
-	monitors map[string]monitor.Monitor
+	monitors   map[string]scheduledMonitor
+	watchers   map[string]Watcher
+	minBackoff time.Duration
+	maxBackoff time.Duration
 }
 
 func NewMonitorManager() *MonitorManager {
 	return &MonitorManager{
-		monitors: make(map[string]monitor.Monitor),
+		monitors:   make(map[string]scheduledMonitor),
+		watchers:   make(map[string]Watcher),
+		minBackoff: watcherMinBackoff,
+		maxBackoff: watcherMaxBackoff,
 	}
 }
 
-func (m *MonitorManager) AddMonitor(name string, mon monitor.Monitor) {
-	m.monitors[name] = mon
+// AddMonitor adds a monitor which will be run every interval
+func (m *MonitorManager) AddMonitor(name string, mon monitor.Monitor, interval time.Duration) {
+	m.monitors[name] = scheduledMonitor{monitor: mon, interval: interval}
+}
+
+// AddWatcher adds a watcher which will be started with the monitors
+func (m *MonitorManager) AddWatcher(name string, watcher Watcher) {
+	m.watchers[name] = watcher
 }
 
-func (m *MonitorManager) Run() error {
+// Run starts the monitors and the watchers, they run until the context is done
+func (m *MonitorManager) Run(ctx context.Context) error {
 	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
-	for _, mon := range m.monitors {
-		go func(mon monitor.Monitor) {
-			ticker := time.NewTicker(interval_of_monitors * time.Minute)
+	for name, mon := range m.monitors {
+		go func(name string, mon scheduledMonitor) {
+			ticker := time.NewTicker(mon.interval)
 			defer ticker.Stop()
-			for range ticker.C {
-				err := mon.Run()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				err := mon.monitor.Run()
 				if err != nil {
 					// handle error (e.g., log it)
-					logger.Error("ai-agentic-monitor: failed to run monitor,", logger.Args("err", err.Error()))
+					logger.Error("ai-agentic-monitor: failed to run monitor,", logger.Args("monitor", name, "err", err.Error()))
 				}
 			}
-		}(mon)
+		}(name, mon)
+	}
+	for name, watcher := range m.watchers {
+		go m.runWatcher(ctx, name, watcher)
 	}
 	return nil
 }
+
+// runWatcher restarts the watcher whenever it stops before the context is
+// done, the delay before a restart grows while the watcher keeps failing.
+func (m *MonitorManager) runWatcher(ctx context.Context, name string, watcher Watcher) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	backoff := m.minBackoff
+	for {
+		start := time.Now()
+		err := runSafely(ctx, watcher)
+		if ctx.Err() != nil {
+			return
+		}
+		// A watcher which ran for a while before failing is not failing in a row.
+		if time.Since(start) >= m.maxBackoff {
+			backoff = m.minBackoff
+		}
+		if err == nil {
+			err = fmt.Errorf("watcher returned")
+		}
+		logger.Error("ai-agentic-monitor: watcher stopped, restarting it,", logger.Args("watcher", name, "err", err.Error(), "in", backoff.String()))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, m.maxBackoff)
+	}
+}
+
+// runSafely runs the watcher, a panic is returned as an error
+func runSafely(ctx context.Context, watcher Watcher) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("watcher panicked: %v", r)
+		}
+	}()
+	return watcher.Run(ctx)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyWatcher fails its first runs, then runs until the context is done
+type flakyWatcher struct {
+	mu       sync.Mutex
+	runs     []time.Time
+	failures int
+	panics   bool
+}
+
+func (w *flakyWatcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+	w.runs = append(w.runs, time.Now())
+	run := len(w.runs)
+	w.mu.Unlock()
+	if run <= w.failures {
+		if w.panics {
+			panic("lost the event source")
+		}
+		return fmt.Errorf("run %d failed", run)
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (w *flakyWatcher) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.runs)
+}
+
+func TestRunWatcherRestarts(t *testing.T) {
+	tests := []struct {
+		name    string
+		watcher *flakyWatcher
+	}{
+		{"errors", &flakyWatcher{failures: 3}},
+		{"panics", &flakyWatcher{failures: 2, panics: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewMonitorManager()
+			manager.minBackoff = 10 * time.Millisecond
+			manager.maxBackoff = time.Second
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				manager.runWatcher(ctx, tt.name, tt.watcher)
+				close(done)
+			}()
+			assert.Eventually(t, func() bool { return tt.watcher.count() == tt.watcher.failures+1 }, 5*time.Second, 5*time.Millisecond)
+
+			// the delay doubles at every failure in a row
+			tt.watcher.mu.Lock()
+			runs := tt.watcher.runs
+			tt.watcher.mu.Unlock()
+			for i := 1; i < len(runs); i++ {
+				assert.GreaterOrEqual(t, runs[i].Sub(runs[i-1]), manager.minBackoff<<(i-1))
+			}
+
+			cancel()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the watcher is not stopped with the context")
+			}
+			assert.Equal(t, tt.watcher.failures+1, tt.watcher.count())
+		})
+	}
+}
+
+func TestRunWatcherStopsDuringBackoff(t *testing.T) {
+	manager := NewMonitorManager()
+	manager.minBackoff = time.Hour
+	manager.maxBackoff = time.Hour
+	watcher := &flakyWatcher{failures: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.runWatcher(ctx, "kernel-events", watcher)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return watcher.count() == 1 }, 5*time.Second, 5*time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the backoff does not stop with the context")
+	}
+	assert.Equal(t, 1, watcher.count())
+}
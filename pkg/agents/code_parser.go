@@ -13,8 +13,11 @@ import (
 )
 
 const (
-	Catchdir   = ".nuwa-terminal"
-	ScriptsDir = "scripts"
+	Catchdir          = ".nuwa-terminal"
+	ScriptsDir        = "scripts"
+	SessionsDir       = "sessions"
+	LogPatternsFile   = "log_patterns.json"
+	DependencyMapFile = "dependency_map.json"
 )
 
 type ScriptCodeParser struct {
@@ -0,0 +1,217 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/session"
+	"github.com/pterm/pterm"
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/callbacks"
+	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/memory"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const (
+	// default number of agent steps before the run is aborted
+	DefaultMaxIterations = 5
+	// default number of tool actions of one step executed at the same time
+	DefaultMaxParallelism = 4
+)
+
+// MonitorExecutor is the chain running the monitor agent, unlike the executor of
+// langchaingo the independent actions planned in one step are executed concurrently
+// and a failing tool is reported to the agent as an observation.
+type MonitorExecutor struct {
+	Agent            agents.Agent
+	CallbacksHandler callbacks.Handler
+	// MaxIterations is the number of steps before the run is aborted with agents.ErrNotFinished
+	MaxIterations int
+	// MaxParallelism bounds the number of actions of one step executed at the same time
+	MaxParallelism int
+	// Session persists the inputs and steps of the run after every step, the run
+	// continues from the steps already in the session. Nil disables the persistence.
+	Session      *session.Session
+	SessionStore *session.Store
+}
+
+var _ chains.Chain = &MonitorExecutor{}
+
+// ExecutorOption is a function to configure the MonitorExecutor
+type ExecutorOption func(*MonitorExecutor)
+
+// WithMaxIterations sets the number of steps before the run is aborted
+func WithMaxIterations(iterations int) ExecutorOption {
+	return func(e *MonitorExecutor) {
+		e.MaxIterations = iterations
+	}
+}
+
+// WithMaxParallelism sets the number of actions executed at the same time
+func WithMaxParallelism(parallelism int) ExecutorOption {
+	return func(e *MonitorExecutor) {
+		e.MaxParallelism = parallelism
+	}
+}
+
+// WithExecutorCallback sets the callbacks handler of the executor
+func WithExecutorCallback(handler callbacks.Handler) ExecutorOption {
+	return func(e *MonitorExecutor) {
+		e.CallbacksHandler = handler
+	}
+}
+
+// WithSession persists the run in the session and continues from its steps
+func WithSession(store *session.Store, sess *session.Session) ExecutorOption {
+	return func(e *MonitorExecutor) {
+		e.SessionStore = store
+		e.Session = sess
+	}
+}
+
+func NewMonitorExecutor(agent agents.Agent, opts ...ExecutorOption) *MonitorExecutor {
+	executor := &MonitorExecutor{
+		Agent:          agent,
+		MaxIterations:  DefaultMaxIterations,
+		MaxParallelism: DefaultMaxParallelism,
+	}
+	for _, opt := range opts {
+		opt(executor)
+	}
+	return executor
+}
+
+func (e *MonitorExecutor) Call(ctx context.Context, inputValues map[string]any, _ ...chains.ChainCallOption) (map[string]any, error) {
+	inputs := make(map[string]string, len(inputValues))
+	for key, value := range inputValues {
+		valueStr, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", agents.ErrExecutorInputNotString, key)
+		}
+		inputs[key] = valueStr
+	}
+
+	steps := make([]schema.AgentStep, 0)
+	if e.Session != nil {
+		e.Session.Inputs = inputs
+		steps = e.Session.AgentSteps()
+		e.saveSession(steps, session.Running, "", nil)
+	}
+
+	for i := 0; i < e.MaxIterations; i++ {
+		actions, finish, err := e.Agent.Plan(ctx, steps, inputs)
+		if err != nil {
+			e.saveSession(steps, session.Failed, "", err)
+			return nil, err
+		}
+		if finish != nil {
+			if e.CallbacksHandler != nil {
+				e.CallbacksHandler.HandleAgentFinish(ctx, *finish)
+			}
+			answer, _ := finish.ReturnValues[e.Agent.GetOutputKeys()[0]].(string)
+			e.saveSession(steps, session.Finished, answer, nil)
+			return finish.ReturnValues, nil
+		}
+		if len(actions) == 0 {
+			e.saveSession(steps, session.Failed, "", agents.ErrAgentNoReturn)
+			return nil, agents.ErrAgentNoReturn
+		}
+
+		steps = append(steps, e.doActions(ctx, actions)...)
+		e.saveSession(steps, session.Running, "", nil)
+	}
+
+	e.saveSession(steps, session.NotFinished, "", agents.ErrNotFinished)
+	return nil, agents.ErrNotFinished
+}
+
+// saveSession persists the state of the run, failures are only logged since
+// the persistence must never break a run.
+func (e *MonitorExecutor) saveSession(steps []schema.AgentStep, status, answer string, runErr error) {
+	if e.Session == nil || e.SessionStore == nil {
+		return
+	}
+
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	e.Session.SetAgentSteps(steps)
+	e.Session.Status = status
+	e.Session.Answer = answer
+	e.Session.Error = ""
+	if runErr != nil {
+		e.Session.Error = runErr.Error()
+	}
+	if err := e.SessionStore.Save(e.Session); err != nil {
+		logger.Error("ai-agentic-monitor: failed to save session,", logger.Args("session", e.Session.ID, "err", err.Error()))
+	}
+}
+
+// doActions executes the actions with bounded parallelism, the steps are
+// returned in the order of the actions.
+func (e *MonitorExecutor) doActions(ctx context.Context, actions []schema.AgentAction) []schema.AgentStep {
+	parallelism := e.MaxParallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	steps := make([]schema.AgentStep, len(actions))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, action := range actions {
+		wg.Add(1)
+		go func(i int, action schema.AgentAction) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			steps[i] = schema.AgentStep{
+				Action:      action,
+				Observation: e.doAction(ctx, action),
+			}
+		}(i, action)
+	}
+	wg.Wait()
+
+	return steps
+}
+
+func (e *MonitorExecutor) doAction(ctx context.Context, action schema.AgentAction) string {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	if e.CallbacksHandler != nil {
+		e.CallbacksHandler.HandleAgentAction(ctx, action)
+	}
+
+	tool := findToolByName(e.Agent.GetTools(), action.Tool)
+	if tool == nil {
+		return fmt.Sprintf("%s is not a valid tool, try another one", action.Tool)
+	}
+
+	observation, err := tool.Call(ctx, action.ToolInput)
+	if err != nil {
+		logger.Error("ai-agentic-monitor: tool failed,", logger.Args("tool", action.Tool, "err", err.Error()))
+		return fmt.Sprintf("%s failed with error: %s", action.Tool, err.Error())
+	}
+	return observation
+}
+
+// GetInputKeys gets the input keys the agent of the executor expects.
+func (e *MonitorExecutor) GetInputKeys() []string {
+	return e.Agent.GetInputKeys()
+}
+
+// GetOutputKeys gets the output keys the agent of the executor returns.
+func (e *MonitorExecutor) GetOutputKeys() []string {
+	return e.Agent.GetOutputKeys()
+}
+
+func (e *MonitorExecutor) GetMemory() schema.Memory {
+	return memory.NewSimple()
+}
+
+// DefaultSessionStore returns the store of the sessions in the home directory
+func DefaultSessionStore() *session.Store {
+	return session.NewStore(filepath.Join(os.Getenv("HOME"), Catchdir, SessionsDir))
+}
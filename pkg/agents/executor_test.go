@@ -0,0 +1,97 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// slowTool records the maximal number of concurrent calls, the first calls
+// take the longest so the observations come back out of order.
+type slowTool struct {
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (s *slowTool) Name() string        { return "Slow" }
+func (s *slowTool) Description() string { return "a slow tool" }
+
+func (s *slowTool) Call(ctx context.Context, input string) (string, error) {
+	s.mu.Lock()
+	s.running++
+	if s.running > s.max {
+		s.max = s.running
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running--
+		s.mu.Unlock()
+	}()
+
+	var delay int
+	fmt.Sscanf(input, "%d", &delay)
+	time.Sleep(time.Duration(delay) * time.Millisecond)
+	if delay == 0 {
+		return "", fmt.Errorf("no delay")
+	}
+	return "observed " + input, nil
+}
+
+// toolsAgent is an agent only giving its tools to the executor
+type toolsAgent struct {
+	tools []tools.Tool
+}
+
+func (a *toolsAgent) Plan(context.Context, []schema.AgentStep, map[string]string) ([]schema.AgentAction, *schema.AgentFinish, error) {
+	return nil, nil, nil
+}
+func (a *toolsAgent) GetInputKeys() []string  { return []string{"input"} }
+func (a *toolsAgent) GetOutputKeys() []string { return []string{"output"} }
+func (a *toolsAgent) GetTools() []tools.Tool  { return a.tools }
+
+func TestDoActions(t *testing.T) {
+	tests := []struct {
+		parallelism int
+		want        int
+	}{
+		{parallelism: 0, want: 1},
+		{parallelism: 1, want: 1},
+		{parallelism: 3, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("parallelism %d", tt.parallelism), func(t *testing.T) {
+			tool := &slowTool{}
+			executor := NewMonitorExecutor(&toolsAgent{tools: []tools.Tool{tool}}, WithMaxParallelism(tt.parallelism))
+
+			var actions []schema.AgentAction
+			var want []string
+			for delay := 40; delay > 0; delay -= 5 {
+				input := fmt.Sprintf("%d", delay)
+				actions = append(actions, schema.AgentAction{Tool: "Slow", ToolInput: input})
+				want = append(want, "observed "+input)
+			}
+			actions = append(actions,
+				schema.AgentAction{Tool: "Slow", ToolInput: "0"},
+				schema.AgentAction{Tool: "Missing", ToolInput: "1"},
+			)
+			want = append(want, "Slow failed with error: no delay", "Missing is not a valid tool, try another one")
+
+			steps := executor.doActions(context.Background(), actions)
+			observations := make([]string, 0, len(steps))
+			for i, step := range steps {
+				assert.Equal(t, actions[i], step.Action)
+				observations = append(observations, step.Observation)
+			}
+			assert.Equal(t, want, observations)
+			assert.Equal(t, tt.want, tool.max)
+		})
+	}
+}
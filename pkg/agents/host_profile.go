@@ -0,0 +1,86 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+// hostProfilePrompt describes the role of the host to the agent, empty
+// without profile so the prompt is unchanged.
+func hostProfilePrompt(profile *config.HostProfile) string {
+	if profile == nil {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("The operators describe this host as follows, judge what you find against it:\n")
+	if profile.Role != "" {
+		fmt.Fprintf(&builder, "- role: %s\n", profile.Role)
+	}
+	if profile.Description != "" {
+		fmt.Fprintf(&builder, "- description: %s\n", strings.TrimSpace(profile.Description))
+	}
+	if profile.Environment != "" {
+		fmt.Fprintf(&builder, "- environment: %s\n", profile.Environment)
+	}
+	if len(profile.Owners) > 0 {
+		fmt.Fprintf(&builder, "- owners: %s\n", strings.Join(profile.Owners, ", "))
+	}
+
+	if len(profile.Services) > 0 {
+		builder.WriteString("- expected services, a critical one not running or not listening is a severe finding:\n")
+		for _, service := range profile.Services {
+			fmt.Fprintf(&builder, "  - %s", service.Name)
+			var details []string
+			if service.Unit != "" {
+				details = append(details, "unit "+service.Unit)
+			}
+			if len(service.Ports) > 0 {
+				ports := make([]string, 0, len(service.Ports))
+				for _, port := range service.Ports {
+					ports = append(ports, fmt.Sprint(port))
+				}
+				details = append(details, "ports "+strings.Join(ports, ", "))
+			}
+			if service.Critical {
+				details = append(details, "critical")
+			}
+			if len(details) > 0 {
+				fmt.Fprintf(&builder, " (%s)", strings.Join(details, ", "))
+			}
+			if service.Notes != "" {
+				fmt.Fprintf(&builder, ": %s", strings.TrimSpace(service.Notes))
+			}
+			builder.WriteString("\n")
+		}
+	}
+
+	if len(profile.NormalLoad) > 0 {
+		builder.WriteString("- normal load, values within these ranges are not findings:\n")
+		for _, load := range profile.NormalLoad {
+			var bounds string
+			switch {
+			case load.Min != nil && load.Max != nil:
+				bounds = fmt.Sprintf("%g to %g", *load.Min, *load.Max)
+			case load.Min != nil:
+				bounds = fmt.Sprintf("at least %g", *load.Min)
+			default:
+				bounds = fmt.Sprintf("up to %g", *load.Max)
+			}
+			fmt.Fprintf(&builder, "  - %s: %s", load.Metric, bounds)
+			if load.Notes != "" {
+				fmt.Fprintf(&builder, ", %s", strings.TrimSpace(load.Notes))
+			}
+			builder.WriteString("\n")
+		}
+	}
+
+	if len(profile.Quirks) > 0 {
+		builder.WriteString("- known quirks, they are not problems:\n")
+		for _, quirk := range profile.Quirks {
+			fmt.Fprintf(&builder, "  - %s\n", strings.TrimSpace(quirk))
+		}
+	}
+	return builder.String()
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
 	sysprmpts "github.com/darmenliu/ai-agentic-monitor/pkg/prompts"
 	"github.com/darmenliu/ai-agentic-monitor/pkg/system"
 	"github.com/pterm/pterm"
@@ -34,26 +35,53 @@ type MonitorAgent struct {
 	OutputKey string
 	// CallbacksHandler is the handler for callbacks.
 	CallbacksHandler callbacks.Handler
+	// ScratchPad keeps the scratchpad under a token budget, nil disables the compression.
+	ScratchPad *ScratchPadCompressor
+	// HostProfile is the role of the host given in the prompt, nil when the host is not described.
+	HostProfile *config.HostProfile
+}
+
+// AgentOption is a function to configure the MonitorAgent
+type AgentOption func(*MonitorAgent)
+
+// WithScratchPadCompression compresses the scratchpad to stay under maxTokens
+// tokens of the model, the default budget is used if maxTokens is not positive.
+func WithScratchPadCompression(llm llms.Model, model string, maxTokens int) AgentOption {
+	return func(a *MonitorAgent) {
+		a.ScratchPad = NewScratchPadCompressor(llm, model, maxTokens)
+	}
+}
+
+// WithHostProfile gives the profile of the host to the agent in its prompt
+func WithHostProfile(profile *config.HostProfile) AgentOption {
+	return func(a *MonitorAgent) {
+		a.HostProfile = profile
+	}
 }
 
 const (
 	_troubleshootingFinalAnswerAction = "Final Answer:"
 )
 
-func NewMonitorAgent(llm llms.Model, tools []tools.Tool, outputkey string, callback callbacks.Handler) *MonitorAgent {
-	return &MonitorAgent{
-		Chain: chains.NewLLMChain(
-			llm,
-			CreateMonitorAgentPrompt(tools),
-			chains.WithCallback(callback),
-		),
+func NewMonitorAgent(llm llms.Model, tools []tools.Tool, outputkey string, callback callbacks.Handler, opts ...AgentOption) *MonitorAgent {
+	agent := &MonitorAgent{
 		Tools:            tools,
 		OutputKey:        outputkey,
 		CallbacksHandler: callback,
 	}
+	for _, opt := range opts {
+		opt(agent)
+	}
+	// the prompt depends on the options, like the profile of the host
+	agent.Chain = chains.NewLLMChain(
+		llm,
+		CreateMonitorAgentPrompt(tools, agent.HostProfile),
+		chains.WithCallback(callback),
+	)
+	return agent
 }
 
-func CreateMonitorAgentPrompt(tools []tools.Tool) prompts.PromptTemplate {
+func CreateMonitorAgentPrompt(tools []tools.Tool, profile *config.HostProfile) prompts.PromptTemplate {
 	return prompts.PromptTemplate{
 		Template:       sysprmpts.SysPromptForAgentMode,
 		TemplateFormat: prompts.TemplateFormatGoTemplate,
@@ -66,6 +94,7 @@ func CreateMonitorAgentPrompt(tools []tools.Tool) prompts.PromptTemplate {
 				}
 				return info
 			}(),
+			"host_profile":      hostProfilePrompt(profile),
 			"tools":             toolDescriptions(tools),
 			"tool_names":        toolNames(tools),
 			"ShellScriptFormat": sysprmpts.ShellScriptFormat,
@@ -108,7 +137,11 @@ func (tbs *MonitorAgent) Plan(
 		fullInputs[key] = value
 	}
 
-	fullInputs["agent_scratchpad"] = constructScratchPad(intermediateSteps)
+	if tbs.ScratchPad != nil {
+		fullInputs["agent_scratchpad"] = tbs.ScratchPad.Construct(ctx, intermediateSteps)
+	} else {
+		fullInputs["agent_scratchpad"] = constructScratchPad(intermediateSteps)
+	}
 
 	var stream func(ctx context.Context, chunk []byte) error
 
@@ -190,15 +223,34 @@ func (tbs *MonitorAgent) parseOutput(output string) ([]schema.AgentAction, *sche
 	// Print the normalized output for debugging
 	logger.Info("Parsing output:", logger.Args("output", normalizedOutput))
 
-	// Improved regex to handle dynamic script names and multiline content
+	// Improved regex to handle dynamic script names and multiline content,
+	// one step could contain several independent actions.
 	r := regexp.MustCompile(`(?s)Action: (.*?)\nAction_input:`)
-	matches := r.FindStringSubmatch(normalizedOutput)
+	matches := r.FindAllStringSubmatchIndex(normalizedOutput, -1)
 	if len(matches) == 0 {
 		logger.Error("ai-agentic-monitor: Unable to parse the output,", logger.Args("output", normalizedOutput))
 		return nil, nil, fmt.Errorf("%w: %s", agents.ErrUnableToParseOutput, normalizedOutput)
 	}
-	logger.Info("Matched:", logger.Args("match content for tool name:", matches[0]))
-	return []schema.AgentAction{
-		{Tool: strings.TrimSpace(matches[1]), ToolInput: strings.TrimSpace(normalizedOutput), Log: normalizedOutput},
-	}, nil, nil
+
+	actions := make([]schema.AgentAction, 0, len(matches))
+	for i, match := range matches {
+		end := len(normalizedOutput)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		toolName := strings.TrimSpace(normalizedOutput[match[2]:match[3]])
+		logger.Info("Matched:", logger.Args("match content for tool name:", toolName))
+
+		// The log of the step is kept once for the scratchpad, with the first action.
+		log := ""
+		if i == 0 {
+			log = normalizedOutput
+		}
+		actions = append(actions, schema.AgentAction{
+			Tool:      toolName,
+			ToolInput: strings.TrimSpace(normalizedOutput[match[0]:end]),
+			Log:       log,
+		})
+	}
+	return actions, nil, nil
 }
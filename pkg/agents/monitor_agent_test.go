@@ -0,0 +1,55 @@
+package agents
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tmc/langchaingo/agents"
+)
+
+func TestParseOutput(t *testing.T) {
+	agent := &MonitorAgent{OutputKey: "output"}
+	output := "Thought: check the disk and the memory at once\r\n" +
+		"Action: DiskInspector\r\n" +
+		"Action_input: {\"path\": \"/var\"}\r\n" +
+		"Action: ScriptExecutor\r\n" +
+		"Action_input: ```bash\nfree -m\n```\n"
+
+	actions, finish, err := agent.parseOutput(output)
+	assert.NoError(t, err)
+	assert.Nil(t, finish)
+	if !assert.Len(t, actions, 2) {
+		return
+	}
+	normalized := "Thought: check the disk and the memory at once\n" +
+		"Action: DiskInspector\n" +
+		"Action_input: {\"path\": \"/var\"}\n" +
+		"Action: ScriptExecutor\n" +
+		"Action_input: ```bash\nfree -m\n```\n"
+
+	// the input of an action stops where the next action starts
+	assert.Equal(t, "DiskInspector", actions[0].Tool)
+	assert.Equal(t, "Action: DiskInspector\nAction_input: {\"path\": \"/var\"}", actions[0].ToolInput)
+	assert.Equal(t, "ScriptExecutor", actions[1].Tool)
+	assert.Equal(t, "Action: ScriptExecutor\nAction_input: ```bash\nfree -m\n```", actions[1].ToolInput)
+	// the output is logged once in the scratchpad, with the first action
+	assert.Equal(t, normalized, actions[0].Log)
+	assert.Empty(t, actions[1].Log)
+}
+
+func TestParseOutputFinalAnswer(t *testing.T) {
+	agent := &MonitorAgent{OutputKey: "output"}
+	output := "Thought: the disk is full\nFinal Answer: /var is full of logs"
+
+	actions, finish, err := agent.parseOutput(output)
+	assert.NoError(t, err)
+	assert.Nil(t, actions)
+	if assert.NotNil(t, finish) {
+		assert.Equal(t, " /var is full of logs", finish.ReturnValues["output"])
+		assert.Equal(t, output, finish.Log)
+	}
+
+	_, _, err = agent.parseOutput("Thought: I do not know what to do")
+	assert.True(t, errors.Is(err, agents.ErrUnableToParseOutput))
+}
@@ -0,0 +1,188 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pterm/pterm"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const (
+	// DefaultScratchPadTokens is the default token budget of the scratchpad
+	DefaultScratchPadTokens = 6000
+	// DefaultMaxObservationChars is the size above which an observation is truncated
+	DefaultMaxObservationChars = 4000
+	// DefaultKeepRecentSteps is the number of latest steps never summarized
+	DefaultKeepRecentSteps = 2
+
+	// observations are never truncated below this size
+	minObservationChars = 400
+
+	summarizeStepsPrompt = `You are helping a linux system monitor agent during a long investigation. Summarize the
+investigation steps below into a short list of facts. Keep every number, file path, process name, error message and
+conclusion which could matter for the investigation, drop the commands output which is not relevant.
+
+%s
+Steps:
+%s
+
+Summary:`
+)
+
+// ScratchPadCompressor keeps the scratchpad of the agent under a token budget,
+// large observations are truncated and the older steps are summarized by the LLM.
+type ScratchPadCompressor struct {
+	llm llms.Model
+	// Model is the model name used to count the tokens
+	Model string
+	// MaxTokens is the token budget of the scratchpad
+	MaxTokens int
+	// MaxObservationChars is the size above which an observation keeps only its head and tail
+	MaxObservationChars int
+	// KeepRecentSteps is the number of latest steps which are never summarized
+	KeepRecentSteps int
+
+	mu sync.Mutex
+	// summary of the first summarizedSteps steps, reused for the next turns
+	summary         string
+	summarizedSteps int
+}
+
+func NewScratchPadCompressor(llm llms.Model, model string, maxTokens int) *ScratchPadCompressor {
+	if maxTokens <= 0 {
+		maxTokens = DefaultScratchPadTokens
+	}
+	return &ScratchPadCompressor{
+		llm:                 llm,
+		Model:               model,
+		MaxTokens:           maxTokens,
+		MaxObservationChars: DefaultMaxObservationChars,
+		KeepRecentSteps:     DefaultKeepRecentSteps,
+	}
+}
+
+// Construct builds the scratchpad of the steps within the token budget
+func (c *ScratchPadCompressor) Construct(ctx context.Context, steps []schema.AgentStep) string {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	if len(steps) == 0 {
+		return ""
+	}
+
+	maxChars := c.MaxObservationChars
+	scratchPad := formatSteps(steps, maxChars) + "\n" + "Thought:"
+	tokens := c.CountTokens(scratchPad)
+	if tokens <= c.MaxTokens {
+		return scratchPad
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recent := steps
+	summary := ""
+	if older := turnStart(steps, len(steps)-c.KeepRecentSteps); older > 0 {
+		var err error
+		summary, err = c.summarize(ctx, steps[:older], maxChars)
+		if err != nil {
+			logger.Warn("ai-agentic-monitor: failed to summarize the scratchpad,", logger.Args("err", err.Error()))
+		} else {
+			recent = steps[older:]
+		}
+	}
+
+	// Shrink the observations of the remaining steps until the budget is met.
+	for {
+		scratchPad = formatSummary(summary) + formatSteps(recent, maxChars) + "\n" + "Thought:"
+		tokens = c.CountTokens(scratchPad)
+		if tokens <= c.MaxTokens || maxChars <= minObservationChars {
+			break
+		}
+		maxChars /= 2
+	}
+
+	logger.Info("ai-agentic-monitor: scratchpad compressed,", logger.Args("steps", len(steps), "summarized", len(steps)-len(recent), "tokens", tokens))
+	return scratchPad
+}
+
+// turnStart moves the index i back to the first step of its turn, the actions
+// planned in one turn are kept together since only the first one has the log.
+func turnStart(steps []schema.AgentStep, i int) int {
+	if i >= len(steps) {
+		return i
+	}
+	for i > 0 && steps[i].Action.Log == "" {
+		i--
+	}
+	return i
+}
+
+// CountTokens estimates the number of tokens of the text for the model
+func (c *ScratchPadCompressor) CountTokens(text string) int {
+	return llms.CountTokens(c.Model, text)
+}
+
+// summarize returns the summary of the steps, the summary of the previous turn is
+// extended with the new steps instead of summarizing all the steps again.
+func (c *ScratchPadCompressor) summarize(ctx context.Context, steps []schema.AgentStep, maxChars int) (string, error) {
+	if c.summarizedSteps == len(steps) {
+		return c.summary, nil
+	}
+
+	start := 0
+	previous := ""
+	if c.summarizedSteps > 0 && c.summarizedSteps < len(steps) {
+		start = c.summarizedSteps
+		previous = "Summary of the earlier steps:\n" + c.summary + "\n"
+	}
+
+	prompt := fmt.Sprintf(summarizeStepsPrompt, previous, formatSteps(steps[start:], maxChars))
+	summary, err := llms.GenerateFromSinglePrompt(ctx, c.llm, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	c.summary = strings.TrimSpace(summary)
+	c.summarizedSteps = len(steps)
+	return c.summary, nil
+}
+
+func formatSummary(summary string) string {
+	if summary == "" {
+		return ""
+	}
+	return "Summary of the previous steps:\n" + summary + "\n"
+}
+
+func formatSteps(steps []schema.AgentStep, maxChars int) string {
+	var scratchPad strings.Builder
+	for _, step := range steps {
+		scratchPad.WriteString(step.Action.Log)
+		scratchPad.WriteString("\nObservation: " + TruncateObservation(step.Observation, maxChars))
+	}
+	return scratchPad.String()
+}
+
+// TruncateObservation keeps the head and the tail of an observation longer than maxChars
+func TruncateObservation(observation string, maxChars int) string {
+	if maxChars <= 0 || len(observation) <= maxChars {
+		return observation
+	}
+
+	// Cut on rune boundaries to keep the observation valid UTF-8.
+	head := maxChars / 2
+	for head > 0 && !utf8.RuneStart(observation[head]) {
+		head--
+	}
+	tail := len(observation) - maxChars/2
+	for tail < len(observation) && !utf8.RuneStart(observation[tail]) {
+		tail++
+	}
+	return observation[:head] +
+		fmt.Sprintf("\n... [%d characters truncated] ...\n", tail-head) +
+		observation[tail:]
+}
@@ -0,0 +1,152 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+// summaryModel answers every prompt with a summary and records the prompts
+type summaryModel struct {
+	prompts []string
+}
+
+func (m *summaryModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var prompt strings.Builder
+	for _, message := range messages {
+		for _, part := range message.Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				prompt.WriteString(text.Text)
+			}
+		}
+	}
+	m.prompts = append(m.prompts, prompt.String())
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "the summary"}}}, nil
+}
+
+func (m *summaryModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// turnSteps returns the steps of turns of the given number of actions, only
+// the first action of a turn has the log of the turn.
+func turnSteps(actions ...int) []schema.AgentStep {
+	var steps []schema.AgentStep
+	for turn, count := range actions {
+		for i := 0; i < count; i++ {
+			step := schema.AgentStep{
+				Action:      schema.AgentAction{Tool: "ScriptExecutor", ToolInput: fmt.Sprintf("action %d.%d", turn, i)},
+				Observation: fmt.Sprintf("observation %d.%d ", turn, i) + strings.Repeat("x", 1000),
+			}
+			if i == 0 {
+				step.Action.Log = fmt.Sprintf("Thought: turn %d", turn)
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+func TestScratchPadConstruct(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions []int
+		// summarized are the turns given to the model, nil when nothing is summarized
+		summarized []int
+		kept       []int
+	}{
+		{name: "cut between turns", actions: []int{1, 1, 2}, summarized: []int{0, 1}, kept: []int{2}},
+		{name: "cut within a turn", actions: []int{1, 3}, summarized: []int{0}, kept: []int{1}},
+		{name: "cut within the first turn", actions: []int{4}, kept: []int{0}},
+		{name: "fewer steps than the recent ones", actions: []int{1}, kept: []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &summaryModel{}
+			compressor := NewScratchPadCompressor(model, "gpt-4", 10)
+			steps := turnSteps(tt.actions...)
+
+			scratchPad := compressor.Construct(context.Background(), steps)
+			assert.True(t, strings.HasSuffix(scratchPad, "\nThought:"))
+			if tt.summarized == nil {
+				assert.Empty(t, model.prompts)
+				assert.NotContains(t, scratchPad, "Summary of the previous steps")
+			} else if assert.Len(t, model.prompts, 1) {
+				assert.True(t, strings.HasPrefix(scratchPad, "Summary of the previous steps:\nthe summary\n"))
+				for _, turn := range tt.summarized {
+					assert.Contains(t, model.prompts[0], fmt.Sprintf("Thought: turn %d", turn))
+					assert.NotContains(t, scratchPad, fmt.Sprintf("Thought: turn %d", turn))
+				}
+			}
+			// the kept turns have their log and all the observations of their actions
+			for _, turn := range tt.kept {
+				assert.Contains(t, scratchPad, fmt.Sprintf("Thought: turn %d", turn))
+				for i := 0; i < tt.actions[turn]; i++ {
+					assert.Contains(t, scratchPad, fmt.Sprintf("observation %d.%d", turn, i))
+					if len(model.prompts) > 0 {
+						assert.NotContains(t, model.prompts[0], fmt.Sprintf("observation %d.%d", turn, i))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestScratchPadConstructWithinBudget(t *testing.T) {
+	model := &summaryModel{}
+	compressor := NewScratchPadCompressor(model, "gpt-4", 0)
+	steps := turnSteps(1, 2)
+
+	scratchPad := compressor.Construct(context.Background(), steps)
+	assert.Equal(t, constructScratchPad(steps), scratchPad)
+	assert.Empty(t, model.prompts)
+	assert.Empty(t, compressor.Construct(context.Background(), nil))
+}
+
+func TestScratchPadReusesSummary(t *testing.T) {
+	model := &summaryModel{}
+	compressor := NewScratchPadCompressor(model, "gpt-4", 10)
+	steps := turnSteps(1, 1, 1)
+
+	compressor.Construct(context.Background(), steps)
+	compressor.Construct(context.Background(), steps)
+	assert.Len(t, model.prompts, 1)
+
+	// the next turn only summarizes the new step with the previous summary
+	steps = append(steps, turnSteps(1, 1, 1, 1)[3])
+	compressor.Construct(context.Background(), steps)
+	if assert.Len(t, model.prompts, 2) {
+		assert.Contains(t, model.prompts[1], "Summary of the earlier steps:\nthe summary")
+		assert.NotContains(t, model.prompts[1], "observation 0.0")
+		assert.Contains(t, model.prompts[1], "observation 1.0")
+	}
+}
+
+func TestTruncateObservation(t *testing.T) {
+	tests := []struct {
+		name        string
+		observation string
+		maxChars    int
+		want        string
+	}{
+		{name: "short", observation: "0123456789", maxChars: 10, want: "0123456789"},
+		{name: "unlimited", observation: "0123456789", maxChars: 0, want: "0123456789"},
+		{name: "head and tail", observation: "0123456789", maxChars: 4, want: "01\n... [6 characters truncated] ...\n89"},
+		{name: "odd size", observation: "0123456789", maxChars: 5, want: "01\n... [6 characters truncated] ...\n89"},
+		// é is two bytes, the cuts move to the rune boundaries
+		{name: "runes", observation: "aéééééb", maxChars: 4, want: "a\n... [10 characters truncated] ...\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateObservation(tt.observation, tt.maxChars)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, utf8.ValidString(got))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/cgroup"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	// default and maximal number of cgroups returned by the CgroupInspector
+	defaultTopCgroups = 10
+	maxTopCgroups     = 50
+	// interval between the two samples measuring the cgroup rates
+	cgroupSampleInterval = time.Second
+)
+
+// CgroupInspector reports the resources of the cgroup v2 hierarchy, the
+// cgroups are mapped to their systemd units and containers.
+type CgroupInspector struct {
+	// FS is the proc filesystem where the cgroup2 mount is found
+	FS procfs.FS
+
+	once      sync.Once
+	collector *cgroup.Collector
+	err       error
+}
+
+var _ tools.Tool = &CgroupInspector{}
+
+// cgroupRequest is the input of the CgroupInspector
+type cgroupRequest struct {
+	Sort      string `json:"sort"`
+	Limit     int    `json:"limit"`
+	Path      string `json:"path"`
+	PID       int    `json:"pid"`
+	Unit      string `json:"unit"`
+	Container string `json:"container"`
+}
+
+// Description returns a string describing the CgroupInspector tool.
+func (c *CgroupInspector) Description() string {
+	return `Inspects the cgroup v2 hierarchy and returns JSON, use it to find which systemd unit or container uses
+	the resources or is throttled. The input is a JSON object, either {"sort": "cpu|memory|throttled|io|pids|oom|pressure",
+	"limit": 10} to list the top cgroups with their cpu usage and cpu throttling measured over one second, memory.current,
+	memory.high and memory.max, the oom_kill and high events, io rates, pids and pressure, or one of {"path":
+	"/system.slice/nginx.service"}, {"pid": 1234}, {"unit": "nginx.service"} or {"container": "container id prefix"} to
+	describe one cgroup. Every cgroup is mapped to its systemd unit, slice, container id and runtime.`
+}
+
+// Name returns the name of the tool.
+func (c *CgroupInspector) Name() string {
+	return "CgroupInspector"
+}
+
+func (c *CgroupInspector) Call(ctx context.Context, input string) (string, error) {
+	request := cgroupRequest{}
+	if match := jsonObjectRegexp.FindString(actionInput(input)); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return "", fmt.Errorf("invalid CgroupInspector input %s: %w", match, err)
+		}
+	}
+
+	c.once.Do(func() {
+		hierarchy, err := cgroup.NewHierarchy(c.FS)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.collector = cgroup.NewCollector(hierarchy)
+	})
+	if c.err != nil {
+		return "", c.err
+	}
+
+	if request.PID > 0 {
+		path, err := cgroup.PIDGroup(c.FS, request.PID)
+		if err != nil {
+			return "", err
+		}
+		request.Path = path
+	}
+	if request.Path != "" {
+		group, err := c.collector.Hierarchy().Group(request.Path)
+		if err != nil {
+			return "", err
+		}
+		return toJSON(group)
+	}
+	if request.Unit != "" || request.Container != "" {
+		return c.find(request.Unit, request.Container)
+	}
+
+	if request.Limit <= 0 {
+		request.Limit = defaultTopCgroups
+	}
+	top, err := c.collector.Top(ctx, strings.ToLower(request.Sort), min(request.Limit, maxTopCgroups), cgroupSampleInterval)
+	if err != nil {
+		return "", err
+	}
+	return toJSON(top)
+}
+
+// find describes the cgroups of the unit or of the container with the id prefix
+func (c *CgroupInspector) find(unit, container string) (string, error) {
+	usages, err := c.collector.Collect()
+	if err != nil {
+		return "", err
+	}
+	var groups []*cgroup.Group
+	for _, usage := range usages {
+		if unit != "" && usage.Unit != unit {
+			continue
+		}
+		if container != "" && (usage.ContainerID == "" || !strings.HasPrefix(usage.ContainerID, container)) {
+			continue
+		}
+		groups = append(groups, usage.Group)
+	}
+	if len(groups) == 0 {
+		return "", fmt.Errorf("no cgroup found for unit %q container %q", unit, container)
+	}
+	return toJSON(groups)
+}
@@ -0,0 +1,144 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/depmap"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// maxDependencyEdges is the maximal number of edges returned by the DependencyMap tool
+const maxDependencyEdges = 100
+
+// DependencyMap discovers the dependencies of the services of the host and
+// tells which of their connections are stalled and what changed since the
+// last discovery of the watcher, without changing the saved map.
+type DependencyMap struct {
+	Tracker *depmap.Tracker
+}
+
+var _ tools.Tool = &DependencyMap{}
+
+// dependencyMapRequest is the input of the DependencyMap tool
+type dependencyMapRequest struct {
+	Focus string `json:"focus"`
+}
+
+// dependencyMapResult is the output of the DependencyMap tool
+type dependencyMapResult struct {
+	Time    time.Time       `json:"time"`
+	Focus   string          `json:"focus,omitempty"`
+	Changes *depmap.Changes `json:"changes"`
+	Stalled []string        `json:"stalled,omitempty"`
+	Nodes   []*depmap.Node  `json:"nodes"`
+	Edges   []*depmap.Edge  `json:"edges"`
+	Omitted int             `json:"omitted_edges,omitempty"`
+}
+
+// Description returns a string describing the DependencyMap tool.
+func (d *DependencyMap) Description() string {
+	return fmt.Sprintf(`Discovers the dependency graph of the host from the established TCP connections, the listening sockets,
+	the systemd unit dependencies (requires, wants, after) and the parents of the processes. The nodes are the systemd services,
+	the containers and the processes, the remote endpoints they connect to (with the usual service of the port, like redis) and
+	the remote clients of the listening services. A connects edge has the number of connections by state, the bytes queued and
+	the stalled connections, which are retransmitting or still connecting: use it to find that an application is slow because
+	its connection to a database or a cache is stalled. The input is a JSON object {"focus": "redis"}, the optional focus keeps the
+	edges of the nodes whose id, name, process, service or listening port contains it. The changes since the last discovery of the dependency
+	watcher are the new edges, the established dependencies which disappeared and the connections which started stalling. At most %d edges are
+	returned, the stalled ones first.`, maxDependencyEdges)
+}
+
+// Name returns the name of the tool.
+func (d *DependencyMap) Name() string {
+	return "DependencyMap"
+}
+
+func (d *DependencyMap) Call(ctx context.Context, input string) (string, error) {
+	request, err := parseDependencyMapRequest(input)
+	if err != nil {
+		return "", err
+	}
+	// The map is only refreshed by the watcher, which must see every change.
+	graph, changes, err := d.Tracker.Discover(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	result := dependencyMapResult{Time: graph.Time, Focus: request.Focus, Changes: changes}
+	result.Nodes, result.Edges = focusGraph(graph, request.Focus)
+	sort.SliceStable(result.Edges, func(i, j int) bool {
+		return result.Edges[i].Stalled > result.Edges[j].Stalled
+	})
+	for _, edge := range result.Edges {
+		if edge.Stalled > 0 {
+			result.Stalled = append(result.Stalled, edge.String())
+		}
+	}
+	if len(result.Edges) > maxDependencyEdges {
+		result.Omitted = len(result.Edges) - maxDependencyEdges
+		result.Edges = result.Edges[:maxDependencyEdges]
+	}
+	return toJSON(result)
+}
+
+// focusGraph returns the nodes and the edges of the nodes matching the
+// focus, with the nodes at the other end of the edges
+func focusGraph(graph *depmap.Graph, focus string) ([]*depmap.Node, []*depmap.Edge) {
+	focus = strings.ToLower(focus)
+	if focus == "" {
+		return graph.Nodes, graph.Edges
+	}
+	matching := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		if nodeMatches(node, focus) {
+			matching[node.ID] = true
+		}
+	}
+	kept := make(map[string]bool)
+	edges := []*depmap.Edge{}
+	for _, edge := range graph.Edges {
+		if matching[edge.From] || matching[edge.To] {
+			edges = append(edges, edge)
+			kept[edge.From], kept[edge.To] = true, true
+		}
+	}
+	nodes := []*depmap.Node{}
+	for _, node := range graph.Nodes {
+		if matching[node.ID] || kept[node.ID] {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, edges
+}
+
+func nodeMatches(node *depmap.Node, focus string) bool {
+	values := append([]string{node.ID, node.Name, node.Service}, node.Processes...)
+	values = append(values, node.Listens...)
+	for _, value := range values {
+		if value != "" && strings.Contains(strings.ToLower(value), focus) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDependencyMapRequest reads the JSON request of the input, the input
+// is the focus when it is not valid JSON.
+func parseDependencyMapRequest(input string) (dependencyMapRequest, error) {
+	request := dependencyMapRequest{}
+	text := actionInput(input)
+	if match := jsonObjectRegexp.FindString(text); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return request, fmt.Errorf("invalid DependencyMap input %s: %w", match, err)
+		}
+	} else {
+		request.Focus = strings.Trim(text, "\"' ")
+	}
+	request.Focus = strings.TrimSpace(request.Focus)
+	return request, nil
+}
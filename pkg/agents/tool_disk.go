@@ -0,0 +1,132 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/disk"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	DiskActionUsage   = "usage"
+	DiskActionDirs    = "dirs"
+	DiskActionDeleted = "deleted"
+
+	// default and maximal depth of the largest directories
+	defaultDirsDepth = 2
+	maxDirsDepth     = 6
+	// default and maximal time spent walking the directories
+	defaultDirsBudget = 10 * time.Second
+	maxDirsBudget     = 60 * time.Second
+	// default and maximal number of directories or deleted files returned
+	defaultDiskLimit = 15
+	maxDiskLimit     = 100
+)
+
+// DiskInspector reports the usage of the filesystems, the largest directories
+// and the deleted files held open, without any shell involved.
+type DiskInspector struct {
+	// FS is the proc filesystem read by the tool
+	FS procfs.FS
+}
+
+var _ tools.Tool = &DiskInspector{}
+
+// diskRequest is the input of the DiskInspector
+type diskRequest struct {
+	Action  string `json:"action"`
+	Path    string `json:"path"`
+	Depth   int    `json:"depth"`
+	Timeout string `json:"timeout"`
+	Limit   int    `json:"limit"`
+}
+
+// Description returns a string describing the DiskInspector tool.
+func (d *DiskInspector) Description() string {
+	return `Inspects the disks and filesystems and returns JSON, use it instead of df, du or lsof when a disk is full.
+	The input is a JSON object with an action: {"action": "usage"} returns the bytes and inodes usage and the mount
+	options of every mounted filesystem, including the read-only remounts; {"action": "dirs", "path": "/var",
+	"depth": 2, "timeout": "10s", "limit": 15} returns the largest directories below the path, it stays on the filesystem
+	of the path and returns partial sizes when the timeout is reached; {"action": "deleted", "limit": 15} returns the
+	deleted files still held open by processes, their space is freed only when the process closes them or restarts.`
+}
+
+// Name returns the name of the tool.
+func (d *DiskInspector) Name() string {
+	return "DiskInspector"
+}
+
+func (d *DiskInspector) Call(ctx context.Context, input string) (string, error) {
+	request, err := parseDiskRequest(input)
+	if err != nil {
+		return "", err
+	}
+
+	switch request.Action {
+	case DiskActionUsage:
+		usage, err := disk.Usage(d.FS)
+		if err != nil {
+			return "", err
+		}
+		return toJSON(usage)
+	case DiskActionDirs:
+		budget := defaultDirsBudget
+		if request.Timeout != "" {
+			if budget, err = time.ParseDuration(request.Timeout); err != nil {
+				return "", fmt.Errorf("invalid timeout %q: %w", request.Timeout, err)
+			}
+		}
+		report, err := disk.LargestDirs(request.Path, request.Depth, min(budget, maxDirsBudget), request.Limit)
+		if err != nil {
+			return "", err
+		}
+		return toJSON(report)
+	case DiskActionDeleted:
+		files, err := d.FS.DeletedFiles()
+		if err != nil {
+			return "", err
+		}
+		if len(files) > request.Limit {
+			files = files[:request.Limit]
+		}
+		return toJSON(files)
+	}
+	return "", fmt.Errorf("unknown DiskInspector action %q, use usage, dirs or deleted", request.Action)
+}
+
+var diskActionRegexp = regexp.MustCompile(`(?i)\b(usage|dirs|deleted)\b`)
+
+// parseDiskRequest reads the JSON request of the input, the action is guessed
+// from the words of the input when it is not valid JSON.
+func parseDiskRequest(input string) (diskRequest, error) {
+	request := diskRequest{}
+	text := actionInput(input)
+	if match := jsonObjectRegexp.FindString(text); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return request, fmt.Errorf("invalid DiskInspector input %s: %w", match, err)
+		}
+	} else if match := diskActionRegexp.FindString(text); match != "" {
+		request.Action = match
+	}
+
+	if request.Action == "" {
+		request.Action = DiskActionUsage
+	}
+	if request.Path == "" {
+		request.Path = "/"
+	}
+	if request.Depth <= 0 {
+		request.Depth = defaultDirsDepth
+	}
+	request.Depth = min(request.Depth, maxDirsDepth)
+	if request.Limit <= 0 {
+		request.Limit = defaultDiskLimit
+	}
+	request.Limit = min(request.Limit, maxDiskLimit)
+	return request, nil
+}
@@ -0,0 +1,120 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/forecast"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// CapacityForecast projects when the filesystems, the inodes, the swap and
+// the memory of the leaking processes run out, from the trends of the
+// collected metrics.
+type CapacityForecast struct {
+	Store *metrics.Store
+}
+
+var _ tools.Tool = &CapacityForecast{}
+
+// capacityForecastRequest is the input of the CapacityForecast tool
+type capacityForecastRequest struct {
+	Kind    string `json:"kind"`
+	Horizon string `json:"horizon"`
+	History string `json:"history"`
+}
+
+// capacityForecast is a forecast returned by the CapacityForecast tool
+type capacityForecast struct {
+	forecast.Forecast
+	Message       string `json:"message"`
+	WithinHorizon bool   `json:"within_horizon"`
+}
+
+// capacityForecastResult is the output of the CapacityForecast tool
+type capacityForecastResult struct {
+	Horizon   string             `json:"horizon"`
+	Forecasts []capacityForecast `json:"forecasts"`
+}
+
+// Description returns a string describing the CapacityForecast tool.
+func (c *CapacityForecast) Description() string {
+	return fmt.Sprintf(`Forecasts when the resources of the host run out from the trends of the collected metrics: the filesystems
+	(disk.used_percent), their inodes (disk.inodes_used_percent), the swap (swap.used_percent) and the processes whose memory
+	grows steadily like a leak (process.rss_bytes, whose limit is their memory plus the available memory). The input is a JSON
+	object {"kind": "filesystem", "horizon": "72h", "history": "6h"}, all fields are optional: kind is one of %s,
+	horizon marks the exhaustions projected within it, %s by default, and history is the history the trends are fitted on.
+	Each forecast has the current value, the limit, the growth per hour, the r2 of the trend and the projected exhaustion time
+	with its 95%% confidence interval, the series without significant growth have no exhaustion time.`,
+		strings.Join(forecast.Kinds, ", "), config.DefaultForecastHorizon)
+}
+
+// Name returns the name of the tool.
+func (c *CapacityForecast) Name() string {
+	return "CapacityForecast"
+}
+
+func (c *CapacityForecast) Call(ctx context.Context, input string) (string, error) {
+	request, err := parseCapacityForecastRequest(input)
+	if err != nil {
+		return "", err
+	}
+	if stats := c.Store.Stats(); stats.Series == 0 {
+		return "", fmt.Errorf("no metrics sampled yet, the metrics are collected while the monitors run")
+	}
+	def := config.ForecastsDefinition{Horizon: request.Horizon, History: request.History}
+	horizon, err := def.GetHorizon()
+	if err != nil {
+		return "", err
+	}
+	history, err := def.GetHistory()
+	if err != nil {
+		return "", err
+	}
+	options := forecast.Options{History: history}
+	if request.Kind != "" {
+		options.Kinds = []string{request.Kind}
+	}
+
+	now := time.Now()
+	forecasts, err := forecast.Capacity(c.Store, now, options)
+	if err != nil {
+		return "", err
+	}
+	result := capacityForecastResult{Horizon: forecast.FormatDuration(horizon), Forecasts: []capacityForecast{}}
+	for _, found := range forecasts {
+		result.Forecasts = append(result.Forecasts, capacityForecast{
+			Forecast:      found,
+			Message:       found.Message(now),
+			WithinHorizon: found.Within(now.Add(horizon)),
+		})
+	}
+	if len(result.Forecasts) == 0 {
+		return "", fmt.Errorf("no forecast yet, the trends need %s of metrics", forecast.DefaultMinHistory)
+	}
+	return toJSON(result)
+}
+
+var forecastKindRegexp = regexp.MustCompile(`\b(` + strings.Join(forecast.Kinds, "|") + `)\b`)
+
+// parseCapacityForecastRequest reads the JSON request of the input, a kind
+// in the input is forecast with the defaults when the input is not valid JSON.
+func parseCapacityForecastRequest(input string) (capacityForecastRequest, error) {
+	request := capacityForecastRequest{}
+	text := actionInput(input)
+	if match := jsonObjectRegexp.FindString(text); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return request, fmt.Errorf("invalid CapacityForecast input %s: %w", match, err)
+		}
+	} else if match := forecastKindRegexp.FindString(strings.ToLower(text)); match != "" {
+		request.Kind = match
+	}
+	request.Kind = strings.ToLower(strings.TrimSpace(request.Kind))
+	return request, nil
+}
@@ -0,0 +1,59 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logmine"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// LogPatterns reports the log templates which are new, spiking or rare in the
+// lines written since its previous call, a compact view of huge logs.
+type LogPatterns struct {
+	Miner *logmine.Miner
+	// Consumer is the monitor calling the tool, each has its own previous call
+	Consumer string
+}
+
+var _ tools.Tool = &LogPatterns{}
+
+// logPatternsRequest is the input of the LogPatterns tool
+type logPatternsRequest struct {
+	Sources []string `json:"sources"`
+	Limit   int      `json:"limit"`
+}
+
+// Description returns a string describing the LogPatterns tool.
+func (l *LogPatterns) Description() string {
+	sources := ""
+	for _, source := range l.Miner.Sources() {
+		sources += source.Name + ", "
+	}
+	return fmt.Sprintf(`Tells what is unusual in the logs written since the previous call, use it before searching the logs.
+	The lines are clustered into templates where the variable parts are replaced by <*>, the result lists the templates
+	never seen before (new), the templates logged much more than usual (spikes), the rare ones and the most frequent ones,
+	each with an example line and its count. The first call only learns the usual templates of a source, it is listed as
+	baseline. The input is a JSON object {"sources": ["name"], "limit": 10}, all fields are optional. The sources are: %s`, sources)
+}
+
+// Name returns the name of the tool.
+func (l *LogPatterns) Name() string {
+	return "LogPatterns"
+}
+
+func (l *LogPatterns) Call(ctx context.Context, input string) (string, error) {
+	request := logPatternsRequest{}
+	if match := jsonObjectRegexp.FindString(actionInput(input)); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return "", fmt.Errorf("invalid LogPatterns input %s: %w", match, err)
+		}
+	}
+
+	report, err := l.Miner.Scan(l.Consumer, request.Sources, request.Limit)
+	if err != nil {
+		return "", err
+	}
+	return toJSON(report)
+}
@@ -0,0 +1,92 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logsearch"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// LogSearch searches the log sources allowed by the agent config and returns
+// bounded, deduplicated excerpts with their counts.
+type LogSearch struct {
+	Searcher *logsearch.Searcher
+}
+
+var _ tools.Tool = &LogSearch{}
+
+// logSearchRequest is the input of the LogSearch tool
+type logSearchRequest struct {
+	Pattern  string   `json:"pattern"`
+	Sources  []string `json:"sources"`
+	Since    string   `json:"since"`
+	Until    string   `json:"until"`
+	Severity string   `json:"severity"`
+	Fields   []string `json:"fields"`
+	Limit    int      `json:"limit"`
+}
+
+// Description returns a string describing the LogSearch tool.
+func (l *LogSearch) Description() string {
+	sources := ""
+	for _, source := range l.Searcher.Sources() {
+		sources += fmt.Sprintf("%s (%s %s), ", source.Name, source.Type, source.Path)
+	}
+	return fmt.Sprintf(`Searches the logs including the rotated and gzip files, use it instead of grep.
+	The input is a JSON object {"pattern": "Go regexp", "sources": ["name"], "since": "2h", "until": "RFC3339 or duration",
+	"severity": "warning", "fields": ["status>=500"], "limit": 20}, all fields are optional. since and until are durations
+	before now or RFC3339 timestamps, severity is the minimal level among debug, info, notice, warning, error, critical,
+	alert and emerg. The lines are parsed as syslog, access log, JSON or kernel log, fields are conditions on the parsed
+	fields with the operators = != =~ > >= < <=, like status>=500, request_time>1 or path=~^/api. Similar lines are
+	deduplicated and returned with their count and fields. The allowed sources are: %s`, sources)
+}
+
+// Name returns the name of the tool.
+func (l *LogSearch) Name() string {
+	return "LogSearch"
+}
+
+func (l *LogSearch) Call(ctx context.Context, input string) (string, error) {
+	request := logSearchRequest{}
+	if match := jsonObjectRegexp.FindString(actionInput(input)); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return "", fmt.Errorf("invalid LogSearch input %s: %w", match, err)
+		}
+	}
+
+	now := time.Now()
+	since, err := logsearch.ParseTime(request.Since, now)
+	if err != nil {
+		return "", err
+	}
+	until, err := logsearch.ParseTime(request.Until, now)
+	if err != nil {
+		return "", err
+	}
+
+	filters := make([]logsearch.FieldFilter, 0, len(request.Fields))
+	for _, expr := range request.Fields {
+		filter, err := logsearch.ParseFieldFilter(expr)
+		if err != nil {
+			return "", err
+		}
+		filters = append(filters, filter)
+	}
+
+	result, err := l.Searcher.Search(logsearch.Query{
+		Pattern:  request.Pattern,
+		Sources:  request.Sources,
+		Since:    since,
+		Until:    until,
+		Severity: request.Severity,
+		Fields:   filters,
+		Limit:    request.Limit,
+	})
+	if err != nil {
+		return "", err
+	}
+	return toJSON(result)
+}
@@ -0,0 +1,211 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logsearch"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	// default range of a metrics query
+	defaultMetricsRange = time.Hour
+	// maximal number of rows of the table of a series, the step is enlarged to fit
+	maxMetricsRows = 60
+	// maximal number of series returned by a query
+	maxMetricsSeries = 20
+)
+
+// MetricsQuery queries the history of the system metrics sampled by the
+// collector, so the agent could see the trends instead of a single reading.
+type MetricsQuery struct {
+	Store *metrics.Store
+	// Interval is the time between two samples of the collector
+	Interval time.Duration
+}
+
+var _ tools.Tool = &MetricsQuery{}
+
+// metricsQueryRequest is the input of the MetricsQuery tool
+type metricsQueryRequest struct {
+	Metric    string            `json:"metric"`
+	Labels    map[string]string `json:"labels"`
+	Since     string            `json:"since"`
+	Until     string            `json:"until"`
+	Step      string            `json:"step"`
+	Aggregate string            `json:"aggregate"`
+	Summary   bool              `json:"summary"`
+}
+
+// metricsSeries is a series returned by the MetricsQuery tool
+type metricsSeries struct {
+	Name    string          `json:"name"`
+	Labels  metrics.Labels  `json:"labels,omitempty"`
+	Summary metrics.Summary `json:"summary"`
+	// Table is a "time value" row by point
+	Table string `json:"table,omitempty"`
+}
+
+// metricsQueryResult is the output of the MetricsQuery tool
+type metricsQueryResult struct {
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Step      string          `json:"step"`
+	Aggregate string          `json:"aggregate"`
+	Series    []metricsSeries `json:"series"`
+	Truncated int             `json:"truncated,omitempty"`
+}
+
+// Description returns a string describing the MetricsQuery tool.
+func (m *MetricsQuery) Description() string {
+	return fmt.Sprintf(`Queries the history of the system metrics sampled every %s, use it to see trends and to correlate a spike with
+	the logs instead of free, vmstat or iostat. The input is a JSON object {"metric": "cpu.usage_percent", "labels": {"device": "sda"},
+	"since": "1h", "until": "RFC3339 or duration", "step": "1m", "aggregate": "max", "summary": false}, only metric is required.
+	aggregate combines the samples of a step among avg, min, max, p95, last, sum and rate (change per second), the step is enlarged
+	to return at most %d rows. Each series is returned with the count, min, max, avg, p95, first and last values of its samples
+	and the time of its maximum, and a table of "time value" rows by step unless summary is true. An empty metric or a prefix like "disk."
+	lists the available series. The metrics are procs.*, cpu.*, memory.*, swap.*, load.*, disk.* (device label, or mount
+	label for disk.used_percent, disk.avail_bytes and disk.inodes_used_percent, sampled every 5m for the network
+	filesystems), net.* (interface label), fd.*,
+	pressure.* (resource label), cgroup.* (cgroup label, like /system.slice/nginx.service, for the cpu, throttling, memory
+	and io of the slices, units and containers) and process.rss_bytes (pid and comm labels, the largest processes running for 5m).`, m.Interval, maxMetricsRows)
+}
+
+// Name returns the name of the tool.
+func (m *MetricsQuery) Name() string {
+	return "MetricsQuery"
+}
+
+func (m *MetricsQuery) Call(ctx context.Context, input string) (string, error) {
+	request, err := parseMetricsQueryRequest(input)
+	if err != nil {
+		return "", err
+	}
+	if stats := m.Store.Stats(); stats.Series == 0 {
+		return "", fmt.Errorf("no metrics sampled yet, the metrics are collected while the monitors run")
+	}
+	if request.Metric == "" || strings.HasSuffix(request.Metric, ".") || strings.HasSuffix(request.Metric, "*") {
+		return toJSON(m.Store.List(strings.TrimSuffix(request.Metric, "*")))
+	}
+
+	now := time.Now()
+	from, err := logsearch.ParseTime(request.Since, now)
+	if err != nil {
+		return "", err
+	}
+	if from.IsZero() {
+		from = now.Add(-defaultMetricsRange)
+	}
+	to, err := logsearch.ParseTime(request.Until, now)
+	if err != nil {
+		return "", err
+	}
+	if to.IsZero() {
+		to = now
+	}
+	step, err := m.step(request.Step, from, to)
+	if err != nil {
+		return "", err
+	}
+	aggregate := strings.ToLower(request.Aggregate)
+	if aggregate == "" {
+		aggregate = metrics.AggregateAvg
+	}
+
+	// The summary is computed on the raw samples so a short spike is not
+	// averaged out by the step, only the table is downsampled.
+	rawAggregate := ""
+	if aggregate == metrics.AggregateRate {
+		rawAggregate = metrics.AggregateRate
+	} else if err := metrics.ValidateAggregate(aggregate); err != nil {
+		return "", err
+	}
+	results, err := m.Store.Query(metrics.Query{
+		Name:      request.Metric,
+		Labels:    metrics.Labels(request.Labels),
+		From:      from,
+		To:        to,
+		Aggregate: rawAggregate,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", fmt.Errorf("no series of the metric %q with the labels %s, use an empty metric to list the series",
+			request.Metric, metrics.Labels(request.Labels))
+	}
+
+	result := metricsQueryResult{From: from, To: to, Step: step.String(), Aggregate: aggregate, Series: []metricsSeries{}}
+	if len(results) > maxMetricsSeries {
+		result.Truncated = len(results) - maxMetricsSeries
+		results = results[:maxMetricsSeries]
+	}
+	for _, series := range results {
+		current := metricsSeries{Name: series.Name, Labels: series.Labels, Summary: metrics.Summarize(series.Points)}
+		if !request.Summary {
+			current.Table = metricsTable(metrics.Downsample(series.Points, from, step, aggregate), to.Sub(from) >= 24*time.Hour)
+		}
+		result.Series = append(result.Series, current)
+	}
+	return toJSON(result)
+}
+
+// step returns the requested step, enlarged to keep the table of a series
+// within maxMetricsRows rows, never shorter than the interval.
+func (m *MetricsQuery) step(value string, from, to time.Time) (time.Duration, error) {
+	step := (to.Sub(from) + maxMetricsRows - 1) / maxMetricsRows
+	step = step.Round(time.Second)
+	if step < m.Interval {
+		step = m.Interval
+	}
+	if value != "" {
+		requested, err := time.ParseDuration(value)
+		if err != nil || requested < 0 {
+			return 0, fmt.Errorf("invalid step %q, use a duration like 1m", value)
+		}
+		step = max(step, requested)
+	}
+	return step, nil
+}
+
+// metricsTable returns a "time value" row by point, with the date only when
+// the range spans several days.
+func metricsTable(points []metrics.Point, withDate bool) string {
+	layout := "15:04:05"
+	if withDate {
+		layout = "01-02 15:04:05"
+	}
+	var table strings.Builder
+	for _, point := range points {
+		table.WriteString(point.Time.Local().Format(layout))
+		table.WriteByte(' ')
+		table.WriteString(strconv.FormatFloat(point.Value, 'f', -1, 64))
+		table.WriteByte('\n')
+	}
+	return table.String()
+}
+
+var metricNameRegexp = regexp.MustCompile(`\b[a-z]+\.[a-z0-9_]*`)
+
+// parseMetricsQueryRequest reads the JSON request of the input, a metric name
+// in the input is queried with the defaults when the input is not valid JSON.
+func parseMetricsQueryRequest(input string) (metricsQueryRequest, error) {
+	request := metricsQueryRequest{}
+	text := actionInput(input)
+	if match := jsonObjectRegexp.FindString(text); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return request, fmt.Errorf("invalid MetricsQuery input %s: %w", match, err)
+		}
+	} else if match := metricNameRegexp.FindString(strings.ToLower(text)); match != "" {
+		request.Metric = match
+	}
+	request.Metric = strings.TrimSpace(request.Metric)
+	return request, nil
+}
@@ -0,0 +1,58 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+func TestMetricsQuerySummarizesSamples(t *testing.T) {
+	store := metrics.NewStore(1000, 0)
+	now := time.Now()
+	// nine minutes of samples every second, with a spike of one sample
+	for i := 540; i > 0; i-- {
+		value := 10.0
+		if i == 300 {
+			value = 95
+		}
+		store.Append("cpu.usage_percent", nil, now.Add(-time.Duration(i)*time.Second), value)
+	}
+	tool := &MetricsQuery{Store: store, Interval: time.Second}
+
+	tests := []struct {
+		name  string
+		input string
+		step  string
+	}{
+		{"automatic step", `{"metric": "cpu.usage_percent", "since": "10m"}`, "10s"},
+		{"step too short", `{"metric": "cpu.usage_percent", "since": "10m", "step": "1s"}`, "10s"},
+		{"longer step", `{"metric": "cpu.usage_percent", "since": "10m", "step": "1m"}`, "1m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := tool.Call(context.Background(), tt.input)
+			assert.NoError(t, err)
+			var result metricsQueryResult
+			assert.NoError(t, json.Unmarshal([]byte(output), &result))
+			assert.Equal(t, tt.step, result.Step)
+			if !assert.Len(t, result.Series, 1) {
+				return
+			}
+			series := result.Series[0]
+			// the spike is averaged out of the table but not of the summary
+			assert.Equal(t, 95.0, series.Summary.Max)
+			assert.Equal(t, 540, series.Summary.Count)
+			assert.NotContains(t, series.Table, " 95\n")
+			assert.LessOrEqual(t, strings.Count(series.Table, "\n"), maxMetricsRows+1)
+		})
+	}
+
+	_, err := tool.Call(context.Background(), `{"metric": "cpu.usage_percent", "aggregate": "median"}`)
+	assert.Error(t, err)
+}
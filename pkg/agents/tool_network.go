@@ -0,0 +1,122 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	NetworkActionSummary     = "summary"
+	NetworkActionListeners   = "listeners"
+	NetworkActionConnections = "connections"
+
+	// default and maximal number of connections returned
+	defaultConnections = 30
+	maxConnections     = 200
+	// interval between the two samples measuring the retransmissions
+	networkSampleInterval = time.Second
+)
+
+// NetworkInspector reports the state of the network stack from /proc/net
+type NetworkInspector struct {
+	// FS is the proc filesystem read by the tool
+	FS procfs.FS
+}
+
+var _ tools.Tool = &NetworkInspector{}
+
+// networkRequest is the input of the NetworkInspector
+type networkRequest struct {
+	Action string `json:"action"`
+	State  string `json:"state"`
+	Port   int    `json:"port"`
+	Limit  int    `json:"limit"`
+}
+
+// connectionsResult is the result of the connections action
+type connectionsResult struct {
+	Total       int                 `json:"total"`
+	Connections []procfs.Connection `json:"connections"`
+}
+
+// Description returns a string describing the NetworkInspector tool.
+func (n *NetworkInspector) Description() string {
+	return `Inspects the network stack from /proc/net and returns JSON, use it instead of ss, netstat or ip.
+	The input is a JSON object with an action: {"action": "summary"} returns the histogram of the TCP states, the
+	processes holding CLOSE_WAIT sockets, the socket counters, the TCP and UDP counters like RetransSegs and
+	ListenOverflows, the retransmission rate measured over one second and the errors and drops of every interface;
+	{"action": "listeners"} returns the listening TCP, UDP and unix sockets with their accept queue and owning process;
+	{"action": "connections", "state": "CLOSE_WAIT", "port": 443, "limit": 30} returns the connections with their owning
+	process, state and port are optional filters.`
+}
+
+// Name returns the name of the tool.
+func (n *NetworkInspector) Name() string {
+	return "NetworkInspector"
+}
+
+func (n *NetworkInspector) Call(ctx context.Context, input string) (string, error) {
+	request, err := parseNetworkRequest(input)
+	if err != nil {
+		return "", err
+	}
+
+	switch request.Action {
+	case NetworkActionSummary:
+		summary, err := n.FS.NetworkSummary(networkSampleInterval)
+		if err != nil {
+			return "", err
+		}
+		return toJSON(summary)
+	case NetworkActionListeners:
+		listeners, err := n.FS.Listeners()
+		if err != nil {
+			return "", err
+		}
+		return toJSON(listeners)
+	case NetworkActionConnections:
+		connections, err := n.FS.Connections(request.State, request.Port)
+		if err != nil {
+			return "", err
+		}
+		result := connectionsResult{Total: len(connections), Connections: connections}
+		if len(connections) > request.Limit {
+			result.Connections = connections[:request.Limit]
+		}
+		return toJSON(result)
+	}
+	return "", fmt.Errorf("unknown NetworkInspector action %q, use summary, listeners or connections", request.Action)
+}
+
+var networkActionRegexp = regexp.MustCompile(`(?i)\b(summary|listeners|connections)\b`)
+
+// parseNetworkRequest reads the JSON request of the input, the action is
+// guessed from the words of the input when it is not valid JSON.
+func parseNetworkRequest(input string) (networkRequest, error) {
+	request := networkRequest{}
+	text := actionInput(input)
+	if match := jsonObjectRegexp.FindString(text); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return request, fmt.Errorf("invalid NetworkInspector input %s: %w", match, err)
+		}
+	} else if match := networkActionRegexp.FindString(text); match != "" {
+		request.Action = strings.ToLower(match)
+	}
+
+	if request.Action == "" {
+		request.Action = NetworkActionSummary
+	}
+	request.State = strings.ToUpper(request.State)
+	if request.Limit <= 0 {
+		request.Limit = defaultConnections
+	}
+	request.Limit = min(request.Limit, maxConnections)
+	return request, nil
+}
@@ -0,0 +1,67 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/probe"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// Probe runs a synthetic HTTP, TCP, TLS or DNS probe, like the checks of the
+// monitors config, so the agent could verify a service from the outside.
+type Probe struct{}
+
+var _ tools.Tool = &Probe{}
+
+// Description returns a string describing the Probe tool.
+func (p *Probe) Description() string {
+	return `Probes a service from this host and returns the result as JSON with the latency, use it instead of curl, nc,
+	openssl or dig. The input is a JSON object with a type and a target: {"type": "http", "target": "https://example.com/health",
+	"expect_status": [200], "body_contains": "ok"} sends a GET request and checks the status and the body, "body_matches"
+	takes a regular expression and "headers" adds request headers; {"type": "tcp", "target": "db:5432"} connects to the port;
+	{"type": "tls", "target": "example.com:443", "min_valid_days": 14} returns the certificate chain, its expiry and
+	whether it is trusted; {"type": "dns", "target": "example.com", "record_type": "A", "resolver": "10.0.0.2:53",
+	"expect": ["93.184.216.34"]} resolves the name with the resolver, the system resolver when not given.
+	Every probe accepts a "timeout" like "5s".`
+}
+
+// Name returns the name of the tool.
+func (p *Probe) Name() string {
+	return "Probe"
+}
+
+func (p *Probe) Call(ctx context.Context, input string) (string, error) {
+	spec, err := parseProbeSpec(input)
+	if err != nil {
+		return "", err
+	}
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+	return toJSON(probe.Run(ctx, spec))
+}
+
+var urlRegexp = regexp.MustCompile(`https?://\S+`)
+
+// parseProbeSpec reads the JSON spec of the input, an URL in the input is
+// probed with a http probe when the input is not valid JSON.
+func parseProbeSpec(input string) (config.ProbeSpec, error) {
+	spec := config.ProbeSpec{}
+	text := actionInput(input)
+	if match := jsonObjectRegexp.FindString(text); match != "" {
+		if err := json.Unmarshal([]byte(match), &spec); err != nil {
+			return spec, fmt.Errorf("invalid Probe input %s: %w", match, err)
+		}
+	} else if match := urlRegexp.FindString(text); match != "" {
+		spec.Type = config.ProbeHTTP
+		spec.Target = match
+	}
+	if spec.Type == "" && spec.Target != "" {
+		spec.Type = config.ProbeHTTP
+	}
+	return spec, nil
+}
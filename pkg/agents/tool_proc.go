@@ -0,0 +1,158 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ProcReader is a read-only tool returning the content of a /proc or /sys file
+// as compact JSON, no shell is involved.
+type ProcReader struct {
+	name        string
+	description string
+	read        func(fs procfs.FS, input string) (any, error)
+	// FS is the proc and sys filesystems read by the tool
+	FS procfs.FS
+}
+
+var _ tools.Tool = &ProcReader{}
+
+// Description returns a string describing the ProcReader tool.
+func (p *ProcReader) Description() string {
+	return p.description
+}
+
+// Name returns the name of the tool.
+func (p *ProcReader) Name() string {
+	return p.name
+}
+
+func (p *ProcReader) Call(ctx context.Context, input string) (string, error) {
+	value, err := p.read(p.FS, input)
+	if err != nil {
+		return "", err
+	}
+	return toJSON(value)
+}
+
+// NewProcTools returns the read-only /proc tools reading the given filesystems
+func NewProcTools(fs procfs.FS) []tools.Tool {
+	return []tools.Tool{
+		&ProcReader{
+			name:        "ProcMeminfo",
+			description: `Returns /proc/meminfo as JSON, values are in kB. The input is ignored.`,
+			read:        func(fs procfs.FS, _ string) (any, error) { return fs.MemInfo() },
+			FS:          fs,
+		},
+		&ProcReader{
+			name:        "ProcLoadavg",
+			description: `Returns /proc/loadavg as JSON with the 1, 5 and 15 minutes load and the running/total tasks. The input is ignored.`,
+			read:        func(fs procfs.FS, _ string) (any, error) { return fs.LoadAvg() },
+			FS:          fs,
+		},
+		&ProcReader{
+			name:        "ProcStat",
+			description: `Returns /proc/stat as JSON, the cpu times are cumulative in USER_HZ, also context switches, boot time and running/blocked processes. The input is ignored.`,
+			read:        func(fs procfs.FS, _ string) (any, error) { return fs.Stat() },
+			FS:          fs,
+		},
+		&ProcReader{
+			name:        "ProcVmstat",
+			description: `Returns the counters of /proc/vmstat as JSON, like pgmajfault, pswpin, pswpout or oom_kill. The input could list counter name prefixes to return only these counters.`,
+			read:        readVMStat,
+			FS:          fs,
+		},
+		&ProcReader{
+			name:        "ProcPressure",
+			description: `Returns the pressure stall information of /proc/pressure as JSON, the averages are the percentage of time tasks were stalled. The input could list the resources among cpu, memory and io, all by default.`,
+			read:        readPressure,
+			FS:          fs,
+		},
+		&ProcReader{
+			name:        "ProcDiskstats",
+			description: `Returns the cumulative IO counters of the block devices from /proc/diskstats as JSON, times are in ms. The input is ignored.`,
+			read:        func(fs procfs.FS, _ string) (any, error) { return fs.DiskStats() },
+			FS:          fs,
+		},
+		&ProcReader{
+			name:        "ProcNetDev",
+			description: `Returns the cumulative counters of the network interfaces from /proc/net/dev with their state and speed as JSON. The input is ignored.`,
+			read:        func(fs procfs.FS, _ string) (any, error) { return fs.NetDev() },
+			FS:          fs,
+		},
+	}
+}
+
+func readVMStat(fs procfs.FS, input string) (any, error) {
+	counters, err := fs.VMStat()
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := inputWords(input)
+	if len(prefixes) == 0 {
+		return counters, nil
+	}
+	selected := make(map[string]uint64)
+	for name, value := range counters {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				selected[name] = value
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+func readPressure(fs procfs.FS, input string) (any, error) {
+	resources := make([]string, 0, len(procfs.PressureResources))
+	for _, word := range inputWords(input) {
+		for _, resource := range procfs.PressureResources {
+			if word == resource {
+				resources = append(resources, resource)
+			}
+		}
+	}
+	if len(resources) == 0 {
+		resources = procfs.PressureResources
+	}
+
+	pressures := make(map[string]procfs.Pressure, len(resources))
+	for _, resource := range resources {
+		pressure, err := fs.Pressure(resource)
+		if err != nil {
+			return nil, fmt.Errorf("pressure stall information is not available: %w", err)
+		}
+		pressures[resource] = pressure
+	}
+	return pressures, nil
+}
+
+// actionInput returns the tool input without the action header written by the agent
+func actionInput(input string) string {
+	if _, after, ok := strings.Cut(input, "Action_input:"); ok {
+		return after
+	}
+	return input
+}
+
+// inputWords returns the lower case words of the tool input
+func inputWords(input string) []string {
+	input = strings.NewReplacer(",", " ", "`", " ", "\"", " ").Replace(actionInput(input))
+	return strings.Fields(strings.ToLower(input))
+}
+
+// toJSON returns the compact JSON of the value
+func toJSON(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the tool output: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,109 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	// default number of processes returned by the ProcessInspector
+	defaultTopProcesses = 10
+	// maximal number of processes returned by the ProcessInspector
+	maxTopProcesses = 50
+	// interval between the two samples measuring the cpu and io rates
+	processSampleInterval = 500 * time.Millisecond
+)
+
+// ProcessInspector lists the top processes or describes one process from /proc/[pid]
+type ProcessInspector struct {
+	// FS is the proc filesystem read by the tool
+	FS procfs.FS
+}
+
+var _ tools.Tool = &ProcessInspector{}
+
+// processRequest is the input of the ProcessInspector
+type processRequest struct {
+	Sort  string `json:"sort"`
+	Limit int    `json:"limit"`
+	PID   int    `json:"pid"`
+}
+
+// Description returns a string describing the ProcessInspector tool.
+func (p *ProcessInspector) Description() string {
+	return `Inspects the processes from /proc and returns JSON, use it instead of ps or top.
+	The input is a JSON object, either {"sort": "cpu|rss|fds|io", "limit": 10} to list the top processes
+	sorted by cpu usage, resident memory, open file descriptors or disk IO rate, or {"pid": 1234} to describe
+	one process with its cmdline, cgroup, threads, state, limits, IO counters and parent chain.`
+}
+
+// Name returns the name of the tool.
+func (p *ProcessInspector) Name() string {
+	return "ProcessInspector"
+}
+
+func (p *ProcessInspector) Call(ctx context.Context, input string) (string, error) {
+	request, err := parseProcessRequest(input)
+	if err != nil {
+		return "", err
+	}
+
+	if request.PID > 0 {
+		detail, err := p.FS.DescribeProcess(request.PID)
+		if err != nil {
+			return "", err
+		}
+		return toJSON(detail)
+	}
+
+	top, err := p.FS.TopProcesses(ctx, request.Sort, request.Limit, processSampleInterval)
+	if err != nil {
+		return "", err
+	}
+	return toJSON(top)
+}
+
+var (
+	jsonObjectRegexp = regexp.MustCompile(`(?s)\{.*\}`)
+	pidRegexp        = regexp.MustCompile(`(?i)\bpid\D{0,3}(\d+)`)
+	sortRegexp       = regexp.MustCompile(`(?i)\b(cpu|rss|fds|io)\b`)
+	limitRegexp      = regexp.MustCompile(`(?i)\b(?:top|limit)\D{0,3}(\d+)`)
+)
+
+// parseProcessRequest reads the JSON request of the input, the request is
+// guessed from the words of the input when it is not valid JSON.
+func parseProcessRequest(input string) (processRequest, error) {
+	request := processRequest{}
+	if match := jsonObjectRegexp.FindString(actionInput(input)); match != "" {
+		if err := json.Unmarshal([]byte(match), &request); err != nil {
+			return request, fmt.Errorf("invalid ProcessInspector input %s: %w", match, err)
+		}
+	} else {
+		text := actionInput(input)
+		if match := pidRegexp.FindStringSubmatch(text); match != nil {
+			request.PID, _ = strconv.Atoi(match[1])
+		}
+		if match := sortRegexp.FindStringSubmatch(text); match != nil {
+			request.Sort = match[1]
+		}
+		if match := limitRegexp.FindStringSubmatch(text); match != nil {
+			request.Limit, _ = strconv.Atoi(match[1])
+		}
+	}
+
+	if request.Sort == "" {
+		request.Sort = procfs.SortByCPU
+	}
+	if request.Limit <= 0 {
+		request.Limit = defaultTopProcesses
+	}
+	request.Limit = min(request.Limit, maxTopProcesses)
+	return request, nil
+}
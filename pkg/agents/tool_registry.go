@@ -0,0 +1,194 @@
+package agents
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/collector"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/depmap"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logmine"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logsearch"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/session"
+	"github.com/pterm/pterm"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// ToolRegistry creates the tools of the agent from the agent config
+type ToolRegistry struct {
+	fs          procfs.FS
+	agentConfig *config.AgentConfig
+	// the miner is shared by the tools so the scans of the monitors do not overlap
+	miner *logmine.Miner
+	// the collector samples the metrics when it runs, in the daemon only
+	collector *collector.Collector
+	// the tracker of the dependencies is shared by the watcher and the tool
+	tracker *depmap.Tracker
+	// the store of the sessions is shared by the executors so its lock
+	// serializes the saves and prunes of the concurrent monitors
+	sessions *session.Store
+}
+
+// NewToolRegistry creates a registry reading the live system, a nil config
+// gives the tools which do not need any configuration.
+func NewToolRegistry(agentConfig *config.AgentConfig) *ToolRegistry {
+	if agentConfig == nil {
+		agentConfig = &config.AgentConfig{}
+	}
+	statePath := filepath.Join(os.Getenv("HOME"), Catchdir, LogPatternsFile)
+	fs := procfs.DefaultFS()
+	// The settings were validated with the config, the defaults are used otherwise.
+	interval, err := agentConfig.Metrics.GetInterval()
+	if err != nil {
+		interval = config.DefaultMetricsInterval
+	}
+	retention, err := agentConfig.Metrics.GetRetention()
+	if err != nil {
+		retention = config.DefaultMetricsRetention
+	}
+	store := metrics.NewStore(int(retention/interval), agentConfig.Metrics.MaxSeries)
+	return &ToolRegistry{
+		fs:          fs,
+		agentConfig: agentConfig,
+		miner:       logmine.NewMiner(agentConfig.LogSources, agentConfig.LogMining, statePath),
+		collector:   collector.New(fs, store, interval, retention),
+		tracker:     depmap.NewTracker(depmap.NewDiscoverer(fs), filepath.Join(os.Getenv("HOME"), Catchdir, DependencyMapFile)),
+		sessions:    DefaultSessionStore(),
+	}
+}
+
+// MetricsCollector returns the collector of the system metrics, it must be
+// run for the metrics to be sampled.
+func (r *ToolRegistry) MetricsCollector() *collector.Collector {
+	return r.collector
+}
+
+// DependencyTracker returns the tracker of the dependency map of the host
+func (r *ToolRegistry) DependencyTracker() *depmap.Tracker {
+	return r.tracker
+}
+
+// SessionStore returns the store of the investigation sessions
+func (r *ToolRegistry) SessionStore() *session.Store {
+	return r.sessions
+}
+
+// SessionsSettings returns the retention of the investigation sessions
+func (r *ToolRegistry) SessionsSettings() config.SessionsSettings {
+	return r.agentConfig.Sessions
+}
+
+// HostProfile returns the profile of the host, nil when it is not described
+func (r *ToolRegistry) HostProfile() *config.HostProfile {
+	return r.agentConfig.Host
+}
+
+// DefaultTools returns the tools used by monitors which do not list any tool,
+// the Probe tool reaches any address so it is not given to untrusted models.
+func (r *ToolRegistry) DefaultTools() []tools.Tool {
+	return append([]tools.Tool{&ScriptExecutor{}, &Probe{}}, r.ReadOnlyTools()...)
+}
+
+// ReadOnlyTools returns the tools which only read the system, without any shell
+// or network access involved, they are the only tools given to untrusted models.
+func (r *ToolRegistry) ReadOnlyTools() []tools.Tool {
+	readOnly := append(NewProcTools(r.fs),
+		&ProcessInspector{FS: r.fs},
+		&DiskInspector{FS: r.fs},
+		&NetworkInspector{FS: r.fs},
+		&CgroupInspector{FS: r.fs},
+		&MetricsQuery{Store: r.collector.Store(), Interval: r.collector.Interval()},
+		&CapacityForecast{Store: r.collector.Store()},
+		&DependencyMap{Tracker: r.tracker},
+	)
+	if len(r.agentConfig.LogSources) > 0 {
+		readOnly = append(readOnly, &LogSearch{Searcher: logsearch.NewSearcher(r.agentConfig.LogSources)})
+	}
+	if len(r.miner.Sources()) > 0 {
+		readOnly = append(readOnly, &LogPatterns{Miner: r.miner})
+	}
+	return readOnly
+}
+
+// AvailableTools returns all the tools which could be referenced by name
+func (r *ToolRegistry) AvailableTools() []tools.Tool {
+	return r.DefaultTools()
+}
+
+// NewToolsByName creates the tools with the given names, the default tools
+// are returned if no name is given.
+func (r *ToolRegistry) NewToolsByName(names []string) ([]tools.Tool, error) {
+	if len(names) == 0 {
+		return r.DefaultTools(), nil
+	}
+
+	available := r.AvailableTools()
+	agentTools := make([]tools.Tool, 0, len(names))
+	for _, name := range names {
+		tool := findToolByName(available, name)
+		if tool == nil {
+			return nil, fmt.Errorf("unknown tool %q, available tools: %s", name, toolNames(available))
+		}
+		agentTools = append(agentTools, tool)
+	}
+	return agentTools, nil
+}
+
+// RestrictTools keeps only the read-only tools for untrusted models, the
+// read-only tools are returned if none of the tools is read-only.
+func (r *ToolRegistry) RestrictTools(agentTools []tools.Tool, untrusted bool) []tools.Tool {
+	if !untrusted {
+		return agentTools
+	}
+
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	readOnly := r.ReadOnlyTools()
+	restricted := make([]tools.Tool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		if findToolByName(readOnly, tool.Name()) == nil {
+			logger.Warn("ai-agentic-monitor: tool not allowed for untrusted model,", logger.Args("tool", tool.Name()))
+			continue
+		}
+		restricted = append(restricted, tool)
+	}
+	if len(restricted) == 0 {
+		return readOnly
+	}
+	return restricted
+}
+
+// BindTools returns the tools used by the given monitor, the tools keeping a
+// state between their calls, like LogPatterns, keep one for each monitor.
+func (r *ToolRegistry) BindTools(agentTools []tools.Tool, monitor string) []tools.Tool {
+	bound := make([]tools.Tool, 0, len(agentTools))
+	for _, tool := range agentTools {
+		if patterns, ok := tool.(*LogPatterns); ok {
+			tool = &LogPatterns{Miner: patterns.Miner, Consumer: monitor}
+		}
+		bound = append(bound, tool)
+	}
+	return bound
+}
+
+func findToolByName(agentTools []tools.Tool, name string) tools.Tool {
+	for _, tool := range agentTools {
+		if strings.EqualFold(tool.Name(), name) {
+			return tool
+		}
+	}
+	return nil
+}
+
+// ToolDescriptions returns the description of the tools, one tool per line
+func ToolDescriptions(agentTools []tools.Tool) string {
+	return strings.TrimSpace(toolDescriptions(agentTools))
+}
+
+// ToolNames returns the names of the tools separated by comma
+func ToolNames(agentTools []tools.Tool) string {
+	return toolNames(agentTools)
+}
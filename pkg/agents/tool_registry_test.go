@@ -0,0 +1,20 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUntrustedTools(t *testing.T) {
+	registry := NewToolRegistry(nil)
+
+	assert.NotNil(t, findToolByName(registry.DefaultTools(), "Probe"))
+	assert.NotNil(t, findToolByName(registry.DefaultTools(), "ScriptExecutor"))
+	// the untrusted models must not reach the network nor run commands
+	for _, name := range []string{"Probe", "ScriptExecutor"} {
+		assert.Nil(t, findToolByName(registry.ReadOnlyTools(), name), name)
+		assert.Nil(t, findToolByName(registry.RestrictTools(registry.DefaultTools(), true), name), name)
+	}
+	assert.NotNil(t, findToolByName(registry.RestrictTools(registry.DefaultTools(), false), "Probe"))
+}
@@ -0,0 +1,56 @@
+package anomaly
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LoadBaselines reads the seasonal baselines saved by SaveBaselines, a
+// missing file means the baselines are learnt from scratch.
+func (d *Detector) LoadBaselines(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read anomaly baselines: %w", err)
+	}
+	baselines := make(map[string]*Baseline)
+	if err := json.Unmarshal(data, &baselines); err != nil {
+		return fmt.Errorf("failed to parse anomaly baselines %s: %w", path, err)
+	}
+	for key, baseline := range baselines {
+		if _, ok := d.series[key]; !ok && baseline != nil {
+			d.baselines[key] = baseline
+		}
+	}
+	return nil
+}
+
+// SaveBaselines writes the seasonal baselines, the file is replaced atomically
+func (d *Detector) SaveBaselines(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create anomaly baselines directory: %w", err)
+	}
+	data, err := json.Marshal(d.baselines)
+	if err != nil {
+		return fmt.Errorf("failed to encode anomaly baselines: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save anomaly baselines: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save anomaly baselines: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save anomaly baselines: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
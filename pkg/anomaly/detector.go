@@ -0,0 +1,305 @@
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+const (
+	DetectorEWMA        = "ewma"
+	DetectorSeasonal    = "seasonal"
+	DetectorChangePoint = "change_point"
+
+	// DefaultZScore is the default number of standard deviations of an anomaly
+	DefaultZScore = 4.0
+
+	// smoothing of the recent level of the ewma detector, about the last 40 samples
+	ewmaAlpha = 0.05
+	// samples before the ewma detector reports anything
+	ewmaWarmup = 60
+	// consecutive anomalous samples before the ewma detector reports, a single spike is not an anomaly
+	ewmaConsecutive = 3
+	// consecutive anomalous minutes before the seasonal detector reports
+	seasonalConsecutive = 3
+
+	// minutes of the two windows compared by the change point detection, a
+	// slow trend does not move their means apart like a shift of the level
+	changeWindow = 15
+
+	// minutes of a seasonal bucket before it is used, two weeks of the hour
+	// for the day of the week and three days of the hour for the hour of the day
+	minWeeklyCount = 120
+	minDailyCount  = 180
+	// the seasonal buckets forget the values older than about 8 weeks
+	maxWeeklyCount = 8 * 60
+	maxDailyCount  = 8 * 7 * 60
+
+	// the deviation is at least this ratio of the mean
+	relativeFloor = 0.01
+)
+
+// Detectors are all the detectors
+var Detectors = []string{DetectorEWMA, DetectorSeasonal, DetectorChangePoint}
+
+// Anomaly is a value of a series out of its expected range
+type Anomaly struct {
+	Metric   string         `json:"metric"`
+	Labels   metrics.Labels `json:"labels,omitempty"`
+	Time     time.Time      `json:"time"`
+	Value    float64        `json:"value"`
+	Detector string         `json:"detector"`
+	// Score is the signed distance to the expected level in standard deviations
+	Score        float64 `json:"score"`
+	ExpectedLow  float64 `json:"expected_low"`
+	ExpectedHigh float64 `json:"expected_high"`
+	// Baseline is what the value is compared to, like "usual Tuesday 10:00 level"
+	Baseline string `json:"baseline"`
+}
+
+// Series returns the metric and the labels of the anomaly
+func (a Anomaly) Series() string {
+	return a.Metric + a.Labels.String()
+}
+
+// Message describes the anomaly in a sentence for the alerts and the prompts
+func (a Anomaly) Message() string {
+	direction := "above"
+	if a.Score < 0 {
+		direction = "below"
+	}
+	if a.Detector == DetectorChangePoint {
+		direction = "up from"
+		if a.Score < 0 {
+			direction = "down from"
+		}
+		return fmt.Sprintf("%s shifted %s its %s: %g, expected %g to %g",
+			a.Series(), direction, a.Baseline, a.Value, a.ExpectedLow, a.ExpectedHigh)
+	}
+	return fmt.Sprintf("%s is %.1fσ %s its %s: %g, expected %g to %g",
+		a.Series(), math.Abs(a.Score), direction, a.Baseline, a.Value, a.ExpectedLow, a.ExpectedHigh)
+}
+
+// Sample is a value given to the detector, the deviations smaller than
+// MinDeviation are never reported.
+type Sample struct {
+	Metric       string
+	Labels       metrics.Labels
+	Time         time.Time
+	Value        float64
+	MinDeviation float64
+}
+
+// Baseline is the seasonal level of a series by hour of the week and by
+// hour of the day, it is kept across the restarts.
+type Baseline struct {
+	Weekly [7 * 24]Moments `json:"weekly"`
+	Daily  [24]Moments     `json:"daily"`
+}
+
+// seriesState is the state of the detectors for a series
+type seriesState struct {
+	last   time.Time
+	recent ewma
+	// consecutive are the numbers of consecutive anomalous samples of the ewma
+	// detector and of consecutive anomalous minutes of the seasonal detector
+	consecutive         int
+	seasonalConsecutive int
+	// the minute being averaged, the seasonal and change point detectors work on the minute means
+	minute      int64
+	minuteSum   float64
+	minuteCount int
+	// window are the last minute means, the previous window then the current one
+	window []float64
+	// anomalous are the detectors reporting the series, they report again
+	// only after the series came back to its expected range
+	anomalous map[string]bool
+	baseline  *Baseline
+}
+
+// Detector runs the anomaly detectors on every series it observes
+type Detector struct {
+	zScore    float64
+	enabled   map[string]bool
+	series    map[string]*seriesState
+	baselines map[string]*Baseline
+}
+
+// NewDetector creates a detector running the given detectors, all of them
+// when none is given, an anomaly is zScore standard deviations away.
+func NewDetector(detectors []string, zScore float64) (*Detector, error) {
+	if len(detectors) == 0 {
+		detectors = Detectors
+	}
+	if zScore <= 0 {
+		zScore = DefaultZScore
+	}
+	enabled := make(map[string]bool, len(detectors))
+	for _, detector := range detectors {
+		switch detector {
+		case DetectorEWMA, DetectorSeasonal, DetectorChangePoint:
+			enabled[detector] = true
+		default:
+			return nil, fmt.Errorf("unknown detector %q, use %s, %s or %s", detector, DetectorEWMA, DetectorSeasonal, DetectorChangePoint)
+		}
+	}
+	return &Detector{
+		zScore:    zScore,
+		enabled:   enabled,
+		series:    make(map[string]*seriesState),
+		baselines: make(map[string]*Baseline),
+	}, nil
+}
+
+// Observe runs the detectors on the sample and returns the anomalies which
+// started with it, the samples must be observed in time order.
+func (d *Detector) Observe(sample Sample) []Anomaly {
+	key := sample.Metric + sample.Labels.String()
+	state, ok := d.series[key]
+	if !ok {
+		baseline, ok := d.baselines[key]
+		if !ok {
+			baseline = &Baseline{}
+			d.baselines[key] = baseline
+		}
+		state = &seriesState{anomalous: make(map[string]bool), baseline: baseline}
+		d.series[key] = state
+	}
+	if !sample.Time.After(state.last) {
+		return nil
+	}
+	state.last = sample.Time
+
+	var anomalies []Anomaly
+	report := func(detector string, score, mean, std float64, baseline string, t time.Time, value float64) {
+		if math.Abs(score) < d.zScore/2 {
+			state.anomalous[detector] = false
+		}
+		if math.Abs(score) < d.zScore || state.anomalous[detector] {
+			return
+		}
+		state.anomalous[detector] = true
+		anomalies = append(anomalies, Anomaly{
+			Metric:       sample.Metric,
+			Labels:       sample.Labels,
+			Time:         t,
+			Value:        round(value),
+			Detector:     detector,
+			Score:        round(score),
+			ExpectedLow:  round(mean - d.zScore*std),
+			ExpectedHigh: round(mean + d.zScore*std),
+			Baseline:     baseline,
+		})
+	}
+
+	// The minute means are computed first so a new minute is closed with the previous samples only.
+	minute := sample.Time.Unix() / 60
+	if minute != state.minute && state.minuteCount > 0 {
+		at := time.Unix(state.minute*60, 0)
+		value := state.minuteSum / float64(state.minuteCount)
+		d.closeMinute(state, at, value, sample.MinDeviation, report)
+		state.minuteSum, state.minuteCount = 0, 0
+	}
+	state.minute = minute
+	state.minuteSum += sample.Value
+	state.minuteCount++
+
+	value := sample.Value
+	if state.recent.count >= ewmaWarmup {
+		mean := state.recent.mean
+		std := math.Max(state.recent.std(), d.floor(mean, sample.MinDeviation))
+		score := zScore(sample.Value, mean, std)
+		if math.Abs(score) >= d.zScore {
+			state.consecutive++
+		} else {
+			state.consecutive = 0
+		}
+		if d.enabled[DetectorEWMA] && (state.consecutive >= ewmaConsecutive || math.Abs(score) < d.zScore) {
+			report(DetectorEWMA, score, mean, std, "recent level", sample.Time, sample.Value)
+		}
+		// The outliers are clamped to the expected range so a spike does not
+		// inflate the deviation at once, a new level is still learnt slowly.
+		value = math.Min(math.Max(value, mean-d.zScore*std), mean+d.zScore*std)
+	}
+	state.recent.add(value, ewmaAlpha)
+	return anomalies
+}
+
+// closeMinute runs the seasonal and change point detectors on the mean of a minute
+func (d *Detector) closeMinute(state *seriesState, at time.Time, value, minDeviation float64,
+	report func(string, float64, float64, float64, string, time.Time, float64)) {
+	local := at.Local()
+	weekly := &state.baseline.Weekly[int(local.Weekday())*24+local.Hour()]
+	daily := &state.baseline.Daily[local.Hour()]
+	// the weekly baseline is used once it has seen enough weeks, the daily one otherwise
+	bucket, baseline := weekly, fmt.Sprintf("usual %s %02d:00 level", local.Weekday(), local.Hour())
+	if weekly.Count < minWeeklyCount {
+		bucket, baseline = daily, fmt.Sprintf("usual %02d:00 level", local.Hour())
+	}
+	ready := bucket == weekly || daily.Count >= minDailyCount
+	if d.enabled[DetectorSeasonal] && ready {
+		std := math.Max(bucket.Std(), d.floor(bucket.Mean, minDeviation))
+		score := zScore(value, bucket.Mean, std)
+		if math.Abs(score) >= d.zScore {
+			state.seasonalConsecutive++
+		} else {
+			state.seasonalConsecutive = 0
+		}
+		if state.seasonalConsecutive >= seasonalConsecutive || math.Abs(score) < d.zScore {
+			report(DetectorSeasonal, score, bucket.Mean, std, baseline, at, value)
+		}
+	}
+	weekly.Add(value, maxWeeklyCount)
+	daily.Add(value, maxDailyCount)
+
+	if !d.enabled[DetectorChangePoint] {
+		return
+	}
+	state.window = append(state.window, value)
+	if len(state.window) < 2*changeWindow {
+		return
+	}
+	previous, current := Moments{}, Moments{}
+	for i, minuteMean := range state.window {
+		if i < changeWindow {
+			previous.Add(minuteMean, changeWindow)
+		} else {
+			current.Add(minuteMean, changeWindow)
+		}
+	}
+	std := math.Sqrt((previous.Std()*previous.Std() + current.Std()*current.Std()) / 2)
+	std = math.Max(std, d.floor(previous.Mean, minDeviation))
+	score := zScore(current.Mean, previous.Mean, std)
+	if math.Abs(score) >= d.zScore {
+		// the shift is reported once, then the new level becomes the previous window
+		state.anomalous[DetectorChangePoint] = false
+		report(DetectorChangePoint, score, previous.Mean, std,
+			fmt.Sprintf("level of %g over %d minutes", round(previous.Mean), changeWindow), at, current.Mean)
+		state.window = state.window[changeWindow:]
+		return
+	}
+	state.window = state.window[1:]
+}
+
+// floor returns the minimal standard deviation, so a flat series does not
+// report tiny changes and MinDeviation is below zScore deviations.
+func (d *Detector) floor(mean, minDeviation float64) float64 {
+	return math.Max(math.Max(relativeFloor*math.Abs(mean), minDeviation/d.zScore), 1e-9)
+}
+
+// Forget drops the state of the series not observed since the time, their
+// seasonal baselines are kept.
+func (d *Detector) Forget(before time.Time) {
+	for key, state := range d.series {
+		if state.last.Before(before) {
+			delete(d.series, key)
+		}
+	}
+}
+
+// round keeps 4 decimals
+func round(value float64) float64 {
+	return math.Round(value*1e4) / 1e4
+}
@@ -0,0 +1,147 @@
+package anomaly
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testInterval = 10 * time.Second
+
+// testStart is a monday at midnight without daylight saving change in the following days
+var testStart = time.Date(2024, 6, 3, 0, 0, 0, 0, time.Local)
+
+// run observes a sample every testInterval for the duration and returns the anomalies
+func run(t *testing.T, detectors []string, duration time.Duration, value func(at time.Time) float64) []Anomaly {
+	detector, err := NewDetector(detectors, 0)
+	assert.NoError(t, err)
+	// the noise is bounded to about 1.7 standard deviations
+	noise := rand.New(rand.NewSource(1))
+	var anomalies []Anomaly
+	for at := testStart; at.Before(testStart.Add(duration)); at = at.Add(testInterval) {
+		anomalies = append(anomalies, detector.Observe(Sample{
+			Metric: "cpu.usage_percent",
+			Time:   at,
+			Value:  value(at) + noise.Float64()*4 - 2,
+		})...)
+	}
+	return anomalies
+}
+
+// between returns a series at the level, and at the other level between the offsets from testStart
+func between(level, other float64, from, to time.Duration) func(time.Time) float64 {
+	return func(at time.Time) float64 {
+		if !at.Before(testStart.Add(from)) && at.Before(testStart.Add(to)) {
+			return other
+		}
+		return level
+	}
+}
+
+// daily is 20 in the morning and 80 in the afternoon
+func daily(at time.Time) float64 {
+	if at.Hour() < 12 {
+		return 20
+	}
+	return 80
+}
+
+func TestDetector(t *testing.T) {
+	tests := []struct {
+		name      string
+		detectors []string
+		duration  time.Duration
+		value     func(time.Time) float64
+		// want are the detectors of the anomalies, the first one is found between
+		// from and to and its baseline contains baseline
+		want     []string
+		from, to time.Duration
+		baseline string
+	}{
+		{
+			name:     "flat",
+			duration: 4 * 24 * time.Hour,
+			value:    between(50, 50, 0, 0),
+		},
+		{
+			name:      "step change seen by the ewma",
+			detectors: []string{DetectorEWMA},
+			duration:  3 * time.Hour,
+			value:     between(50, 80, 2*time.Hour, 3*time.Hour),
+			want:      []string{DetectorEWMA},
+			from:      2 * time.Hour,
+			to:        2*time.Hour + time.Minute,
+			baseline:  "recent level",
+		},
+		{
+			name:      "step change",
+			detectors: []string{DetectorChangePoint},
+			duration:  3 * time.Hour,
+			value:     between(50, 80, 2*time.Hour, 3*time.Hour),
+			want:      []string{DetectorChangePoint},
+			from:      2 * time.Hour,
+			to:        2*time.Hour + changeWindow*time.Minute,
+			baseline:  "over 15 minutes",
+		},
+		{
+			name:     "single spike",
+			duration: 2 * time.Hour,
+			value:    between(50, 500, time.Hour, time.Hour+testInterval),
+		},
+		{
+			name:     "spike of a minute",
+			duration: 2 * time.Hour,
+			value:    between(50, 500, time.Hour, time.Hour+time.Minute),
+			want:     []string{DetectorEWMA},
+			from:     time.Hour,
+			to:       time.Hour + time.Minute,
+			baseline: "recent level",
+		},
+		{
+			name:      "seasonal pattern",
+			detectors: []string{DetectorSeasonal},
+			duration:  5 * 24 * time.Hour,
+			value:     daily,
+		},
+		{
+			name:      "break of the seasonal pattern",
+			detectors: []string{DetectorSeasonal},
+			duration:  5 * 24 * time.Hour,
+			value: func(at time.Time) float64 {
+				// the afternoon level at 03:00 on the fifth day
+				if at.Sub(testStart) >= 4*24*time.Hour+3*time.Hour && at.Sub(testStart) < 4*24*time.Hour+4*time.Hour {
+					return 80
+				}
+				return daily(at)
+			},
+			want:     []string{DetectorSeasonal},
+			from:     4*24*time.Hour + 3*time.Hour,
+			to:       4*24*time.Hour + 3*time.Hour + 5*time.Minute,
+			baseline: "usual 03:00 level",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomalies := run(t, tt.detectors, tt.duration, tt.value)
+			detectors := make([]string, 0, len(anomalies))
+			for _, anomaly := range anomalies {
+				detectors = append(detectors, anomaly.Detector)
+			}
+			if len(tt.want) == 0 {
+				assert.Empty(t, anomalies)
+				return
+			}
+			if !assert.Equal(t, tt.want, detectors, anomalies) {
+				return
+			}
+			anomaly := anomalies[0]
+			assert.Greater(t, anomaly.Score, 0.0)
+			assert.Greater(t, anomaly.Value, anomaly.ExpectedHigh)
+			assert.Contains(t, anomaly.Baseline, tt.baseline)
+			assert.False(t, anomaly.Time.Before(testStart.Add(tt.from)), anomaly.Time)
+			assert.True(t, anomaly.Time.Before(testStart.Add(tt.to)), anomaly.Time)
+		})
+	}
+}
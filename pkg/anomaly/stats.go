@@ -0,0 +1,59 @@
+package anomaly
+
+import "math"
+
+// Moments are the running mean and variance of the values of a seasonal
+// bucket, once Count reaches the maximal count the oldest values fade out.
+type Moments struct {
+	Count float64 `json:"n"`
+	Mean  float64 `json:"mean"`
+	M2    float64 `json:"m2"`
+}
+
+// Add adds a value with the Welford algorithm, the count is capped so the
+// baseline follows the slow changes of the system.
+func (m *Moments) Add(value float64, maxCount float64) {
+	if m.Count < maxCount {
+		m.Count++
+	} else {
+		m.M2 *= (maxCount - 1) / maxCount
+	}
+	delta := value - m.Mean
+	m.Mean += delta / m.Count
+	m.M2 += delta * (value - m.Mean)
+}
+
+// Std returns the standard deviation of the values
+func (m Moments) Std() float64 {
+	if m.Count < 2 {
+		return 0
+	}
+	return math.Sqrt(m.M2 / (m.Count - 1))
+}
+
+// ewma is an exponentially weighted moving mean and variance
+type ewma struct {
+	count int
+	mean  float64
+	vari  float64
+}
+
+func (e *ewma) add(value, alpha float64) {
+	e.count++
+	if e.count == 1 {
+		e.mean = value
+		return
+	}
+	delta := value - e.mean
+	e.mean += alpha * delta
+	e.vari = (1 - alpha) * (e.vari + alpha*delta*delta)
+}
+
+func (e *ewma) std() float64 {
+	return math.Sqrt(e.vari)
+}
+
+// zScore returns how many standard deviations the value is from the mean
+func zScore(value, mean, std float64) float64 {
+	return (value - mean) / std
+}
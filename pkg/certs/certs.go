@@ -0,0 +1,206 @@
+package certs
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Certificate is a certificate found in files or presented by endpoints, the
+// same certificate found several times is reported once with all its sources.
+type Certificate struct {
+	// Sources are the files and the endpoints where the certificate was found
+	Sources   []string  `json:"sources"`
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans,omitempty"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+	// DaysLeft is negative when the certificate expired
+	DaysLeft    int    `json:"days_left"`
+	IsCA        bool   `json:"is_ca,omitempty"`
+	Fingerprint string `json:"sha256"`
+}
+
+// Report is the result of a scan, sorted by expiry
+type Report struct {
+	Certificates []Certificate `json:"certificates"`
+	Files        int           `json:"files"`
+	Endpoints    int           `json:"endpoints"`
+	// Errors are the files and the endpoints which could not be read
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Severity returns SeverityCritical when the certificate expires within
+// criticalDays or expired, SeverityWarning within warningDays, "" otherwise.
+func (c Certificate) Severity(warningDays, criticalDays int) string {
+	switch {
+	case c.DaysLeft <= criticalDays:
+		return SeverityCritical
+	case c.DaysLeft <= warningDays:
+		return SeverityWarning
+	}
+	return ""
+}
+
+// now returns the current time, the tests replace it to check fixed certificates
+var now = time.Now
+
+// trustStoreBundles are the bundles of the system trust store of the main distributions
+var trustStoreBundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",
+	"/etc/pki/tls/certs/ca-bundle.crt",
+	"/etc/ssl/ca-bundle.pem",
+	"/etc/ssl/cert.pem",
+}
+
+// scan collects the certificates by fingerprint
+type scan struct {
+	spec         config.CertificateScan
+	report       Report
+	certificates map[string]*Certificate
+	// trustStore are the fingerprints of the certificates of the system trust store
+	trustStore map[string]bool
+}
+
+// Scan reads the certificates of the paths and of the endpoints of the spec,
+// the roots of the system trust store are left out unless the spec includes
+// them, their expiry is handled by the distribution updates.
+func Scan(ctx context.Context, spec config.CertificateScan) Report {
+	s := &scan{spec: spec, certificates: make(map[string]*Certificate), trustStore: make(map[string]bool)}
+	if !spec.IncludeTrustStore {
+		s.loadTrustStore()
+	}
+	for _, path := range spec.Paths {
+		s.scanPath(path)
+	}
+	timeout, _ := spec.GetTimeout()
+	for _, endpoint := range spec.Endpoints {
+		s.report.Endpoints++
+		chain, err := fetchChain(ctx, endpoint, timeout)
+		if err != nil {
+			s.report.Errors = append(s.report.Errors, fmt.Sprintf("%s: %s", endpoint, err))
+			continue
+		}
+		s.add("endpoint "+endpoint, chain)
+	}
+
+	for _, cert := range s.certificates {
+		s.report.Certificates = append(s.report.Certificates, *cert)
+	}
+	sort.Slice(s.report.Certificates, func(i, j int) bool {
+		return s.report.Certificates[i].NotAfter.Before(s.report.Certificates[j].NotAfter)
+	})
+	return s.report
+}
+
+// add records the certificates found in source
+func (s *scan) add(source string, certs []*x509.Certificate) {
+	current := now()
+	for _, cert := range certs {
+		fingerprint := fingerprint(cert)
+		if s.trustStore[fingerprint] {
+			continue
+		}
+		if known, ok := s.certificates[fingerprint]; ok {
+			known.Sources = appendSource(known.Sources, source)
+			continue
+		}
+		s.certificates[fingerprint] = &Certificate{
+			Sources:     []string{source},
+			Subject:     cert.Subject.String(),
+			Issuer:      cert.Issuer.String(),
+			SANs:        sans(cert),
+			Serial:      cert.SerialNumber.String(),
+			NotBefore:   cert.NotBefore,
+			NotAfter:    cert.NotAfter,
+			DaysLeft:    daysLeft(cert.NotAfter, current),
+			IsCA:        cert.IsCA,
+			Fingerprint: fingerprint,
+		}
+	}
+}
+
+// fetchChain returns the chain presented by the endpoint, without verifying it
+// since the expiry of an untrusted certificate matters as well.
+func fetchChain(ctx context.Context, endpoint string, timeout time.Duration) ([]*x509.Certificate, error) {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	if net.ParseIP(host) == nil {
+		tlsConfig.ServerName = host
+	}
+	dialer := tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState().PeerCertificates, nil
+}
+
+// loadTrustStore reads the fingerprints of the first bundle of the system trust store found
+func (s *scan) loadTrustStore() {
+	for _, bundle := range trustStoreBundles {
+		data, err := os.ReadFile(bundle)
+		if err != nil {
+			continue
+		}
+		certs, _ := parsePEM(data)
+		for _, cert := range certs {
+			s.trustStore[fingerprint(cert)] = true
+		}
+		return
+	}
+}
+
+// daysLeft returns the full days before the expiry, negative once expired
+func daysLeft(notAfter, now time.Time) int {
+	return int(notAfter.Sub(now).Hours() / 24)
+}
+
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func sans(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+func appendSource(sources []string, source string) []string {
+	for _, known := range sources {
+		if known == source {
+			return sources
+		}
+	}
+	return append(sources, source)
+}
@@ -0,0 +1,133 @@
+package certs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+// fixtureNow is the time the fixtures are checked at, expired.pem expired
+// 31 days before, warning.crt expires 20 days after and healthy.pem a year after.
+var fixtureNow = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestDaysLeft(t *testing.T) {
+	tests := []struct {
+		name     string
+		notAfter time.Time
+		want     int
+	}{
+		{"a year left", fixtureNow.AddDate(1, 0, 0), 365},
+		{"full days only", fixtureNow.Add(47 * time.Hour), 1},
+		{"less than a day", fixtureNow.Add(23 * time.Hour), 0},
+		{"expiring now", fixtureNow, 0},
+		{"expired less than a day ago", fixtureNow.Add(-23 * time.Hour), 0},
+		{"expired", fixtureNow.AddDate(0, 0, -31), -31},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, daysLeft(tt.notAfter, fixtureNow), tt.name)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		daysLeft     int
+		warningDays  int
+		criticalDays int
+		want         string
+	}{
+		{daysLeft: -31, warningDays: 30, criticalDays: 7, want: SeverityCritical},
+		{daysLeft: 0, warningDays: 30, criticalDays: 7, want: SeverityCritical},
+		{daysLeft: 7, warningDays: 30, criticalDays: 7, want: SeverityCritical},
+		{daysLeft: 8, warningDays: 30, criticalDays: 7, want: SeverityWarning},
+		{daysLeft: 30, warningDays: 30, criticalDays: 7, want: SeverityWarning},
+		{daysLeft: 31, warningDays: 30, criticalDays: 7, want: ""},
+		{daysLeft: 20, warningDays: 60, criticalDays: 20, want: SeverityCritical},
+		{daysLeft: 20, warningDays: 10, criticalDays: 5, want: ""},
+	}
+	for _, tt := range tests {
+		cert := Certificate{DaysLeft: tt.daysLeft}
+		assert.Equal(t, tt.want, cert.Severity(tt.warningDays, tt.criticalDays),
+			"%d days left, warning %d, critical %d", tt.daysLeft, tt.warningDays, tt.criticalDays)
+	}
+}
+
+func TestScanFixtures(t *testing.T) {
+	now = func() time.Time { return fixtureNow }
+	defer func() { now = time.Now }()
+
+	spec := config.CertificateScan{Paths: []string{"testdata"}, IncludeTrustStore: true}
+	report := Scan(context.Background(), spec)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 4, report.Files)
+
+	warningDays, criticalDays := spec.GetThresholds()
+	tests := []struct {
+		subject  string
+		sources  []string
+		daysLeft int
+		severity string
+	}{
+		{
+			subject:  "CN=expired.example.com,O=Example",
+			sources:  []string{"testdata/expired.pem"},
+			daysLeft: -31,
+			severity: SeverityCritical,
+		},
+		{
+			subject:  "CN=warning.example.com,O=Example",
+			sources:  []string{"testdata/truststore.jks", "testdata/warning.crt"},
+			daysLeft: 20,
+			severity: SeverityWarning,
+		},
+		{
+			subject:  "CN=healthy.example.com,O=Example",
+			sources:  []string{"testdata/healthy.pem", "testdata/truststore.jks"},
+			daysLeft: 365,
+			severity: "",
+		},
+	}
+	// the certificates are sorted by expiry
+	if !assert.Len(t, report.Certificates, len(tests)) {
+		return
+	}
+	for i, tt := range tests {
+		cert := report.Certificates[i]
+		assert.Equal(t, tt.subject, cert.Subject)
+		assert.ElementsMatch(t, tt.sources, cert.Sources, tt.subject)
+		assert.Equal(t, tt.daysLeft, cert.DaysLeft, tt.subject)
+		assert.Equal(t, tt.severity, cert.Severity(warningDays, criticalDays), tt.subject)
+	}
+}
+
+func TestParseJKS(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "truststore.jks"))
+	assert.NoError(t, err)
+	assert.True(t, isJKS(data))
+
+	certs, err := parseJKS(data)
+	assert.NoError(t, err)
+	if assert.Len(t, certs, 2) {
+		assert.Equal(t, "healthy.example.com", certs[0].Subject.CommonName)
+		assert.Equal(t, "warning.example.com", certs[1].Subject.CommonName)
+	}
+
+	// a truncated keystore keeps the certificates read before the end
+	certs, err = parseJKS(data[:len(data)-100])
+	assert.ErrorContains(t, err, "invalid keystore")
+	assert.Len(t, certs, 1)
+
+	version := append([]byte{}, data...)
+	version[7] = 3
+	_, err = parseJKS(version)
+	assert.ErrorContains(t, err, "unsupported keystore version 3")
+
+	pem, err := os.ReadFile(filepath.Join("testdata", "healthy.pem"))
+	assert.NoError(t, err)
+	assert.False(t, isJKS(pem))
+}
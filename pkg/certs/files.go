@@ -0,0 +1,159 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+const (
+	// maximal size of a certificate file, larger files are not certificates
+	maxCertFileSize = 1 << 20
+	// maximal number of files read by a scan
+	maxCertFiles = 10000
+)
+
+// certExtensions are the extensions of the files read in the scanned directories
+var certExtensions = map[string]bool{
+	".pem": true, ".crt": true, ".cer": true, ".cert": true, ".der": true,
+	".p12": true, ".pfx": true, ".jks": true, ".keystore": true, ".truststore": true, ".ks": true,
+}
+
+// defaultPasswords are tried on every PKCS#12 file, the empty password and the java default one
+var defaultPasswords = []string{"", "changeit"}
+
+// scanPath reads the certificate files below a directory, or the file itself
+// whatever its extension.
+func (s *scan) scanPath(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		s.report.Errors = append(s.report.Errors, err.Error())
+		return
+	}
+	if !info.IsDir() {
+		s.scanFile(path)
+		return
+	}
+
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories like /etc/ssl/private are only reported.
+			s.report.Errors = append(s.report.Errors, err.Error())
+			if entry != nil && entry.IsDir() && file != path {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !certExtensions[strings.ToLower(filepath.Ext(file))] {
+			return nil
+		}
+		if s.report.Files >= maxCertFiles {
+			return fmt.Errorf("stopped after %d files", maxCertFiles)
+		}
+		s.scanFile(file)
+		return nil
+	})
+	if err != nil {
+		s.report.Errors = append(s.report.Errors, fmt.Sprintf("%s: %s", path, err))
+	}
+}
+
+func (s *scan) scanFile(path string) {
+	// Symbolic links to certificates, like the hashed names of /etc/ssl/certs, are
+	// followed, the certificate is reported once with all its paths.
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxCertFileSize {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		s.report.Errors = append(s.report.Errors, err.Error())
+		return
+	}
+	s.report.Files++
+
+	certs, err := s.parse(path, data)
+	if err != nil {
+		s.report.Errors = append(s.report.Errors, fmt.Sprintf("%s: %s", path, err))
+	}
+	s.add(path, certs)
+}
+
+// parse decodes the certificates of a PEM, DER, PKCS#12 or JKS file, a PEM
+// file without certificate, like a private key, is not an error.
+func (s *scan) parse(path string, data []byte) ([]*x509.Certificate, error) {
+	if isJKS(data) {
+		return parseJKS(data)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".p12", ".pfx":
+		return parsePKCS12(data, s.spec.Passwords)
+	}
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return parsePEM(data)
+	}
+	certs, err := x509.ParseCertificates(data)
+	if err != nil {
+		// A binary .keystore could be a PKCS#12 file as well.
+		if pkcs12Certs, pkcs12Err := parsePKCS12(data, s.spec.Passwords); pkcs12Err == nil {
+			return pkcs12Certs, nil
+		}
+		return nil, fmt.Errorf("not a PEM, DER, PKCS#12 or JKS certificate: %w", err)
+	}
+	return certs, nil
+}
+
+func parsePEM(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	var errs []string
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		certs = append(certs, cert)
+	}
+	if len(errs) > 0 {
+		return certs, fmt.Errorf("invalid certificates: %s", strings.Join(errs, "; "))
+	}
+	return certs, nil
+}
+
+// parsePKCS12 tries the passwords on the file, the PKCS#12 files encrypted
+// with AES, the OpenSSL 3 default, are not supported.
+func parsePKCS12(data []byte, passwords []string) ([]*x509.Certificate, error) {
+	var err error
+	for _, password := range append(append([]string{}, passwords...), defaultPasswords...) {
+		var blocks []*pem.Block
+		if blocks, err = pkcs12.ToPEM(data, password); err != nil {
+			continue
+		}
+		var certs []*x509.Certificate
+		for _, block := range blocks {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+				certs = append(certs, cert)
+			}
+		}
+		return certs, nil
+	}
+	return nil, fmt.Errorf("failed to open PKCS#12 file: %w", err)
+}
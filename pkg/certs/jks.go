@@ -0,0 +1,122 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	jksMagic   = 0xFEEDFEED
+	jceksMagic = 0xCECECECE
+
+	jksPrivateKeyEntry  = 1
+	jksTrustedCertEntry = 2
+)
+
+// isJKS tells if the data is a java keystore, JKS or JCEKS
+func isJKS(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	magic := binary.BigEndian.Uint32(data)
+	return magic == jksMagic || magic == jceksMagic
+}
+
+// parseJKS reads the certificates of a java keystore, they are stored in
+// clear so no password is needed, only the private keys are encrypted.
+func parseJKS(data []byte) ([]*x509.Certificate, error) {
+	reader := &jksReader{r: bytes.NewReader(data)}
+	reader.uint32() // magic
+	version := reader.uint32()
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("unsupported keystore version %d", version)
+	}
+	count := reader.uint32()
+
+	var certs []*x509.Certificate
+	for i := uint32(0); i < count && reader.err == nil; i++ {
+		tag := reader.uint32()
+		reader.utf() // alias
+		reader.skip(8)
+		switch tag {
+		case jksPrivateKeyEntry:
+			reader.skip(int64(reader.uint32()))
+			chain := reader.uint32()
+			for j := uint32(0); j < chain && reader.err == nil; j++ {
+				if cert := reader.certificate(version); cert != nil {
+					certs = append(certs, cert)
+				}
+			}
+		case jksTrustedCertEntry:
+			if cert := reader.certificate(version); cert != nil {
+				certs = append(certs, cert)
+			}
+		default:
+			// The secret keys of the JCEKS keystores are serialized java objects.
+			return certs, fmt.Errorf("unsupported keystore entry %d", tag)
+		}
+	}
+	if reader.err != nil {
+		return certs, fmt.Errorf("invalid keystore: %w", reader.err)
+	}
+	return certs, nil
+}
+
+// jksReader reads the big endian fields of a keystore, the first error stops the reads
+type jksReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (r *jksReader) read(size int64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if size < 0 || size > int64(r.r.Len()) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	buf := make([]byte, size)
+	_, r.err = io.ReadFull(r.r, buf)
+	return buf
+}
+
+func (r *jksReader) skip(size int64) {
+	r.read(size)
+}
+
+func (r *jksReader) uint32() uint32 {
+	if buf := r.read(4); buf != nil {
+		return binary.BigEndian.Uint32(buf)
+	}
+	return 0
+}
+
+func (r *jksReader) utf() string {
+	buf := r.read(2)
+	if buf == nil {
+		return ""
+	}
+	return string(r.read(int64(binary.BigEndian.Uint16(buf))))
+}
+
+// certificate reads a certificate, its type is only written by version 2
+func (r *jksReader) certificate(version uint32) *x509.Certificate {
+	if version == 2 {
+		if certType := r.utf(); certType != "X.509" && r.err == nil {
+			r.err = fmt.Errorf("unsupported certificate type %q", certType)
+		}
+	}
+	raw := r.read(int64(r.uint32()))
+	if raw == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
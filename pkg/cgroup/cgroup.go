@@ -0,0 +1,314 @@
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+const (
+	// DefaultRoot is where the unified hierarchy is mounted on most systems
+	DefaultRoot = "/sys/fs/cgroup"
+)
+
+// Hierarchy reads the cgroup v2 hierarchy below its root, the root could
+// point to a fixture tree instead of the live hierarchy.
+type Hierarchy struct {
+	Root string
+}
+
+// NewHierarchy returns the hierarchy mounted as cgroup2 in /proc/mounts, the
+// unified hierarchy of the hybrid setups is found as well.
+func NewHierarchy(fs procfs.FS) (*Hierarchy, error) {
+	mounts, err := fs.Mounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, mount := range mounts {
+		if mount.FSType == "cgroup2" {
+			return &Hierarchy{Root: mount.MountPoint}, nil
+		}
+	}
+	return nil, fmt.Errorf("cgroup v2 is not mounted, only the cgroup v2 hierarchy is supported")
+}
+
+// Memory are the memory files of a cgroup in bytes, Max and High are nil when unlimited
+type Memory struct {
+	Current uint64            `json:"current"`
+	High    *uint64           `json:"high,omitempty"`
+	Max     *uint64           `json:"max,omitempty"`
+	Swap    uint64            `json:"swap_current,omitempty"`
+	Events  map[string]uint64 `json:"events,omitempty"`
+	// Stat is a selection of memory.stat
+	Stat map[string]uint64 `json:"stat,omitempty"`
+}
+
+// CPU is the content of cpu.stat and cpu.max, the times are in microseconds
+type CPU struct {
+	UsageUsec     uint64 `json:"usage_usec"`
+	UserUsec      uint64 `json:"user_usec"`
+	SystemUsec    uint64 `json:"system_usec"`
+	NrPeriods     uint64 `json:"nr_periods"`
+	NrThrottled   uint64 `json:"nr_throttled"`
+	ThrottledUsec uint64 `json:"throttled_usec"`
+	// MaxCPUs is the quota of cpu.max in cpus, nil when unlimited
+	MaxCPUs *float64 `json:"max_cpus,omitempty"`
+	Weight  uint64   `json:"weight,omitempty"`
+}
+
+// IO is a device line of io.stat
+type IO struct {
+	Device string `json:"device"`
+	RBytes uint64 `json:"rbytes"`
+	WBytes uint64 `json:"wbytes"`
+	RIOs   uint64 `json:"rios"`
+	WIOs   uint64 `json:"wios"`
+}
+
+// Pids is the content of pids.current and pids.max, Max is nil when unlimited
+type Pids struct {
+	Current uint64  `json:"current"`
+	Max     *uint64 `json:"max,omitempty"`
+}
+
+// Group is the state of a cgroup, a controller not enabled for the cgroup is nil
+type Group struct {
+	// Path is the path of the cgroup below the root, / for the root cgroup
+	Path     string                     `json:"path"`
+	Identity                            // unit and container owning the cgroup
+	Memory   *Memory                    `json:"memory,omitempty"`
+	CPU      *CPU                       `json:"cpu,omitempty"`
+	IO       []IO                       `json:"io,omitempty"`
+	Pids     *Pids                      `json:"pids,omitempty"`
+	Pressure map[string]procfs.Pressure `json:"pressure,omitempty"`
+}
+
+// memoryStatKeys are the fields of memory.stat worth reporting
+var memoryStatKeys = []string{"anon", "file", "kernel", "sock", "shmem", "file_dirty", "file_writeback", "pgmajfault"}
+
+// dir returns the directory of the cgroup
+func (h *Hierarchy) dir(path string) string {
+	return filepath.Join(h.Root, filepath.FromSlash(path))
+}
+
+// Group reads the state of the cgroup with the given path below the root
+func (h *Hierarchy) Group(path string) (*Group, error) {
+	path = "/" + strings.Trim(filepath.ToSlash(filepath.Clean("/"+path)), "/")
+	dir := h.dir(path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("cgroup %s not found", path)
+	}
+
+	group := &Group{Path: path, Identity: Identify(path)}
+	if current, err := readUint(filepath.Join(dir, "memory.current")); err == nil {
+		group.Memory = &Memory{Current: current}
+		group.Memory.High, _ = readLimit(filepath.Join(dir, "memory.high"))
+		group.Memory.Max, _ = readLimit(filepath.Join(dir, "memory.max"))
+		group.Memory.Swap, _ = readUint(filepath.Join(dir, "memory.swap.current"))
+		group.Memory.Events, _ = readFlatKeyed(filepath.Join(dir, "memory.events"))
+		if stat, err := readFlatKeyed(filepath.Join(dir, "memory.stat")); err == nil {
+			group.Memory.Stat = make(map[string]uint64, len(memoryStatKeys))
+			for _, key := range memoryStatKeys {
+				if value, ok := stat[key]; ok {
+					group.Memory.Stat[key] = value
+				}
+			}
+		}
+	}
+	if stat, err := readFlatKeyed(filepath.Join(dir, "cpu.stat")); err == nil {
+		group.CPU = &CPU{
+			UsageUsec: stat["usage_usec"], UserUsec: stat["user_usec"], SystemUsec: stat["system_usec"],
+			NrPeriods: stat["nr_periods"], NrThrottled: stat["nr_throttled"], ThrottledUsec: stat["throttled_usec"],
+		}
+		group.CPU.MaxCPUs, _ = readCPUMax(filepath.Join(dir, "cpu.max"))
+		group.CPU.Weight, _ = readUint(filepath.Join(dir, "cpu.weight"))
+	}
+	group.IO, _ = readIOStat(filepath.Join(dir, "io.stat"))
+	if current, err := readUint(filepath.Join(dir, "pids.current")); err == nil {
+		group.Pids = &Pids{Current: current}
+		group.Pids.Max, _ = readLimit(filepath.Join(dir, "pids.max"))
+	}
+	for _, resource := range procfs.PressureResources {
+		pressure, err := procfs.ParsePressureFile(filepath.Join(dir, resource+".pressure"))
+		if err != nil {
+			continue
+		}
+		if group.Pressure == nil {
+			group.Pressure = make(map[string]procfs.Pressure, len(procfs.PressureResources))
+		}
+		group.Pressure[resource] = pressure
+	}
+	return group, nil
+}
+
+// PIDGroup returns the path of the cgroup of the process, from the 0:: line of /proc/[pid]/cgroup
+func PIDGroup(fs procfs.FS, pid int) (string, error) {
+	lines, err := fs.ProcCgroups(pid)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("process %d is not in a cgroup v2 hierarchy", pid)
+}
+
+// UnitPIDs returns the processes of the systemd units by unit, from the
+// cgroup.procs of the cgroups identified as the units or below them, like
+// the services of the users or the cgroups of a service.
+func (h *Hierarchy) UnitPIDs() (map[string][]int, error) {
+	units := make(map[string][]int)
+	err := filepath.WalkDir(h.Root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == h.Root {
+				return err
+			}
+			return fs.SkipDir
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(h.Root, path)
+		if err != nil {
+			return err
+		}
+		unit := Identify("/" + filepath.ToSlash(rel)).Unit
+		if unit == "" {
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Join(path, "cgroup.procs"))
+		if err != nil {
+			return nil
+		}
+		for _, field := range strings.Fields(string(data)) {
+			if pid, err := strconv.Atoi(field); err == nil {
+				units[unit] = append(units[unit], pid)
+			}
+		}
+		return nil
+	})
+	return units, err
+}
+
+// TotalIO returns the sum of the io.stat lines
+func (g *Group) TotalIO() IO {
+	total := IO{Device: "total"}
+	for _, io := range g.IO {
+		total.RBytes += io.RBytes
+		total.WBytes += io.WBytes
+		total.RIOs += io.RIOs
+		total.WIOs += io.WIOs
+	}
+	return total
+}
+
+func readString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readUint(path string) (uint64, error) {
+	value, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// readLimit reads a limit file, nil is returned for "max"
+func readLimit(path string) (*uint64, error) {
+	value, err := readString(path)
+	if err != nil || value == "max" {
+		return nil, err
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// readCPUMax reads cpu.max, "$MAX $PERIOD" where $MAX is max when unlimited
+func readCPUMax(path string) (*float64, error) {
+	value, err := readString(path)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(value)
+	if len(fields) != 2 || fields[0] == "max" {
+		return nil, nil
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period == 0 {
+		return nil, fmt.Errorf("invalid cpu.max %q", value)
+	}
+	cpus := math.Round(quota/period*100) / 100
+	return &cpus, nil
+}
+
+// readFlatKeyed reads the "key value" files like cpu.stat or memory.events
+func readFlatKeyed(path string) (map[string]uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if value, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = value
+		}
+	}
+	return values, scanner.Err()
+}
+
+// readIOStat reads io.stat, "MAJ:MIN rbytes=1 wbytes=2 rios=3 wios=4 ..." lines
+func readIOStat(path string) ([]IO, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stats []IO
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		io := IO{Device: fields[0]}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			number, _ := strconv.ParseUint(value, 10, 64)
+			switch key {
+			case "rbytes":
+				io.RBytes = number
+			case "wbytes":
+				io.WBytes = number
+			case "rios":
+				io.RIOs = number
+			case "wios":
+				io.WIOs = number
+			}
+		}
+		stats = append(stats, io)
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,35 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitPIDs(t *testing.T) {
+	root := t.TempDir()
+	for path, procs := range map[string]string{
+		"/":                                "1\n",
+		"/init.scope":                      "1\n",
+		"/system.slice/nginx.service":      "10\n11\n",
+		"/system.slice/docker.service/sub": "30\n",
+		"/user.slice/user-1000.slice/user@1000.service/app.slice/redis.service": "20\n",
+		"/user.slice/user-1000.slice/session-2.scope":                           "40\n",
+	} {
+		dir := filepath.Join(root, filepath.FromSlash(path))
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(procs), 0644))
+	}
+
+	units, err := (&Hierarchy{Root: root}).UnitPIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]int{
+		"init.scope":      {1},
+		"nginx.service":   {10, 11},
+		"docker.service":  {30},
+		"redis.service":   {20},
+		"session-2.scope": {40},
+	}, units)
+}
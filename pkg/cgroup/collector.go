@@ -0,0 +1,270 @@
+package cgroup
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/mathutil"
+)
+
+const (
+	SortByCPU       = "cpu"
+	SortByMemory    = "memory"
+	SortByThrottled = "throttled"
+	SortByIO        = "io"
+	SortByPids      = "pids"
+	SortByOOM       = "oom"
+	SortByPressure  = "pressure"
+
+	// maximal depth of the cgroups collected below the root, deep enough
+	// for the kubernetes pods and the user sessions
+	maxCollectDepth = 8
+)
+
+// Rates are the changes of the counters of a cgroup between two samples
+type Rates struct {
+	// CPUPercent is the cpu usage in percent of one cpu
+	CPUPercent float64 `json:"cpu_percent"`
+	// ThrottledPercent is the percentage of the enforcement periods which were throttled
+	ThrottledPercent float64 `json:"throttled_percent"`
+	// ThrottledMsPerSec is the time the cgroup was throttled per second
+	ThrottledMsPerSec float64 `json:"throttled_ms_per_sec"`
+	ReadBytesPerSec   float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSec  float64 `json:"write_bytes_per_sec"`
+	OOMKills          uint64  `json:"oom_kills"`
+	MemoryHighEvents  uint64  `json:"memory_high_events"`
+	MemoryMaxEvents   uint64  `json:"memory_max_events"`
+	Interval          string  `json:"interval"`
+}
+
+// GroupUsage is the state of a cgroup and its rates since the previous sample,
+// Rates is nil for the first sample of a cgroup.
+type GroupUsage struct {
+	*Group
+	Rates *Rates `json:"rates,omitempty"`
+}
+
+type sample struct {
+	group *Group
+	time  time.Time
+}
+
+// Collector samples all the cgroups of the hierarchy, the rates are computed
+// against the previous sample so the collector could be called periodically.
+type Collector struct {
+	hierarchy *Hierarchy
+	maxDepth  int
+	mu        sync.Mutex
+	previous  map[string]sample
+}
+
+func NewCollector(hierarchy *Hierarchy) *Collector {
+	return &Collector{hierarchy: hierarchy, maxDepth: maxCollectDepth, previous: make(map[string]sample)}
+}
+
+// SetMaxDepth sets the depth of the cgroups collected below the root, 1 only
+// collects the slices and the units directly below the root.
+func (c *Collector) SetMaxDepth(depth int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if depth > 0 {
+		c.maxDepth = depth
+	}
+}
+
+// Hierarchy returns the hierarchy sampled by the collector
+func (c *Collector) Hierarchy() *Hierarchy {
+	return c.hierarchy
+}
+
+// Collect samples every cgroup of the hierarchy
+func (c *Collector) Collect() ([]GroupUsage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	paths, err := c.paths()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	current := make(map[string]sample, len(paths))
+	usages := make([]GroupUsage, 0, len(paths))
+	for _, path := range paths {
+		group, err := c.hierarchy.Group(path)
+		if err != nil {
+			// The cgroup was removed during the walk.
+			continue
+		}
+		usage := GroupUsage{Group: group}
+		if previous, ok := c.previous[path]; ok {
+			usage.Rates = rates(previous, sample{group: group, time: now})
+		}
+		current[path] = sample{group: group, time: now}
+		usages = append(usages, usage)
+	}
+	c.previous = current
+	return usages, nil
+}
+
+// Top samples the cgroups twice, interval apart, and returns the n first
+// cgroups sorted by one of the SortBy constants, the root cgroup is left out.
+func (c *Collector) Top(ctx context.Context, sortBy string, n int, interval time.Duration) ([]GroupUsage, error) {
+	key, err := sortKey(sortBy)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.Collect(); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+	}
+	usages, err := c.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	top := make([]GroupUsage, 0, len(usages))
+	for _, usage := range usages {
+		if usage.Path != "/" {
+			top = append(top, usage)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return key(top[i]) > key(top[j])
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top, nil
+}
+
+// paths returns the paths of the cgroups below the root, up to the maximal depth
+func (c *Collector) paths() ([]string, error) {
+	root := c.hierarchy.Root
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return fs.SkipDir
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			paths = append(paths, "/")
+			return nil
+		}
+		paths = append(paths, "/"+filepath.ToSlash(rel))
+		if strings.Count(rel, string(filepath.Separator)) >= c.maxDepth-1 {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func rates(previous, current sample) *Rates {
+	seconds := current.time.Sub(previous.time).Seconds()
+	if seconds <= 0 {
+		return nil
+	}
+	r := &Rates{Interval: current.time.Sub(previous.time).Round(time.Millisecond).String()}
+	if before, after := previous.group.CPU, current.group.CPU; before != nil && after != nil {
+		r.CPUPercent = mathutil.Round2(float64(delta(before.UsageUsec, after.UsageUsec)) / 1e6 / seconds * 100)
+		r.ThrottledMsPerSec = mathutil.Round2(float64(delta(before.ThrottledUsec, after.ThrottledUsec)) / 1e3 / seconds)
+		if periods := delta(before.NrPeriods, after.NrPeriods); periods > 0 {
+			r.ThrottledPercent = mathutil.Round2(float64(delta(before.NrThrottled, after.NrThrottled)) * 100 / float64(periods))
+		}
+	}
+	before, after := previous.group.TotalIO(), current.group.TotalIO()
+	r.ReadBytesPerSec = mathutil.Round2(float64(delta(before.RBytes, after.RBytes)) / seconds)
+	r.WriteBytesPerSec = mathutil.Round2(float64(delta(before.WBytes, after.WBytes)) / seconds)
+	if before, after := previous.group.Memory, current.group.Memory; before != nil && after != nil {
+		r.OOMKills = delta(before.Events["oom_kill"], after.Events["oom_kill"])
+		r.MemoryHighEvents = delta(before.Events["high"], after.Events["high"])
+		r.MemoryMaxEvents = delta(before.Events["max"], after.Events["max"])
+	}
+	return r
+}
+
+// delta returns the increase of a counter, 0 when it was reset
+func delta(before, after uint64) uint64 {
+	if after < before {
+		return 0
+	}
+	return after - before
+}
+
+func sortKey(sortBy string) (func(GroupUsage) float64, error) {
+	switch sortBy {
+	case SortByCPU, "":
+		return func(u GroupUsage) float64 {
+			if u.Rates == nil {
+				return 0
+			}
+			return u.Rates.CPUPercent
+		}, nil
+	case SortByMemory:
+		return func(u GroupUsage) float64 {
+			if u.Memory == nil {
+				return 0
+			}
+			return float64(u.Memory.Current)
+		}, nil
+	case SortByThrottled:
+		return func(u GroupUsage) float64 {
+			if u.Rates == nil {
+				return 0
+			}
+			return u.Rates.ThrottledPercent
+		}, nil
+	case SortByIO:
+		return func(u GroupUsage) float64 {
+			if u.Rates == nil {
+				return 0
+			}
+			return u.Rates.ReadBytesPerSec + u.Rates.WriteBytesPerSec
+		}, nil
+	case SortByPids:
+		return func(u GroupUsage) float64 {
+			if u.Pids == nil {
+				return 0
+			}
+			return float64(u.Pids.Current)
+		}, nil
+	case SortByOOM:
+		return func(u GroupUsage) float64 {
+			if u.Memory == nil {
+				return 0
+			}
+			return float64(u.Memory.Events["oom_kill"])
+		}, nil
+	case SortByPressure:
+		return func(u GroupUsage) float64 {
+			pressure := 0.0
+			for _, p := range u.Pressure {
+				pressure = max(pressure, p.Some.Avg10)
+			}
+			return pressure
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown sort %q, use cpu, memory, throttled, io, pids, oom or pressure", sortBy)
+}
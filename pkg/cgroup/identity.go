@@ -0,0 +1,68 @@
+package cgroup
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Identity is what owns a cgroup, the systemd unit and the container
+type Identity struct {
+	// Unit is the deepest systemd unit of the path, like nginx.service
+	Unit string `json:"unit,omitempty"`
+	// Slice is the deepest systemd slice of the path, like system.slice
+	Slice       string `json:"slice,omitempty"`
+	ContainerID string `json:"container_id,omitempty"`
+	// Runtime is the container runtime guessed from the path, like docker or containerd
+	Runtime string `json:"runtime,omitempty"`
+	// PodUID is the UID of the kubernetes pod
+	PodUID string `json:"pod_uid,omitempty"`
+}
+
+var (
+	// docker-<id>.scope, cri-containerd-<id>.scope, crio-<id>.scope, libpod-<id>.scope or a bare <id>
+	containerRegexp = regexp.MustCompile(`^(?:(docker|cri-containerd|crio|libpod)-)?([0-9a-f]{64})(?:\.scope)?$`)
+	podRegexp       = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+	unitSuffixes    = []string{".service", ".scope", ".socket", ".mount", ".swap"}
+)
+
+// runtimes maps the prefixes of the container scopes and the parent
+// directories of the cgroupfs driver to the runtimes
+var runtimes = map[string]string{
+	"docker":         "docker",
+	"cri-containerd": "containerd",
+	"crio":           "cri-o",
+	"libpod":         "podman",
+	"lxc":            "lxc",
+	"machine.slice":  "systemd-machined",
+}
+
+// Identify maps the path of a cgroup to its systemd unit and container
+func Identify(path string) Identity {
+	identity := Identity{}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if strings.HasSuffix(part, ".slice") {
+			identity.Slice = part
+		}
+		for _, suffix := range unitSuffixes {
+			if strings.HasSuffix(part, suffix) {
+				identity.Unit = part
+			}
+		}
+		if match := podRegexp.FindStringSubmatch(part); match != nil {
+			identity.PodUID = strings.ReplaceAll(match[1], "_", "-")
+		}
+		if match := containerRegexp.FindStringSubmatch(part); match != nil {
+			identity.ContainerID = match[2]
+			switch {
+			case match[1] != "":
+				identity.Runtime = runtimes[match[1]]
+			case i > 0 && runtimes[parts[i-1]] != "":
+				identity.Runtime = runtimes[parts[i-1]]
+			case identity.PodUID != "":
+				identity.Runtime = "kubernetes"
+			}
+		}
+	}
+	return identity
+}
@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"github.com/darmenliu/ai-agentic-monitor/pkg/cgroup"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+// depth of the cgroups sampled at every interval, the slices and their units
+// or containers like /system.slice/nginx.service
+const cgroupDepth = 2
+
+// collectCgroups samples the cpu, memory and io of the cgroups, by cgroup
+// path, the hierarchy is looked up again until cgroup v2 is found. The series
+// of the removed cgroups, like the scopes of the sessions, are removed.
+func (c *Collector) collectCgroups(current *snapshot) error {
+	defer c.store.PrunePrefix("cgroup.", current.time.Add(-staleSeriesAfter))
+	if c.cgroups == nil {
+		hierarchy, err := cgroup.NewHierarchy(c.fs)
+		if err != nil {
+			return err
+		}
+		c.cgroups = cgroup.NewCollector(hierarchy)
+		c.cgroups.SetMaxDepth(cgroupDepth)
+	}
+
+	usages, err := c.cgroups.Collect()
+	if err != nil {
+		return err
+	}
+	for _, usage := range usages {
+		if usage.Path == "/" {
+			continue
+		}
+		labels := metrics.Labels{"cgroup": usage.Path}
+		if memory := usage.Memory; memory != nil {
+			c.add("cgroup.memory_bytes", labels, current.time, float64(memory.Current))
+			if memory.Max != nil && *memory.Max > 0 {
+				c.add("cgroup.memory_max_percent", labels, current.time, float64(memory.Current)*100/float64(*memory.Max))
+			}
+		}
+		// The rates start with the second sample of a cgroup.
+		if rates := usage.Rates; rates != nil {
+			if usage.CPU != nil {
+				c.add("cgroup.cpu_percent", labels, current.time, rates.CPUPercent)
+				c.add("cgroup.throttled_percent", labels, current.time, rates.ThrottledPercent)
+			}
+			c.add("cgroup.read_bytes_per_sec", labels, current.time, rates.ReadBytesPerSec)
+			c.add("cgroup.write_bytes_per_sec", labels, current.time, rates.WriteBytesPerSec)
+		}
+	}
+	return nil
+}
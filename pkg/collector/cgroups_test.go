@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+// writeCgroup writes the files of a fake cgroup below the root
+func writeCgroup(t *testing.T, root, path string, files map[string]string) {
+	dir := filepath.Join(root, filepath.FromSlash(path))
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func seriesByName(store *metrics.Store, prefix string) map[string]map[string]bool {
+	found := make(map[string]map[string]bool)
+	for _, info := range store.List(prefix) {
+		if found[info.Name] == nil {
+			found[info.Name] = make(map[string]bool)
+		}
+		found[info.Name][info.Labels["cgroup"]] = true
+	}
+	return found
+}
+
+func TestCollectCgroups(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "cgroup")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "proc"), 0755))
+	mounts := fmt.Sprintf("cgroup2 %s cgroup2 rw,nosuid,nodev,noexec 0 0\n", root)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "proc", "mounts"), []byte(mounts), 0644))
+
+	writeCgroup(t, root, "/", map[string]string{"cpu.stat": "usage_usec 9000000\n"})
+	writeCgroup(t, root, "/system.slice", map[string]string{"memory.current": "6000\n", "memory.max": "max\n"})
+	nginx := map[string]string{
+		"memory.current": "1000\n",
+		"memory.max":     "4000\n",
+		"cpu.stat":       "usage_usec 1000000\nnr_periods 10\nnr_throttled 0\nthrottled_usec 0\n",
+		"io.stat":        "8:0 rbytes=4096 wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n",
+	}
+	writeCgroup(t, root, "/system.slice/nginx.service", nginx)
+	// below the collected depth
+	writeCgroup(t, root, "/system.slice/nginx.service/worker", map[string]string{"memory.current": "500\n"})
+
+	store := metrics.NewStore(10, 0)
+	c := New(procfs.NewFS(filepath.Join(dir, "proc"), filepath.Join(dir, "sys")), store, time.Second, time.Minute)
+	assert.NoError(t, c.collectCgroups(&snapshot{time: time.Now()}))
+
+	found := seriesByName(store, "cgroup.")
+	assert.Equal(t, map[string]bool{"/system.slice": true, "/system.slice/nginx.service": true}, found["cgroup.memory_bytes"])
+	assert.Equal(t, map[string]bool{"/system.slice/nginx.service": true}, found["cgroup.memory_max_percent"])
+	assert.NotContains(t, found, "cgroup.cpu_percent", "the rates start with the second sample")
+
+	results, err := store.Query(metrics.Query{Name: "cgroup.memory_max_percent", From: time.Now().Add(-time.Minute)})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) && assert.Len(t, results[0].Points, 1) {
+		assert.Equal(t, 25.0, results[0].Points[0].Value)
+	}
+
+	nginx["cpu.stat"] = "usage_usec 3000000\nnr_periods 20\nnr_throttled 10\nthrottled_usec 5000\n"
+	nginx["io.stat"] = "8:0 rbytes=8192 wbytes=4096 rios=2 wios=1 dbytes=0 dios=0\n"
+	writeCgroup(t, root, "/system.slice/nginx.service", nginx)
+	assert.NoError(t, c.collectCgroups(&snapshot{time: time.Now()}))
+
+	found = seriesByName(store, "cgroup.")
+	for _, name := range []string{"cgroup.cpu_percent", "cgroup.throttled_percent", "cgroup.read_bytes_per_sec", "cgroup.write_bytes_per_sec"} {
+		assert.True(t, found[name]["/system.slice/nginx.service"], name)
+	}
+	// the slice has no cpu controller
+	assert.False(t, found["cgroup.cpu_percent"]["/system.slice"])
+	assert.NotContains(t, found["cgroup.memory_bytes"], "/")
+}
+
+func TestCollectCgroupsWithoutHierarchy(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "mounts"), []byte("proc /proc proc rw 0 0\n"), 0644))
+	c := New(procfs.NewFS(dir, dir), metrics.NewStore(10, 0), time.Second, time.Minute)
+	assert.Error(t, c.collectCgroups(&snapshot{time: time.Now()}))
+	assert.Nil(t, c.cgroups)
+}
@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/cgroup"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/psi"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// size of a sector of /proc/diskstats, whatever the sector size of the device
+	sectorSize = 512
+	// processes with the largest resident memory sampled at every interval
+	topProcesses = 20
+	// processes younger than this are not sampled, so the short jobs do not
+	// fill the store with series of a few samples
+	minProcessAge = 5 * time.Minute
+	// the series of the processes and cgroups without sample for this time
+	// are removed, their pids and paths are not reused
+	staleSeriesAfter = 10 * time.Minute
+	// minimal time between two warnings about the series dropped by the full store
+	droppedWarningInterval = time.Hour
+	// time between two samples of the network filesystems, their statfs
+	// could wait for an unreachable server
+	networkFSInterval = 5 * time.Minute
+)
+
+// snapshot are the counters of a sample, the rates are computed against the previous snapshot
+type snapshot struct {
+	time   time.Time
+	stat   *procfs.Stat
+	vmstat map[string]uint64
+	disks  map[string]procfs.DiskStat
+	nets   map[string]procfs.NetDevStat
+}
+
+// Collector samples the system metrics from /proc into the store at every
+// interval, the counters are stored as rates per second.
+type Collector struct {
+	fs       procfs.FS
+	store    *metrics.Store
+	pressure *psi.Collector
+	// cgroups is nil until the cgroup v2 hierarchy is found
+	cgroups   *cgroup.Collector
+	interval  time.Duration
+	retention time.Duration
+	previous  *snapshot
+	// networkFSTime is the time the network filesystems were last sampled
+	networkFSTime time.Time
+	// dropped is the number of samples dropped by the store at the last warning
+	dropped        uint64
+	droppedWarning time.Time
+}
+
+// New creates a collector sampling every interval, the store should keep
+// retention/interval samples by series.
+func New(fs procfs.FS, store *metrics.Store, interval, retention time.Duration) *Collector {
+	return &Collector{
+		fs:        fs,
+		store:     store,
+		pressure:  psi.NewCollector(fs, nil),
+		interval:  interval,
+		retention: retention,
+	}
+}
+
+// Store returns the store the collector writes to
+func (c *Collector) Store() *metrics.Store {
+	return c.store
+}
+
+// Interval returns the time between two samples
+func (c *Collector) Interval() time.Duration {
+	return c.interval
+}
+
+// Run samples the metrics until the context is done
+func (c *Collector) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	c.Collect(time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			c.Collect(now)
+			c.store.Prune(now.Add(-c.retention))
+		}
+	}
+}
+
+// Collect takes a sample of all the metrics, the metrics which could not be
+// read are skipped and logged.
+func (c *Collector) Collect(now time.Time) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	current := &snapshot{time: now}
+	for _, sample := range []struct {
+		name    string
+		collect func(*snapshot) error
+	}{
+		{"cpu", c.collectCPU},
+		{"memory", c.collectMemory},
+		{"load", c.collectLoad},
+		{"disk", c.collectDisks},
+		{"filesystem", c.collectFilesystems},
+		{"network", c.collectNetwork},
+		{"fd", c.collectFDs},
+		{"pressure", c.collectPressure},
+		{"cgroup", c.collectCgroups},
+		{"process", c.collectProcesses},
+	} {
+		if err := sample.collect(current); err != nil {
+			logger.Debug("ai-agentic-monitor: failed to collect metrics,", logger.Args("metrics", sample.name, "err", err.Error()))
+		}
+	}
+	c.previous = current
+
+	// The new series are refused once the store is full, like a new disk
+	// whose rules and forecasts would never see a sample.
+	if stats := c.store.Stats(); stats.Dropped > c.dropped && now.Sub(c.droppedWarning) >= droppedWarningInterval {
+		logger.Warn("ai-agentic-monitor: the metrics store is full, the samples of new series are dropped,",
+			logger.Args("max_series", stats.MaxSeries, "dropped", stats.Dropped-c.dropped))
+		c.dropped, c.droppedWarning = stats.Dropped, now
+	}
+}
+
+func (c *Collector) add(name string, labels metrics.Labels, t time.Time, value float64) {
+	c.store.Append(name, labels, t, value)
+}
+
+// elapsed returns the seconds since the previous snapshot, 0 without previous snapshot
+func (c *Collector) elapsed(current *snapshot) float64 {
+	if c.previous == nil {
+		return 0
+	}
+	return current.time.Sub(c.previous.time).Seconds()
+}
+
+// rate returns the increase per second of a counter, 0 when it was reset
+func rate(before, after uint64, seconds float64) float64 {
+	if after < before || seconds <= 0 {
+		return 0
+	}
+	return float64(after-before) / seconds
+}
@@ -0,0 +1,250 @@
+package collector
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/disk"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+func (c *Collector) collectCPU(current *snapshot) error {
+	stat, err := c.fs.Stat()
+	if err != nil {
+		return err
+	}
+	current.stat = &stat
+	c.add("procs.running", nil, current.time, float64(stat.ProcsRunning))
+	c.add("procs.blocked", nil, current.time, float64(stat.ProcsBlocked))
+	if c.previous == nil || c.previous.stat == nil {
+		return nil
+	}
+
+	before, after := c.previous.stat.Total, stat.Total
+	total := float64(after.Total()) - float64(before.Total())
+	if total <= 0 {
+		return nil
+	}
+	percent := func(before, after uint64) float64 {
+		if after < before {
+			return 0
+		}
+		return float64(after-before) * 100 / total
+	}
+	c.add("cpu.usage_percent", nil, current.time, percent(before.Busy(), after.Busy()))
+	c.add("cpu.user_percent", nil, current.time, percent(before.User+before.Nice, after.User+after.Nice))
+	c.add("cpu.system_percent", nil, current.time, percent(before.System+before.IRQ+before.SoftIRQ, after.System+after.IRQ+after.SoftIRQ))
+	c.add("cpu.iowait_percent", nil, current.time, percent(before.IOWait, after.IOWait))
+	c.add("cpu.steal_percent", nil, current.time, percent(before.Steal, after.Steal))
+	c.add("cpu.context_switches_per_sec", nil, current.time, rate(c.previous.stat.ContextSwitches, stat.ContextSwitches, c.elapsed(current)))
+	return nil
+}
+
+func (c *Collector) collectMemory(current *snapshot) error {
+	meminfo, err := c.fs.MemInfo()
+	if err != nil {
+		return err
+	}
+	// /proc/meminfo is in kB
+	total, available := meminfo["MemTotal"]*1024, meminfo["MemAvailable"]*1024
+	if total > 0 {
+		c.add("memory.total_bytes", nil, current.time, float64(total))
+		c.add("memory.available_bytes", nil, current.time, float64(available))
+		c.add("memory.used_bytes", nil, current.time, float64(total-min(available, total)))
+		c.add("memory.used_percent", nil, current.time, float64(total-min(available, total))*100/float64(total))
+	}
+	c.add("memory.cached_bytes", nil, current.time, float64(meminfo["Cached"]*1024))
+	c.add("memory.dirty_bytes", nil, current.time, float64(meminfo["Dirty"]*1024))
+
+	swapTotal, swapFree := meminfo["SwapTotal"]*1024, meminfo["SwapFree"]*1024
+	c.add("swap.used_bytes", nil, current.time, float64(swapTotal-min(swapFree, swapTotal)))
+	if swapTotal > 0 {
+		c.add("swap.used_percent", nil, current.time, float64(swapTotal-min(swapFree, swapTotal))*100/float64(swapTotal))
+	}
+
+	vmstat, err := c.fs.VMStat()
+	if err != nil {
+		return err
+	}
+	current.vmstat = vmstat
+	if c.previous != nil && c.previous.vmstat != nil {
+		seconds := c.elapsed(current)
+		c.add("swap.in_pages_per_sec", nil, current.time, rate(c.previous.vmstat["pswpin"], vmstat["pswpin"], seconds))
+		c.add("swap.out_pages_per_sec", nil, current.time, rate(c.previous.vmstat["pswpout"], vmstat["pswpout"], seconds))
+		c.add("memory.major_faults_per_sec", nil, current.time, rate(c.previous.vmstat["pgmajfault"], vmstat["pgmajfault"], seconds))
+	}
+	return nil
+}
+
+func (c *Collector) collectLoad(current *snapshot) error {
+	load, err := c.fs.LoadAvg()
+	if err != nil {
+		return err
+	}
+	c.add("load.load1", nil, current.time, load.Load1)
+	c.add("load.load5", nil, current.time, load.Load5)
+	c.add("load.load15", nil, current.time, load.Load15)
+	c.add("procs.total", nil, current.time, float64(load.Total))
+	return nil
+}
+
+func (c *Collector) collectDisks(current *snapshot) error {
+	disks, err := c.fs.DiskStats()
+	if err != nil {
+		return err
+	}
+	current.disks = make(map[string]procfs.DiskStat, len(disks))
+	seconds := c.elapsed(current)
+	for _, stat := range disks {
+		current.disks[stat.Device] = stat
+		previous, ok := c.previous.disk(stat.Device)
+		if !ok || seconds <= 0 {
+			continue
+		}
+		labels := metrics.Labels{"device": stat.Device}
+		c.add("disk.read_bytes_per_sec", labels, current.time, rate(previous.SectorsRead, stat.SectorsRead, seconds)*sectorSize)
+		c.add("disk.write_bytes_per_sec", labels, current.time, rate(previous.SectorsWritten, stat.SectorsWritten, seconds)*sectorSize)
+		c.add("disk.reads_per_sec", labels, current.time, rate(previous.Reads, stat.Reads, seconds))
+		c.add("disk.writes_per_sec", labels, current.time, rate(previous.Writes, stat.Writes, seconds))
+		// io_ms is the time the device had requests in flight, in ms per second
+		c.add("disk.util_percent", labels, current.time, min(rate(previous.IOTime, stat.IOTime, seconds)/10, 100))
+		c.add("disk.in_flight", labels, current.time, float64(stat.InFlight))
+	}
+	return nil
+}
+
+// collectFilesystems samples the usage of the mounted filesystems, by mount
+// point, the network filesystems only every networkFSInterval.
+func (c *Collector) collectFilesystems(current *snapshot) error {
+	withNetworkFS := current.time.Sub(c.networkFSTime) >= networkFSInterval
+	if withNetworkFS {
+		c.networkFSTime = current.time
+	}
+	usages, err := disk.UsageOf(c.fs, func(mount procfs.Mount) bool {
+		return withNetworkFS || !disk.IsNetworkFS(mount.FSType)
+	})
+	if err != nil {
+		return err
+	}
+	for _, usage := range usages {
+		if usage.Error != "" {
+			continue
+		}
+		labels := metrics.Labels{"mount": usage.MountPoint}
+		c.add("disk.used_percent", labels, current.time, usage.UsedPercent)
+		c.add("disk.avail_bytes", labels, current.time, float64(usage.AvailBytes))
+		if usage.Inodes > 0 {
+			c.add("disk.inodes_used_percent", labels, current.time, usage.InodesUsedPercent)
+		}
+	}
+	return nil
+}
+
+func (c *Collector) collectNetwork(current *snapshot) error {
+	interfaces, err := c.fs.NetDev()
+	if err != nil {
+		return err
+	}
+	current.nets = make(map[string]procfs.NetDevStat, len(interfaces))
+	seconds := c.elapsed(current)
+	for _, stat := range interfaces {
+		current.nets[stat.Interface] = stat
+		previous, ok := c.previous.net(stat.Interface)
+		if !ok || seconds <= 0 {
+			continue
+		}
+		labels := metrics.Labels{"interface": stat.Interface}
+		c.add("net.rx_bytes_per_sec", labels, current.time, rate(previous.RxBytes, stat.RxBytes, seconds))
+		c.add("net.tx_bytes_per_sec", labels, current.time, rate(previous.TxBytes, stat.TxBytes, seconds))
+		c.add("net.rx_packets_per_sec", labels, current.time, rate(previous.RxPackets, stat.RxPackets, seconds))
+		c.add("net.tx_packets_per_sec", labels, current.time, rate(previous.TxPackets, stat.TxPackets, seconds))
+		c.add("net.errors_per_sec", labels, current.time, rate(previous.RxErrors+previous.TxErrors, stat.RxErrors+stat.TxErrors, seconds))
+		c.add("net.dropped_per_sec", labels, current.time, rate(previous.RxDropped+previous.TxDropped, stat.RxDropped+stat.TxDropped, seconds))
+	}
+	return nil
+}
+
+func (c *Collector) collectFDs(current *snapshot) error {
+	fileNr, err := c.fs.FileNr()
+	if err != nil {
+		return err
+	}
+	c.add("fd.allocated", nil, current.time, float64(fileNr.Allocated))
+	if fileNr.Max > 0 {
+		c.add("fd.used_percent", nil, current.time, float64(fileNr.Allocated)*100/float64(fileNr.Max))
+	}
+	return nil
+}
+
+func (c *Collector) collectPressure(current *snapshot) error {
+	samples, err := c.pressure.Collect(nil)
+	if err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		labels := metrics.Labels{"resource": sample.Resource}
+		c.add("pressure.some_avg10", labels, current.time, sample.Some.Avg10)
+		if sample.Some.Percent != nil {
+			c.add("pressure.some_percent", labels, current.time, *sample.Some.Percent)
+		}
+		if sample.Full != nil && sample.Full.Percent != nil {
+			c.add("pressure.full_percent", labels, current.time, *sample.Full.Percent)
+		}
+	}
+	return nil
+}
+
+// collectProcesses samples the resident memory of the largest processes, by
+// pid and command, the memory leaks are found on their growth. The series of
+// the processes which exited or left the largest ones are removed.
+func (c *Collector) collectProcesses(current *snapshot) error {
+	defer c.store.PrunePrefix("process.", current.time.Add(-staleSeriesAfter))
+	pids, err := c.fs.AllPIDs()
+	if err != nil {
+		return err
+	}
+	// the start times of the processes are in ticks since the boot
+	var maxStartTime uint64
+	if current.stat != nil {
+		uptime := current.time.Sub(time.Unix(int64(current.stat.BootTime), 0)) - minProcessAge
+		maxStartTime = uint64(max(uptime.Seconds(), 0) * procfs.UserHZ)
+	}
+	stats := make([]procfs.ProcStat, 0, len(pids))
+	for _, pid := range pids {
+		// the processes could exit while they are read
+		stat, err := c.fs.ProcStat(pid)
+		if err != nil || stat.RSSPages <= 0 {
+			continue
+		}
+		if current.stat != nil && stat.StartTime > maxStartTime {
+			continue
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].RSSPages > stats[j].RSSPages })
+	pageSize := int64(os.Getpagesize())
+	for _, stat := range stats[:min(len(stats), topProcesses)] {
+		labels := metrics.Labels{"pid": strconv.Itoa(stat.PID), "comm": stat.Comm}
+		c.add("process.rss_bytes", labels, current.time, float64(stat.RSSPages*pageSize))
+	}
+	return nil
+}
+
+func (s *snapshot) disk(device string) (procfs.DiskStat, bool) {
+	if s == nil || s.disks == nil {
+		return procfs.DiskStat{}, false
+	}
+	disk, ok := s.disks[device]
+	return disk, ok
+}
+
+func (s *snapshot) net(name string) (procfs.NetDevStat, bool) {
+	if s == nil || s.nets == nil {
+		return procfs.NetDevStat{}, false
+	}
+	stat, ok := s.nets[name]
+	return stat, ok
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+func TestCollectFilesystemsSamplesNetworkFSSlowly(t *testing.T) {
+	dir := t.TempDir()
+	local, remote := filepath.Join(dir, "data"), filepath.Join(dir, "nfs")
+	assert.NoError(t, os.Mkdir(local, 0755))
+	assert.NoError(t, os.Mkdir(remote, 0755))
+	mounts := fmt.Sprintf("/dev/sda1 %s ext4 rw 0 0\nserver:/export %s nfs4 rw 0 0\n", local, remote)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "mounts"), []byte(mounts), 0644))
+
+	store := metrics.NewStore(10, 0)
+	c := New(procfs.NewFS(dir, dir), store, time.Second, time.Hour)
+	start := time.Now()
+	count := func(mount string) int {
+		results, err := store.Query(metrics.Query{Name: "disk.used_percent", Labels: metrics.Labels{"mount": mount}, From: start.Add(-time.Minute), To: start.Add(time.Hour)})
+		assert.NoError(t, err)
+		if len(results) == 0 {
+			return 0
+		}
+		return len(results[0].Points)
+	}
+
+	for _, elapsed := range []time.Duration{0, time.Minute, networkFSInterval} {
+		assert.NoError(t, c.collectFilesystems(&snapshot{time: start.Add(elapsed)}))
+	}
+	assert.Equal(t, 3, count(local))
+	assert.Equal(t, 2, count(remote))
+}
+
+// writeProcess writes the stat of a fake process started the seconds after the boot
+func writeProcess(t *testing.T, proc string, pid int, comm string, started, rssPages int) {
+	dir := filepath.Join(proc, strconv.Itoa(pid))
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	fields := make([]string, 50)
+	for i := range fields {
+		fields[i] = "0"
+	}
+	fields[0] = "S"
+	fields[19] = strconv.Itoa(started * procfs.UserHZ)
+	fields[21] = strconv.Itoa(rssPages)
+	stat := fmt.Sprintf("%d (%s) %s\n", pid, comm, strings.Join(fields, " "))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644))
+}
+
+func TestCollectProcesses(t *testing.T) {
+	proc := t.TempDir()
+	boot := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.NoError(t, os.WriteFile(filepath.Join(proc, "stat"), []byte(fmt.Sprintf("cpu 1 0 1 10 0 0 0 0 0 0\nbtime %d\n", boot.Unix())), 0644))
+	now := boot.Add(time.Hour)
+	writeProcess(t, proc, 1, "systemd", 1, 1000)
+	writeProcess(t, proc, 200, "postgres", 600, 5000)
+	// a short job started a minute ago
+	writeProcess(t, proc, 300, "gcc", 3540, 9000)
+
+	store := metrics.NewStore(100, 0)
+	c := New(procfs.NewFS(proc, proc), store, time.Minute, time.Hour)
+	pids := func() []string {
+		var pids []string
+		for _, info := range store.List("process.") {
+			pids = append(pids, info.Labels["pid"])
+		}
+		return pids
+	}
+	collect := func(at time.Time) {
+		current := &snapshot{time: at}
+		assert.NoError(t, c.collectCPU(current))
+		assert.NoError(t, c.collectProcesses(current))
+	}
+
+	collect(now)
+	assert.ElementsMatch(t, []string{"1", "200"}, pids())
+
+	// the series of an exited process are removed once stale
+	assert.NoError(t, os.RemoveAll(filepath.Join(proc, "200")))
+	collect(now.Add(staleSeriesAfter))
+	assert.ElementsMatch(t, []string{"1", "200", "300"}, pids())
+	collect(now.Add(staleSeriesAfter + time.Minute))
+	assert.ElementsMatch(t, []string{"1", "300"}, pids())
+}
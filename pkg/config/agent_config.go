@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	LogSourceFile    = "file"
+	LogSourceJournal = "journal"
+
+	// default interval between two samples of the metrics and default time they are kept
+	DefaultMetricsInterval  = 5 * time.Second
+	DefaultMetricsRetention = 6 * time.Hour
+	// minimal interval between two samples of the metrics
+	minMetricsInterval = time.Second
+
+	// default age and number of the investigation sessions kept
+	DefaultSessionsMaxAge   = 30 * 24 * time.Hour
+	DefaultSessionsMaxCount = 500
+)
+
+// AgentConfig is the content of the agent config file, it configures what
+// the tools of the agent are allowed to read.
+type AgentConfig struct {
+	LogSources []LogSource       `yaml:"log_sources"`
+	LogMining  LogMiningSettings `yaml:"log_mining"`
+	Metrics    MetricsSettings   `yaml:"metrics"`
+	Sessions   SessionsSettings  `yaml:"sessions"`
+	// Host is the profile of the host given to the agent, nil when not described
+	Host *HostProfile `yaml:"host,omitempty"`
+}
+
+// LogSource is a log the agent is allowed to read, Path is a glob and the
+// rotated files of the matched files (.1, .gz, -20240101) are read as well.
+type LogSource struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+	// Type is file for plain log files or journal for journalctl exports (-o export or -o json)
+	Type string `yaml:"type"`
+}
+
+// LogMiningSettings tune the clustering of the log lines into templates,
+// the zero values are replaced by the defaults of the miner.
+type LogMiningSettings struct {
+	// Similarity is the minimal ratio of equal tokens for a line to join a template
+	Similarity float64 `yaml:"similarity"`
+	// Depth is the depth of the parse tree, the first Depth-2 tokens select the candidate templates
+	Depth int `yaml:"depth"`
+	// SpikeFactor is how many times its usual rate a template must reach to be reported as spiking
+	SpikeFactor float64 `yaml:"spike_factor"`
+	// MinSpikeCount is the minimal number of lines of a spiking template
+	MinSpikeCount int `yaml:"min_spike_count"`
+	// MaxTemplates is the maximal number of templates kept for each source
+	MaxTemplates int `yaml:"max_templates"`
+}
+
+// MetricsSettings tune the collector of the system metrics, the samples are
+// kept in memory so the retention bounds the memory used.
+type MetricsSettings struct {
+	// Interval is the time between two samples, 5s when empty
+	Interval string `yaml:"interval"`
+	// Retention is how long the samples are kept, 6h when empty
+	Retention string `yaml:"retention"`
+	// MaxSeries is the maximal number of series, like the metrics of every disk and interface
+	MaxSeries int `yaml:"max_series"`
+}
+
+// GetInterval returns the interval between two samples
+func (s *MetricsSettings) GetInterval() (time.Duration, error) {
+	if s.Interval == "" {
+		return DefaultMetricsInterval, nil
+	}
+	interval, err := time.ParseDuration(s.Interval)
+	if err != nil || interval < minMetricsInterval {
+		return 0, fmt.Errorf("invalid interval %q, it must be at least %s", s.Interval, minMetricsInterval)
+	}
+	return interval, nil
+}
+
+// GetRetention returns how long the samples are kept
+func (s *MetricsSettings) GetRetention() (time.Duration, error) {
+	if s.Retention == "" {
+		return DefaultMetricsRetention, nil
+	}
+	retention, err := time.ParseDuration(s.Retention)
+	if err != nil || retention <= 0 {
+		return 0, fmt.Errorf("invalid retention %q", s.Retention)
+	}
+	return retention, nil
+}
+
+// SessionsSettings bound the investigation sessions kept on disk, the older
+// ones are removed after every run of the agent.
+type SessionsSettings struct {
+	// MaxAge removes the sessions not updated for this time, 720h when empty
+	MaxAge string `yaml:"max_age"`
+	// MaxCount is the number of most recent sessions kept, 500 when 0
+	MaxCount int `yaml:"max_count"`
+}
+
+// GetMaxAge returns the time after which a session is removed
+func (s *SessionsSettings) GetMaxAge() (time.Duration, error) {
+	return parsePositiveDuration("max_age", s.MaxAge, DefaultSessionsMaxAge)
+}
+
+// GetMaxCount returns the number of sessions kept
+func (s *SessionsSettings) GetMaxCount() int {
+	if s.MaxCount <= 0 {
+		return DefaultSessionsMaxCount
+	}
+	return s.MaxCount
+}
+
+// NewAgentYamlConfig loads the agent config, a missing file means an empty config
+func NewAgentYamlConfig(configPath string) (*AgentConfig, error) {
+	cfg := &AgentConfig{}
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading agent config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing agent config file: %w", err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid agent configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+func (c *AgentConfig) validate() error {
+	mining := c.LogMining
+	if mining.Similarity < 0 || mining.Similarity > 1 {
+		return fmt.Errorf("log_mining: similarity must be between 0 and 1")
+	}
+	if mining.Depth != 0 && mining.Depth < 3 {
+		return fmt.Errorf("log_mining: depth must be at least 3")
+	}
+	if mining.SpikeFactor < 0 || mining.MinSpikeCount < 0 || mining.MaxTemplates < 0 {
+		return fmt.Errorf("log_mining: spike_factor, min_spike_count and max_templates must be positive")
+	}
+	interval, err := c.Metrics.GetInterval()
+	if err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+	retention, err := c.Metrics.GetRetention()
+	if err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+	if retention < interval {
+		return fmt.Errorf("metrics: retention %s is shorter than the interval %s", retention, interval)
+	}
+	if c.Metrics.MaxSeries < 0 {
+		return fmt.Errorf("metrics: max_series must be positive")
+	}
+	if _, err := c.Sessions.GetMaxAge(); err != nil {
+		return fmt.Errorf("sessions: %w", err)
+	}
+	if c.Sessions.MaxCount < 0 {
+		return fmt.Errorf("sessions: max_count must be positive")
+	}
+	if c.Host != nil {
+		if err := c.Host.Validate(); err != nil {
+			return err
+		}
+	}
+
+	names := make(map[string]bool, len(c.LogSources))
+	for i := range c.LogSources {
+		source := &c.LogSources[i]
+		if source.Name == "" {
+			return fmt.Errorf("log source name is required")
+		}
+		if names[source.Name] {
+			return fmt.Errorf("duplicated log source name %q", source.Name)
+		}
+		names[source.Name] = true
+		if !filepath.IsAbs(source.Path) {
+			return fmt.Errorf("log source %q: path %q must be absolute", source.Name, source.Path)
+		}
+		if _, err := filepath.Match(source.Path, ""); err != nil {
+			return fmt.Errorf("log source %q: invalid path %q: %w", source.Name, source.Path, err)
+		}
+		switch source.Type {
+		case "":
+			source.Type = LogSourceFile
+		case LogSourceFile, LogSourceJournal:
+		default:
+			return fmt.Errorf("log source %q: unknown type %q", source.Name, source.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultAnomalyCooldown is the default time between two alerts of the same series and detector
+	DefaultAnomalyCooldown = 30 * time.Minute
+)
+
+// AnomalyMetric selects the series of a metric watched for anomalies
+type AnomalyMetric struct {
+	Name string `yaml:"name"`
+	// Labels select the series having these values, all the series of the metric when empty
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// MinDeviation is the smallest change worth reporting, in the unit of the metric
+	MinDeviation float64 `yaml:"min_deviation,omitempty"`
+}
+
+// AnomaliesDefinition configures the anomaly detection on the collected metrics
+type AnomaliesDefinition struct {
+	// Metrics are the watched metrics, the main metrics of the host when empty
+	Metrics []AnomalyMetric `yaml:"metrics,omitempty"`
+	// Detectors are ewma, seasonal and change_point, all of them when empty
+	Detectors []string `yaml:"detectors,omitempty"`
+	// ZScore is the number of standard deviations of an anomaly, 4 when not set
+	ZScore float64 `yaml:"z_score,omitempty"`
+	// Level is the level of the alerts, warning when empty
+	Level string `yaml:"level,omitempty"`
+	// Investigate starts an investigation of the agent on an anomaly
+	Investigate bool     `yaml:"investigate,omitempty"`
+	Tools       []string `yaml:"tools,omitempty"`
+	Cooldown    string   `yaml:"cooldown,omitempty"`
+}
+
+// DefaultAnomalyMetrics are the metrics watched when none is configured
+var DefaultAnomalyMetrics = []AnomalyMetric{
+	{Name: "cpu.usage_percent", MinDeviation: 10},
+	{Name: "cpu.iowait_percent", MinDeviation: 5},
+	{Name: "memory.used_percent", MinDeviation: 5},
+	{Name: "swap.used_percent", MinDeviation: 5},
+	{Name: "load.load1", MinDeviation: 1},
+	{Name: "disk.util_percent", MinDeviation: 20},
+	{Name: "net.rx_bytes_per_sec", MinDeviation: 1 << 20},
+	{Name: "net.tx_bytes_per_sec", MinDeviation: 1 << 20},
+	{Name: "fd.used_percent", MinDeviation: 5},
+}
+
+// Validate checks the metrics, the level and the cooldown, the detectors are
+// checked by the watcher.
+func (d *AnomaliesDefinition) Validate() error {
+	for _, metric := range d.Metrics {
+		if metric.Name == "" {
+			return fmt.Errorf("anomalies: metric name is required")
+		}
+		if metric.MinDeviation < 0 {
+			return fmt.Errorf("anomalies: metric %q: min_deviation must be positive", metric.Name)
+		}
+	}
+	if d.ZScore < 0 {
+		return fmt.Errorf("anomalies: z_score must be positive")
+	}
+	if d.Level != "" && !isAlertLevel(d.Level) {
+		return fmt.Errorf("anomalies: invalid level %q", d.Level)
+	}
+	if _, err := d.GetCooldown(); err != nil {
+		return fmt.Errorf("anomalies: %w", err)
+	}
+	return nil
+}
+
+// GetMetrics returns the watched metrics, the default metrics when not set
+func (d *AnomaliesDefinition) GetMetrics() []AnomalyMetric {
+	if len(d.Metrics) == 0 {
+		return DefaultAnomalyMetrics
+	}
+	return d.Metrics
+}
+
+// GetCooldown returns the cooldown, the default cooldown when not set
+func (d *AnomaliesDefinition) GetCooldown() (time.Duration, error) {
+	if d.Cooldown == "" {
+		return DefaultAnomalyCooldown, nil
+	}
+	cooldown, err := time.ParseDuration(d.Cooldown)
+	if err != nil || cooldown < 0 {
+		return 0, fmt.Errorf("invalid cooldown %q", d.Cooldown)
+	}
+	return cooldown, nil
+}
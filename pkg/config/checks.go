@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+)
+
+const (
+	ProbeHTTP = "http"
+	ProbeTCP  = "tcp"
+	ProbeTLS  = "tls"
+	ProbeDNS  = "dns"
+
+	// minimal interval allowed between two runs of the same check, checks
+	// do not call the LLM so they could run much more often than monitors
+	minCheckSchedule = 10 * time.Second
+	// DefaultProbeTimeout is the timeout of a probe without timeout
+	DefaultProbeTimeout = 10 * time.Second
+
+	// default days before the expiry of a certificate to raise the warning and the critical alerts
+	DefaultCertWarningDays  = 30
+	DefaultCertCriticalDays = 7
+)
+
+// ProbeSpec describes a synthetic probe, the fields used depend on the type
+type ProbeSpec struct {
+	// Type is one of http, tcp, tls or dns
+	Type string `yaml:"type" json:"type"`
+	// Target is the URL of http probes, host:port of tcp and tls probes and the name resolved by dns probes
+	Target  string `yaml:"target" json:"target"`
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// ExpectStatus are the accepted status codes of http probes, any 2xx or 3xx when empty
+	ExpectStatus []int             `yaml:"expect_status,omitempty" json:"expect_status,omitempty"`
+	BodyContains string            `yaml:"body_contains,omitempty" json:"body_contains,omitempty"`
+	BodyMatches  string            `yaml:"body_matches,omitempty" json:"body_matches,omitempty"`
+	Headers      map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// ServerName overrides the SNI and the verified name of tls and https probes
+	ServerName string `yaml:"server_name,omitempty" json:"server_name,omitempty"`
+	// MinValidDays fails tls and https probes whose certificate expires sooner
+	MinValidDays int `yaml:"min_valid_days,omitempty" json:"min_valid_days,omitempty"`
+	// InsecureSkipVerify reports the certificate without failing on an invalid chain
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty" json:"insecure_skip_verify,omitempty"`
+
+	// Resolver is the host:port of the DNS server of dns probes, the system resolver when empty
+	Resolver string `yaml:"resolver,omitempty" json:"resolver,omitempty"`
+	// RecordType is A, AAAA, CNAME, MX, TXT or NS, A when empty
+	RecordType string `yaml:"record_type,omitempty" json:"record_type,omitempty"`
+	// Expect are values which must all be in the answer of dns probes
+	Expect []string `yaml:"expect,omitempty" json:"expect,omitempty"`
+}
+
+// CertificateScan describes the certificate files and the TLS endpoints
+// whose certificates are checked for expiry.
+type CertificateScan struct {
+	// Paths are directories scanned recursively or certificate files, PEM, DER, PKCS#12 and JKS files are read
+	Paths []string `yaml:"paths,omitempty"`
+	// Endpoints are host:port of TLS servers
+	Endpoints []string `yaml:"endpoints,omitempty"`
+	// WarningDays and CriticalDays are the days before the expiry to raise the alerts
+	WarningDays  int `yaml:"warning_days,omitempty"`
+	CriticalDays int `yaml:"critical_days,omitempty"`
+	// IncludeTrustStore checks the certificates of the system trust store as well, like the roots of /etc/ssl/certs
+	IncludeTrustStore bool `yaml:"include_trust_store,omitempty"`
+	// Passwords are tried to open the PKCS#12 files, the empty password and changeit are always tried
+	Passwords []string `yaml:"passwords,omitempty"`
+	Timeout   string   `yaml:"timeout,omitempty"`
+}
+
+// CheckDefinition describes a scheduled check run without involving the LLM,
+// either a probe whose failure raises an alert of the given level or a scan
+// of certificates raising a warning, then an alert of the given level, as
+// their expiry comes closer.
+type CheckDefinition struct {
+	Name         string           `yaml:"name"`
+	Schedule     string           `yaml:"schedule"`
+	Level        string           `yaml:"level,omitempty"`
+	Probe        *ProbeSpec       `yaml:"probe,omitempty"`
+	Certificates *CertificateScan `yaml:"certificates,omitempty"`
+}
+
+// Validate checks the probe has the fields required by its type
+func (p *ProbeSpec) Validate() error {
+	if p.Target == "" {
+		return fmt.Errorf("probe target is required")
+	}
+	if _, err := p.GetTimeout(); err != nil {
+		return err
+	}
+	if p.BodyMatches != "" {
+		if _, err := regexp.Compile(p.BodyMatches); err != nil {
+			return fmt.Errorf("invalid body_matches %q: %w", p.BodyMatches, err)
+		}
+	}
+	if p.MinValidDays < 0 {
+		return fmt.Errorf("min_valid_days must be positive")
+	}
+
+	switch p.Type {
+	case ProbeHTTP:
+		target, err := url.Parse(p.Target)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return fmt.Errorf("http probe target %q is not a http or https URL", p.Target)
+		}
+	case ProbeTCP, ProbeTLS:
+		if _, _, err := net.SplitHostPort(p.Target); err != nil {
+			return fmt.Errorf("%s probe target %q is not host:port: %w", p.Type, p.Target, err)
+		}
+	case ProbeDNS:
+		if p.Resolver != "" {
+			if _, _, err := net.SplitHostPort(p.Resolver); err != nil {
+				return fmt.Errorf("dns probe resolver %q is not host:port: %w", p.Resolver, err)
+			}
+		}
+		switch strings.ToUpper(p.RecordType) {
+		case "", "A", "AAAA", "CNAME", "MX", "TXT", "NS":
+		default:
+			return fmt.Errorf("unsupported dns record type %q", p.RecordType)
+		}
+	default:
+		return fmt.Errorf("unknown probe type %q, use http, tcp, tls or dns", p.Type)
+	}
+	return nil
+}
+
+// GetTimeout returns the timeout of the probe, the default timeout when not set
+func (p *ProbeSpec) GetTimeout() (time.Duration, error) {
+	if p.Timeout == "" {
+		return DefaultProbeTimeout, nil
+	}
+	timeout, err := time.ParseDuration(p.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid probe timeout %q", p.Timeout)
+	}
+	return timeout, nil
+}
+
+// Validate checks the scan has something to scan and consistent thresholds
+func (s *CertificateScan) Validate() error {
+	if len(s.Paths) == 0 && len(s.Endpoints) == 0 {
+		return fmt.Errorf("certificates scan needs paths or endpoints")
+	}
+	for _, endpoint := range s.Endpoints {
+		if _, _, err := net.SplitHostPort(endpoint); err != nil {
+			return fmt.Errorf("certificates endpoint %q is not host:port: %w", endpoint, err)
+		}
+	}
+	if s.WarningDays < 0 || s.CriticalDays < 0 {
+		return fmt.Errorf("warning_days and critical_days must be positive")
+	}
+	if warning, critical := s.GetThresholds(); critical > warning {
+		return fmt.Errorf("critical_days %d is greater than warning_days %d", critical, warning)
+	}
+	if _, err := s.GetTimeout(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetThresholds returns the warning and critical days, the defaults when not set
+func (s *CertificateScan) GetThresholds() (int, int) {
+	warning, critical := s.WarningDays, s.CriticalDays
+	if warning == 0 {
+		warning = DefaultCertWarningDays
+	}
+	if critical == 0 {
+		critical = DefaultCertCriticalDays
+	}
+	return warning, critical
+}
+
+// GetTimeout returns the timeout of the connections to the endpoints
+func (s *CertificateScan) GetTimeout() (time.Duration, error) {
+	if s.Timeout == "" {
+		return DefaultProbeTimeout, nil
+	}
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid certificates timeout %q", s.Timeout)
+	}
+	return timeout, nil
+}
+
+// Validate checks if the check definition has all required fields
+func (d *CheckDefinition) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("check name is required")
+	}
+	if _, err := d.GetSchedule(); err != nil {
+		return fmt.Errorf("check %q: %w", d.Name, err)
+	}
+	if d.Level != "" && !isAlertLevel(d.Level) {
+		return fmt.Errorf("check %q: invalid level %q", d.Name, d.Level)
+	}
+	switch {
+	case d.Probe != nil && d.Certificates != nil:
+		return fmt.Errorf("check %q: probe and certificates are exclusive", d.Name)
+	case d.Probe != nil:
+		if err := d.Probe.Validate(); err != nil {
+			return fmt.Errorf("check %q: %w", d.Name, err)
+		}
+	case d.Certificates != nil:
+		if err := d.Certificates.Validate(); err != nil {
+			return fmt.Errorf("check %q: %w", d.Name, err)
+		}
+	default:
+		return fmt.Errorf("check %q: probe or certificates is required", d.Name)
+	}
+	return nil
+}
+
+// GetSchedule returns the interval between two runs of the check
+func (d *CheckDefinition) GetSchedule() (time.Duration, error) {
+	schedule, err := time.ParseDuration(d.Schedule)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule %q: %w", d.Schedule, err)
+	}
+	if schedule < minCheckSchedule {
+		return 0, fmt.Errorf("schedule %q is shorter than %s", d.Schedule, minCheckSchedule)
+	}
+	return schedule, nil
+}
+
+// GetLevel returns the level of the alerts of the check, error when not set
+func (d *CheckDefinition) GetLevel() string {
+	if d.Level == "" {
+		return alerts.Error
+	}
+	return d.Level
+}
@@ -6,6 +6,11 @@ type LLMConfiger interface {
 	GetAPIKey() string
 	GetBaseURL() string
 	GetTemperature() float64
+	// GetUntrusted returns true if the model must only be given read-only tools
+	GetUntrusted() bool
+	// GetScratchPadTokens returns the token budget of the scratchpad of the
+	// agent, 0 for the default budget
+	GetScratchPadTokens() int
 }
 
 type LLMConfig struct {
@@ -14,4 +19,36 @@ type LLMConfig struct {
 	Model       string  `yaml:"model"`
 	Temperature float64 `yaml:"temperature"`
 	BaseURL     string  `yaml:"base_url"`
+	Untrusted   bool    `yaml:"untrusted"`
+	// ScratchPadTokens is the token budget of the scratchpad, it should be a
+	// fraction of the context size of the model
+	ScratchPadTokens int `yaml:"scratchpad_tokens,omitempty"`
+}
+
+func (c LLMConfig) GetLLMType() string {
+	return c.Type
+}
+
+func (c LLMConfig) GetModel() string {
+	return c.Model
+}
+
+func (c LLMConfig) GetAPIKey() string {
+	return c.APIKey
+}
+
+func (c LLMConfig) GetBaseURL() string {
+	return c.BaseURL
+}
+
+func (c LLMConfig) GetTemperature() float64 {
+	return c.Temperature
+}
+
+func (c LLMConfig) GetUntrusted() bool {
+	return c.Untrusted
+}
+
+func (c LLMConfig) GetScratchPadTokens() int {
+	return c.ScratchPadTokens
 }
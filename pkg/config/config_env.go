@@ -33,6 +33,22 @@ func (c *LLMBackendEnvConfig) loadLLMConfig() error {
 		c.config.Temperature = tempFloat
 	}
 
+	if untrusted := os.Getenv("LLM_UNTRUSTED"); untrusted != "" {
+		untrustedBool, err := strconv.ParseBool(untrusted)
+		if err != nil {
+			return fmt.Errorf("invalid untrusted value: %w", err)
+		}
+		c.config.Untrusted = untrustedBool
+	}
+
+	if tokens := os.Getenv("LLM_SCRATCHPAD_TOKENS"); tokens != "" {
+		tokensInt, err := strconv.Atoi(tokens)
+		if err != nil || tokensInt < 0 {
+			return fmt.Errorf("invalid scratchpad tokens value %q", tokens)
+		}
+		c.config.ScratchPadTokens = tokensInt
+	}
+
 	if err := c.validate(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -78,3 +94,11 @@ func (c *LLMBackendEnvConfig) GetBaseURL() string {
 func (c *LLMBackendEnvConfig) GetTemperature() float64 {
 	return c.config.Temperature
 }
+
+func (c *LLMBackendEnvConfig) GetUntrusted() bool {
+	return c.config.Untrusted
+}
+
+func (c *LLMBackendEnvConfig) GetScratchPadTokens() int {
+	return c.config.ScratchPadTokens
+}
@@ -7,10 +7,19 @@ import (
 	yaml "gopkg.in/yaml.v3"
 )
 
+// llmConfigFile is the content of the LLM config file, the top level LLM is the default profile
+type llmConfigFile struct {
+	LLMConfig `yaml:",inline"`
+	Profiles  map[string]LLMConfig `yaml:"profiles"`
+	Routing   RoutingConfig        `yaml:"routing"`
+}
+
 // LLMBackendConfig represents the configuration for the LLM backend
 type LLMBackendYamlConfig struct {
-	config LLMConfig
-	path   string
+	config   LLMConfig
+	profiles map[string]LLMConfig
+	routing  RoutingConfig
+	path     string
 }
 
 func NewLLMBackendYamlConfig(configPath string) (*LLMBackendYamlConfig, error) {
@@ -30,12 +39,14 @@ func (c *LLMBackendYamlConfig) loadLLMConfig(configPath string) error {
 		return fmt.Errorf("error reading config file: %w", err)
 	}
 
-	cfg := LLMConfig{}
+	cfg := llmConfigFile{}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return fmt.Errorf("error parsing config file: %w", err)
 	}
 
-	c.config = cfg
+	c.config = cfg.LLMConfig
+	c.profiles = cfg.Profiles
+	c.routing = cfg.Routing
 	if err := c.validate(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
 	}
@@ -54,7 +65,18 @@ func (c *LLMBackendYamlConfig) validate() error {
 	if c.config.Model == "" {
 		return fmt.Errorf("model name is required")
 	}
-	return nil
+	if c.config.ScratchPadTokens < 0 {
+		return fmt.Errorf("invalid scratchpad_tokens %d", c.config.ScratchPadTokens)
+	}
+	for name, profile := range c.profiles {
+		if name == DefaultProfile {
+			return fmt.Errorf("profile name %q is reserved", DefaultProfile)
+		}
+		if err := validateProfile(name, profile); err != nil {
+			return err
+		}
+	}
+	return c.routing.validate(c.profiles)
 }
 
 func (c *LLMBackendYamlConfig) GetLLMType() string {
@@ -76,3 +98,47 @@ func (c *LLMBackendYamlConfig) GetBaseURL() string {
 func (c *LLMBackendYamlConfig) GetTemperature() float64 {
 	return c.config.Temperature
 }
+
+// GetProfile returns the LLM profile with the given name, the default profile
+// is the LLM configured at the top level of the config file.
+func (c *LLMBackendYamlConfig) GetProfile(name string) (LLMConfiger, error) {
+	if name == "" || name == DefaultProfile {
+		return c.config, nil
+	}
+	profile, ok := c.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM profile %q", name)
+	}
+	return profile, nil
+}
+
+// GetRouting resolves the routing policy of the config file. Without routing
+// section the default profile is used for every run and nothing is escalated.
+func (c *LLMBackendYamlConfig) GetRouting() (*LLMRouting, error) {
+	triage, err := c.GetProfile(c.routing.Triage)
+	if err != nil {
+		return nil, err
+	}
+	routing := &LLMRouting{Triage: triage}
+	if c.routing.Escalation == "" {
+		return routing, nil
+	}
+
+	routing.Escalation, err = c.GetProfile(c.routing.Escalation)
+	if err != nil {
+		return nil, err
+	}
+	routing.EscalateOn = c.routing.EscalateOn
+	if len(routing.EscalateOn) == 0 {
+		routing.EscalateOn = []string{EscalateOnFinding, EscalateOnParseError, EscalateOnIterationLimit}
+	}
+	return routing, nil
+}
+
+func (c *LLMBackendYamlConfig) GetUntrusted() bool {
+	return c.config.Untrusted
+}
+
+func (c *LLMBackendYamlConfig) GetScratchPadTokens() int {
+	return c.config.ScratchPadTokens
+}
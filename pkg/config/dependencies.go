@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultDependenciesInterval is the default time between two discoveries of the dependencies
+const DefaultDependenciesInterval = 5 * time.Minute
+
+// DependenciesDefinition configures the periodic discovery of the dependency
+// map of the host, its changes are recorded and alerted
+type DependenciesDefinition struct {
+	// Interval is the time between two discoveries, 5m when empty
+	Interval string `yaml:"interval,omitempty"`
+	// ForgetAfter is the time after which a dependency not seen anymore is forgotten, 168h when empty
+	ForgetAfter string `yaml:"forget_after,omitempty"`
+	// Level is the level of the alerts, warning when empty
+	Level string `yaml:"level,omitempty"`
+	// AlertAdded alerts the new dependencies as well, only the missing and
+	// stalled ones are alerted otherwise
+	AlertAdded bool `yaml:"alert_added,omitempty"`
+	// Investigate starts an investigation of the agent on the stalled and missing dependencies
+	Investigate bool     `yaml:"investigate,omitempty"`
+	Tools       []string `yaml:"tools,omitempty"`
+}
+
+// Validate checks the durations and the level
+func (d *DependenciesDefinition) Validate() error {
+	for _, get := range []func() (time.Duration, error){d.GetInterval, d.GetForgetAfter} {
+		if _, err := get(); err != nil {
+			return fmt.Errorf("dependencies: %w", err)
+		}
+	}
+	if d.Level != "" && !isAlertLevel(d.Level) {
+		return fmt.Errorf("dependencies: invalid level %q", d.Level)
+	}
+	return nil
+}
+
+// GetInterval returns the time between two discoveries, the default interval when not set
+func (d *DependenciesDefinition) GetInterval() (time.Duration, error) {
+	return parsePositiveDuration("interval", d.Interval, DefaultDependenciesInterval)
+}
+
+// GetForgetAfter returns the time a dependency is kept, 0 for the default of the tracker
+func (d *DependenciesDefinition) GetForgetAfter() (time.Duration, error) {
+	return parsePositiveDuration("forget_after", d.ForgetAfter, 0)
+}
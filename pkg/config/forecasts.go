@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultForecastHorizon is the default time within which a projected exhaustion is alerted
+	DefaultForecastHorizon = 72 * time.Hour
+	// DefaultForecastInterval is the default time between two forecasts
+	DefaultForecastInterval = 10 * time.Minute
+)
+
+// ForecastsDefinition configures the forecasts of the exhaustion of the
+// filesystems, the inodes, the swap and the memory of the leaking processes
+type ForecastsDefinition struct {
+	// Horizon alerts the exhaustions projected within this time, 72h when empty
+	Horizon string `yaml:"horizon,omitempty"`
+	// History is the history the trends are fitted on, 24h when empty, no
+	// more than the retention of the metrics
+	History string `yaml:"history,omitempty"`
+	// MinHistory is the shortest history of a forecast, 1h when empty
+	MinHistory string `yaml:"min_history,omitempty"`
+	// Interval is the time between two forecasts, 10m when empty
+	Interval string `yaml:"interval,omitempty"`
+	// Kinds are filesystem, inodes, swap and process, all of them when empty
+	Kinds []string `yaml:"kinds,omitempty"`
+	// Level is the level of the alerts, warning when empty
+	Level string `yaml:"level,omitempty"`
+	// Investigate starts an investigation of the agent on a forecast exhaustion
+	Investigate bool     `yaml:"investigate,omitempty"`
+	Tools       []string `yaml:"tools,omitempty"`
+}
+
+// Validate checks the durations and the level, the kinds are checked by the
+// watcher.
+func (d *ForecastsDefinition) Validate() error {
+	for _, get := range []func() (time.Duration, error){d.GetHorizon, d.GetHistory, d.GetMinHistory, d.GetInterval} {
+		if _, err := get(); err != nil {
+			return fmt.Errorf("forecasts: %w", err)
+		}
+	}
+	if d.Level != "" && !isAlertLevel(d.Level) {
+		return fmt.Errorf("forecasts: invalid level %q", d.Level)
+	}
+	return nil
+}
+
+// GetHorizon returns the horizon, the default horizon when not set
+func (d *ForecastsDefinition) GetHorizon() (time.Duration, error) {
+	return parsePositiveDuration("horizon", d.Horizon, DefaultForecastHorizon)
+}
+
+// GetHistory returns the fitted history, 0 for the default of the forecasts
+func (d *ForecastsDefinition) GetHistory() (time.Duration, error) {
+	return parsePositiveDuration("history", d.History, 0)
+}
+
+// GetMinHistory returns the shortest history, 0 for the default of the forecasts
+func (d *ForecastsDefinition) GetMinHistory() (time.Duration, error) {
+	return parsePositiveDuration("min_history", d.MinHistory, 0)
+}
+
+// GetInterval returns the time between two forecasts, the default interval when not set
+func (d *ForecastsDefinition) GetInterval() (time.Duration, error) {
+	return parsePositiveDuration("interval", d.Interval, DefaultForecastInterval)
+}
+
+func parsePositiveDuration(name, value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import "fmt"
+
+// HostProfile describes what the host is supposed to be doing, it is given
+// to the agent so a stopped service or a busy cpu is judged by the role of
+// the host.
+type HostProfile struct {
+	// Role is what the host is, like db, web or batch
+	Role        string `yaml:"role"`
+	Description string `yaml:"description,omitempty"`
+	// Environment is like production or staging
+	Environment string   `yaml:"environment,omitempty"`
+	Owners      []string `yaml:"owners,omitempty"`
+	// Services are the services expected to run on the host
+	Services []ServiceProfile `yaml:"services,omitempty"`
+	// NormalLoad are the usual ranges of the collected metrics
+	NormalLoad []LoadRange `yaml:"normal_load,omitempty"`
+	// Quirks are the known oddities of the host which are not problems
+	Quirks []string `yaml:"quirks,omitempty"`
+}
+
+// ServiceProfile is a service expected to run on the host
+type ServiceProfile struct {
+	Name string `yaml:"name"`
+	// Unit is the systemd unit of the service, like postgresql.service
+	Unit string `yaml:"unit,omitempty"`
+	// Ports are the ports the service listens on
+	Ports []int `yaml:"ports,omitempty"`
+	// Critical services down are the most severe findings of the host
+	Critical bool   `yaml:"critical,omitempty"`
+	Notes    string `yaml:"notes,omitempty"`
+}
+
+// LoadRange is the usual range of a collected metric, like cpu.usage_percent
+type LoadRange struct {
+	Metric string   `yaml:"metric"`
+	Min    *float64 `yaml:"min,omitempty"`
+	Max    *float64 `yaml:"max,omitempty"`
+	Notes  string   `yaml:"notes,omitempty"`
+}
+
+// Validate checks the services and the load ranges of the profile
+func (p *HostProfile) Validate() error {
+	names := make(map[string]bool, len(p.Services))
+	for _, service := range p.Services {
+		if service.Name == "" {
+			return fmt.Errorf("host: service name is required")
+		}
+		if names[service.Name] {
+			return fmt.Errorf("host: duplicated service name %q", service.Name)
+		}
+		names[service.Name] = true
+		for _, port := range service.Ports {
+			if port <= 0 || port > 65535 {
+				return fmt.Errorf("host: service %q: invalid port %d", service.Name, port)
+			}
+		}
+	}
+	for _, load := range p.NormalLoad {
+		if load.Metric == "" {
+			return fmt.Errorf("host: normal_load metric is required")
+		}
+		if load.Min == nil && load.Max == nil {
+			return fmt.Errorf("host: normal_load %q: min or max is required", load.Metric)
+		}
+		if load.Min != nil && load.Max != nil && *load.Min > *load.Max {
+			return fmt.Errorf("host: normal_load %q: min %g is above max %g", load.Metric, *load.Min, *load.Max)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultKernelEventsCooldown is the default time between two alerts of the same kernel event
+	DefaultKernelEventsCooldown = 10 * time.Minute
+)
+
+// KernelEventsDefinition configures the watcher of the kernel messages, the
+// events recognized raise alerts at once and could start an investigation.
+type KernelEventsDefinition struct {
+	// Source is /dev/kmsg or a file written with the kernel messages like /var/log/kern.log, /dev/kmsg when empty
+	Source string `yaml:"source,omitempty"`
+	// Levels overrides the alert levels of the kinds of events, like segfault: info
+	Levels map[string]string `yaml:"levels,omitempty"`
+	// Ignore are the kinds of events which are not reported
+	Ignore []string `yaml:"ignore,omitempty"`
+	// Investigate are the kinds of events starting an investigation of the agent
+	Investigate []string `yaml:"investigate,omitempty"`
+	// Tools are the tools of the investigations, the default tools when empty
+	Tools []string `yaml:"tools,omitempty"`
+	// Cooldown is the minimal time between two alerts of the same event, like
+	// the segfaults of a command or the errors of a device
+	Cooldown string `yaml:"cooldown,omitempty"`
+}
+
+// Validate checks the levels and the cooldown, the kinds of events are
+// checked by the watcher which knows them.
+func (d *KernelEventsDefinition) Validate() error {
+	for kind, level := range d.Levels {
+		if !isAlertLevel(level) {
+			return fmt.Errorf("kernel_events: invalid level %q of %s", level, kind)
+		}
+	}
+	if _, err := d.GetCooldown(); err != nil {
+		return fmt.Errorf("kernel_events: %w", err)
+	}
+	return nil
+}
+
+// GetCooldown returns the cooldown, the default cooldown when not set
+func (d *KernelEventsDefinition) GetCooldown() (time.Duration, error) {
+	if d.Cooldown == "" {
+		return DefaultKernelEventsCooldown, nil
+	}
+	cooldown, err := time.ParseDuration(d.Cooldown)
+	if err != nil || cooldown < 0 {
+		return 0, fmt.Errorf("invalid cooldown %q", d.Cooldown)
+	}
+	return cooldown, nil
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	// minimal interval allowed between two runs of the same monitor
+	minMonitorSchedule = time.Minute
+	// DefaultMonitorName is the name of the monitor run when none is configured
+	DefaultMonitorName = "monitor"
+)
+
+// MonitorsConfig is the content of the monitors config file
+type MonitorsConfig struct {
+	Monitors []MonitorDefinition `yaml:"monitors"`
+	Checks   []CheckDefinition   `yaml:"checks,omitempty"`
+	// Rules are the thresholds on the collected metrics evaluated without the LLM
+	Rules []RuleDefinition `yaml:"rules,omitempty"`
+	// KernelEvents enables the watcher of the kernel messages when set
+	KernelEvents *KernelEventsDefinition `yaml:"kernel_events,omitempty"`
+	// Pressure enables the watcher of the pressure stall triggers when set
+	Pressure *PressureDefinition `yaml:"pressure,omitempty"`
+	// Anomalies enables the anomaly detection on the collected metrics when set
+	Anomalies *AnomaliesDefinition `yaml:"anomalies,omitempty"`
+	// Forecasts enables the capacity forecasts on the collected metrics when set
+	Forecasts *ForecastsDefinition `yaml:"forecasts,omitempty"`
+	// Dependencies enables the periodic discovery of the dependency map when set
+	Dependencies *DependenciesDefinition `yaml:"dependencies,omitempty"`
+}
+
+// MonitorDefinition describes one scheduled monitor run by the agent
+type MonitorDefinition struct {
+	Name       string          `yaml:"name"`
+	Prompt     string          `yaml:"prompt"`
+	Schedule   string          `yaml:"schedule"`
+	Tools      []string        `yaml:"tools,omitempty"`
+	Severity   []SeverityRule  `yaml:"severity,omitempty"`
+	Thresholds []ThresholdRule `yaml:"thresholds,omitempty"`
+}
+
+// SeverityRule maps a final answer of the agent matching Match to an alert level
+type SeverityRule struct {
+	Match string `yaml:"match"`
+	Level string `yaml:"level"`
+}
+
+// ThresholdRule is a threshold the agent should check during the monitor run
+type ThresholdRule struct {
+	Metric   string  `yaml:"metric"`
+	Operator string  `yaml:"operator"`
+	Value    float64 `yaml:"value"`
+	Level    string  `yaml:"level"`
+}
+
+// NewMonitorsYamlConfig loads the monitors config, a missing file means no monitors
+func NewMonitorsYamlConfig(configPath string) (*MonitorsConfig, error) {
+	cfg := &MonitorsConfig{}
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading monitors config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing monitors config file: %w", err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid monitors configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+func (c *MonitorsConfig) validate() error {
+	names := make(map[string]bool, len(c.Monitors))
+	// The default monitor is added when none is configured, its name is taken as well.
+	if len(c.Monitors) == 0 {
+		names[DefaultMonitorName] = true
+	}
+	for _, def := range c.Monitors {
+		if err := def.Validate(); err != nil {
+			return err
+		}
+		if names[def.Name] {
+			return fmt.Errorf("duplicated monitor name %q", def.Name)
+		}
+		names[def.Name] = true
+	}
+	// Checks and monitors share the run history, their names must not collide.
+	for _, def := range c.Checks {
+		if err := def.Validate(); err != nil {
+			return err
+		}
+		if names[def.Name] {
+			return fmt.Errorf("duplicated monitor or check name %q", def.Name)
+		}
+		names[def.Name] = true
+	}
+	for _, def := range c.Rules {
+		if err := def.Validate(); err != nil {
+			return err
+		}
+		if names[def.Name] {
+			return fmt.Errorf("duplicated monitor, check or rule name %q", def.Name)
+		}
+		names[def.Name] = true
+	}
+	if c.KernelEvents != nil {
+		if err := c.KernelEvents.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Pressure != nil {
+		if err := c.Pressure.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Anomalies != nil {
+		if err := c.Anomalies.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Forecasts != nil {
+		if err := c.Forecasts.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Dependencies != nil {
+		if err := c.Dependencies.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMonitor returns the monitor definition with the given name
+func (c *MonitorsConfig) GetMonitor(name string) (MonitorDefinition, bool) {
+	for _, def := range c.Monitors {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return MonitorDefinition{}, false
+}
+
+// Validate checks if the monitor definition has all required fields
+func (d *MonitorDefinition) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("monitor name is required")
+	}
+	if d.Prompt == "" {
+		return fmt.Errorf("monitor %q: prompt is required", d.Name)
+	}
+	if _, err := d.GetSchedule(); err != nil {
+		return fmt.Errorf("monitor %q: %w", d.Name, err)
+	}
+	for _, rule := range d.Severity {
+		if _, err := regexp.Compile(rule.Match); err != nil {
+			return fmt.Errorf("monitor %q: invalid severity match %q: %w", d.Name, rule.Match, err)
+		}
+		if !isAlertLevel(rule.Level) {
+			return fmt.Errorf("monitor %q: invalid severity level %q", d.Name, rule.Level)
+		}
+	}
+	for _, rule := range d.Thresholds {
+		if rule.Metric == "" {
+			return fmt.Errorf("monitor %q: threshold metric is required", d.Name)
+		}
+		switch rule.Operator {
+		case ">", ">=", "<", "<=", "==", "!=":
+		default:
+			return fmt.Errorf("monitor %q: invalid threshold operator %q", d.Name, rule.Operator)
+		}
+		if !isAlertLevel(rule.Level) {
+			return fmt.Errorf("monitor %q: invalid threshold level %q", d.Name, rule.Level)
+		}
+	}
+	return nil
+}
+
+// GetSchedule returns the interval between two runs of the monitor
+func (d *MonitorDefinition) GetSchedule() (time.Duration, error) {
+	schedule, err := time.ParseDuration(d.Schedule)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedule %q: %w", d.Schedule, err)
+	}
+	if schedule < minMonitorSchedule {
+		return 0, fmt.Errorf("schedule %q is shorter than %s", d.Schedule, minMonitorSchedule)
+	}
+	return schedule, nil
+}
+
+// AppendMonitor validates the definition and appends it to the monitors config file,
+// comments and existing entries of the file are kept as they are.
+func AppendMonitor(configPath string, def MonitorDefinition) error {
+	cfg, err := NewMonitorsYamlConfig(configPath)
+	if err != nil {
+		return err
+	}
+	cfg.Monitors = append(cfg.Monitors, def)
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("invalid monitor definition: %w", err)
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading monitors config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("error parsing monitors config file: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	var entry yaml.Node
+	if err := entry.Encode(def); err != nil {
+		return fmt.Errorf("error encoding monitor definition: %w", err)
+	}
+	if err := appendToSequence(doc.Content[0], "monitors", &entry); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("error encoding monitors config file: %w", err)
+	}
+	return os.WriteFile(configPath, out.Bytes(), 0644)
+}
+
+// appendToSequence appends the node to the sequence stored under key of the mapping,
+// the sequence is created if the key does not exist yet.
+func appendToSequence(mapping *yaml.Node, key string, node *yaml.Node) error {
+	if mapping.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file root is not a mapping")
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		seq := mapping.Content[i+1]
+		if seq.Kind == yaml.ScalarNode && seq.Tag == "!!null" {
+			seq.Kind, seq.Tag, seq.Value = yaml.SequenceNode, "!!seq", ""
+		}
+		if seq.Kind != yaml.SequenceNode {
+			return fmt.Errorf("%s in config file is not a list", key)
+		}
+		seq.Content = append(seq.Content, node)
+		return nil
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{node}},
+	)
+	return nil
+}
+
+func isAlertLevel(level string) bool {
+	switch level {
+	case alerts.Info, alerts.Warning, alerts.Error, alerts.Fatal:
+		return true
+	}
+	return false
+}
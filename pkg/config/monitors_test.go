@@ -0,0 +1,217 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendMonitor(t *testing.T) {
+	def := MonitorDefinition{
+		Name:     "nginx-5xx",
+		Prompt:   "Check the rate of the 5xx responses of nginx.",
+		Schedule: "10m",
+		Tools:    []string{"LogSearch"},
+		Severity: []SeverityRule{{Match: "(?i)spike", Level: "error"}},
+	}
+	tests := []struct {
+		name    string
+		content string
+		// monitors are the names of the monitors read back
+		monitors []string
+		// kept are the lines of the file which must survive the append
+		kept []string
+		err  string
+	}{
+		{
+			name:     "missing file",
+			monitors: []string{"nginx-5xx"},
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			monitors: []string{"nginx-5xx"},
+		},
+		{
+			name: "existing monitors with comments",
+			content: "# monitors of the web servers\n" +
+				"monitors:\n" +
+				"  # the disks fill up with the logs\n" +
+				"  - name: disk\n" +
+				"    prompt: Check the disks.\n" +
+				"    schedule: 1h # hourly is enough\n",
+			monitors: []string{"disk", "nginx-5xx"},
+			kept:     []string{"# monitors of the web servers", "# the disks fill up with the logs", "# hourly is enough"},
+		},
+		{
+			name: "null monitors",
+			content: "# no monitor yet\n" +
+				"monitors:\n" +
+				"rules:\n" +
+				"  - name: disk-full\n" +
+				"    expr: disk.used_percent > 90\n",
+			monitors: []string{"nginx-5xx"},
+			kept:     []string{"# no monitor yet", "expr: disk.used_percent > 90"},
+		},
+		{
+			name: "missing monitors",
+			content: "# only rules\n" +
+				"rules:\n" +
+				"  - name: disk-full\n" +
+				"    expr: disk.used_percent > 90\n",
+			monitors: []string{"nginx-5xx"},
+			kept:     []string{"# only rules", "expr: disk.used_percent > 90"},
+		},
+		{
+			name: "duplicated name",
+			content: "monitors:\n" +
+				"  - name: nginx-5xx\n" +
+				"    prompt: Check nginx.\n" +
+				"    schedule: 1h\n",
+			err: `duplicated monitor name "nginx-5xx"`,
+		},
+		{
+			name: "name of a rule",
+			content: "rules:\n" +
+				"  - name: nginx-5xx\n" +
+				"    expr: disk.used_percent > 90\n",
+			err: `duplicated monitor, check or rule name "nginx-5xx"`,
+		},
+		{
+			name:    "root is not a mapping",
+			content: "- name: disk\n",
+			err:     "error parsing monitors config file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "monitors.yaml")
+			if tt.name != "missing file" {
+				assert.NoError(t, os.WriteFile(configPath, []byte(tt.content), 0644))
+			}
+
+			err := AppendMonitor(configPath, def)
+			data, readErr := os.ReadFile(configPath)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				// the file is left untouched
+				assert.NoError(t, readErr)
+				assert.Equal(t, tt.content, string(data))
+				return
+			}
+			assert.NoError(t, err)
+			for _, line := range tt.kept {
+				assert.Contains(t, string(data), line)
+			}
+
+			cfg, err := NewMonitorsYamlConfig(configPath)
+			assert.NoError(t, err)
+			names := make([]string, 0, len(cfg.Monitors))
+			for _, monitor := range cfg.Monitors {
+				names = append(names, monitor.Name)
+			}
+			assert.Equal(t, tt.monitors, names)
+			appended, ok := cfg.GetMonitor(def.Name)
+			if assert.True(t, ok) {
+				assert.Equal(t, def, appended)
+			}
+		})
+	}
+}
+
+func TestAppendMonitorInvalid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "monitors.yaml")
+	def := MonitorDefinition{
+		Name:     "disk",
+		Prompt:   "Check the disks.",
+		Schedule: "1h",
+		Severity: []SeverityRule{{Match: "(?i)full[", Level: "error"}},
+	}
+	assert.ErrorContains(t, AppendMonitor(configPath, def), "invalid severity match")
+
+	def.Severity = nil
+	def.Schedule = "10s"
+	assert.ErrorContains(t, AppendMonitor(configPath, def), "invalid monitor definition")
+	_, err := os.Stat(configPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMonitorsConfigDuplicateNames(t *testing.T) {
+	monitor := func(name string) string {
+		return "  - name: " + name + "\n    prompt: Check it.\n    schedule: 1h\n"
+	}
+	check := func(name string) string {
+		return "  - name: " + name + "\n    schedule: 1m\n    probe:\n      type: tcp\n      target: localhost:22\n"
+	}
+	rule := func(name string) string {
+		return "  - name: " + name + "\n    expr: disk.used_percent > 90\n"
+	}
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "distinct names",
+			content: "monitors:\n" + monitor("disk") + "checks:\n" + check("ssh") + "rules:\n" + rule("disk-full"),
+		},
+		{
+			name:    "two monitors",
+			content: "monitors:\n" + monitor("disk") + monitor("disk"),
+			err:     `duplicated monitor name "disk"`,
+		},
+		{
+			name:    "monitor and check",
+			content: "monitors:\n" + monitor("disk") + "checks:\n" + check("disk"),
+			err:     `duplicated monitor or check name "disk"`,
+		},
+		{
+			name:    "two checks",
+			content: "checks:\n" + check("ssh") + check("ssh"),
+			err:     `duplicated monitor or check name "ssh"`,
+		},
+		{
+			name:    "monitor and rule",
+			content: "monitors:\n" + monitor("disk") + "rules:\n" + rule("disk"),
+			err:     `duplicated monitor, check or rule name "disk"`,
+		},
+		{
+			name:    "check and rule",
+			content: "checks:\n" + check("ssh") + "rules:\n" + rule("ssh"),
+			err:     `duplicated monitor, check or rule name "ssh"`,
+		},
+		{
+			name:    "two rules",
+			content: "rules:\n" + rule("disk-full") + rule("disk-full"),
+			err:     `duplicated monitor, check or rule name "disk-full"`,
+		},
+		{
+			name:    "rule named like the default monitor",
+			content: "rules:\n" + rule(DefaultMonitorName),
+			err:     `duplicated monitor, check or rule name "monitor"`,
+		},
+		{
+			name:    "check named like the default monitor",
+			content: "checks:\n" + check(DefaultMonitorName),
+			err:     `duplicated monitor or check name "monitor"`,
+		},
+		{
+			name:    "monitor named like the default monitor",
+			content: "monitors:\n" + monitor(DefaultMonitorName) + "rules:\n" + rule("disk-full"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "monitors.yaml")
+			assert.NoError(t, os.WriteFile(configPath, []byte(tt.content), 0644))
+			_, err := NewMonitorsYamlConfig(configPath)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultPressureCooldown is the default time between two alerts of the same pressure trigger
+	DefaultPressureCooldown = 10 * time.Minute
+
+	// the kernel only accepts the triggers of the unprivileged users whose
+	// window is a multiple of this granularity
+	unprivilegedPressureWindow = 2 * time.Second
+)
+
+// privileged tells whether the triggers are registered as root, a variable so
+// the tests could change it
+var privileged = func() bool {
+	return os.Geteuid() == 0
+}
+
+// PressureTrigger is a threshold of the pressure stall information, the
+// kernel notifies when the tasks were stalled more than Stall within Window.
+type PressureTrigger struct {
+	Name string `yaml:"name"`
+	// Resource is cpu, memory or io
+	Resource string `yaml:"resource"`
+	// Cgroup is the path of the cgroup below the cgroup root, the whole system when empty
+	Cgroup string `yaml:"cgroup,omitempty"`
+	// Kind is some, when at least one task stalled, or full, when all the tasks stalled, some when empty
+	Kind   string `yaml:"kind,omitempty"`
+	Stall  string `yaml:"stall"`
+	Window string `yaml:"window"`
+	// Level is the level of the alerts, warning when empty
+	Level string `yaml:"level,omitempty"`
+	// Investigate starts an investigation of the agent when the trigger fires
+	Investigate bool `yaml:"investigate,omitempty"`
+}
+
+// PressureDefinition configures the pressure triggers watched by the monitor
+type PressureDefinition struct {
+	Triggers []PressureTrigger `yaml:"triggers"`
+	// Tools are the tools of the investigations, the default tools when empty
+	Tools    []string `yaml:"tools,omitempty"`
+	Cooldown string   `yaml:"cooldown,omitempty"`
+}
+
+// Validate checks the durations and the levels, the window of the triggers
+// of an unprivileged agent must be a multiple of 2s, the resources and the
+// bounds of the windows are checked by the watcher.
+func (d *PressureDefinition) Validate() error {
+	if len(d.Triggers) == 0 {
+		return fmt.Errorf("pressure: no trigger")
+	}
+	names := make(map[string]bool, len(d.Triggers))
+	for _, trigger := range d.Triggers {
+		if trigger.Name == "" {
+			return fmt.Errorf("pressure: trigger name is required")
+		}
+		if names[trigger.Name] {
+			return fmt.Errorf("pressure: duplicated trigger name %q", trigger.Name)
+		}
+		names[trigger.Name] = true
+		_, window, err := trigger.GetThreshold()
+		if err != nil {
+			return fmt.Errorf("pressure trigger %q: %w", trigger.Name, err)
+		}
+		if !privileged() && window%unprivilegedPressureWindow != 0 {
+			return fmt.Errorf("pressure trigger %q: window %s must be a multiple of %s when the agent does not run as root",
+				trigger.Name, window, unprivilegedPressureWindow)
+		}
+		if trigger.Level != "" && !isAlertLevel(trigger.Level) {
+			return fmt.Errorf("pressure trigger %q: invalid level %q", trigger.Name, trigger.Level)
+		}
+	}
+	if _, err := d.GetCooldown(); err != nil {
+		return fmt.Errorf("pressure: %w", err)
+	}
+	return nil
+}
+
+// GetThreshold returns the stall and the window of the trigger
+func (t *PressureTrigger) GetThreshold() (time.Duration, time.Duration, error) {
+	stall, err := time.ParseDuration(t.Stall)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid stall %q: %w", t.Stall, err)
+	}
+	window, err := time.ParseDuration(t.Window)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid window %q: %w", t.Window, err)
+	}
+	return stall, window, nil
+}
+
+// GetCooldown returns the cooldown, the default cooldown when not set
+func (d *PressureDefinition) GetCooldown() (time.Duration, error) {
+	if d.Cooldown == "" {
+		return DefaultPressureCooldown, nil
+	}
+	cooldown, err := time.ParseDuration(d.Cooldown)
+	if err != nil || cooldown < 0 {
+		return 0, fmt.Errorf("invalid cooldown %q", d.Cooldown)
+	}
+	return cooldown, nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPressureDefinitionValidate(t *testing.T) {
+	defer func(previous func() bool) { privileged = previous }(privileged)
+
+	tests := []struct {
+		name       string
+		trigger    PressureTrigger
+		privileged bool
+		err        string
+	}{
+		{
+			name:    "unprivileged window of 2s",
+			trigger: PressureTrigger{Name: "memory", Resource: "memory", Stall: "150ms", Window: "2s"},
+		},
+		{
+			name:    "unprivileged window of 10s",
+			trigger: PressureTrigger{Name: "io", Resource: "io", Stall: "1s", Window: "10s"},
+		},
+		{
+			name:    "unprivileged window of 1s",
+			trigger: PressureTrigger{Name: "cpu", Resource: "cpu", Stall: "100ms", Window: "1s"},
+			err:     "must be a multiple of 2s",
+		},
+		{
+			name:    "unprivileged window of 3s",
+			trigger: PressureTrigger{Name: "cpu", Resource: "cpu", Stall: "100ms", Window: "3s"},
+			err:     "must be a multiple of 2s",
+		},
+		{
+			name:       "privileged window of 1s",
+			trigger:    PressureTrigger{Name: "cpu", Resource: "cpu", Stall: "100ms", Window: "1s"},
+			privileged: true,
+		},
+		{
+			name:    "invalid window",
+			trigger: PressureTrigger{Name: "cpu", Resource: "cpu", Stall: "100ms", Window: "soon"},
+			err:     "invalid window",
+		},
+		{
+			name:    "invalid level",
+			trigger: PressureTrigger{Name: "cpu", Resource: "cpu", Stall: "100ms", Window: "2s", Level: "urgent"},
+			err:     "invalid level",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privileged = func() bool { return tt.privileged }
+			def := PressureDefinition{Triggers: []PressureTrigger{tt.trigger}}
+			err := def.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+
+	privileged = func() bool { return true }
+	def := PressureDefinition{Triggers: []PressureTrigger{
+		{Name: "cpu", Resource: "cpu", Stall: "100ms", Window: "2s"},
+		{Name: "cpu", Resource: "cpu", Kind: "full", Stall: "100ms", Window: "2s"},
+	}}
+	assert.Error(t, def.Validate(), "the trigger names are unique")
+}
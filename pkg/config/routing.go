@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultProfile is the name of the LLM configured at the top level of the config file
+	DefaultProfile = "default"
+
+	// EscalateOnFinding escalates when the triage model reports a finding
+	EscalateOnFinding = "finding"
+	// EscalateOnParseError escalates when the output of the triage model could not be parsed
+	EscalateOnParseError = "parse_error"
+	// EscalateOnIterationLimit escalates when the triage model hits the iteration limit
+	EscalateOnIterationLimit = "iteration_limit"
+)
+
+// RoutingConfig selects the LLM profiles used for routine runs and for escalations
+type RoutingConfig struct {
+	Triage     string   `yaml:"triage"`
+	Escalation string   `yaml:"escalation"`
+	EscalateOn []string `yaml:"escalate_on"`
+}
+
+// LLMRouting is the resolved routing policy of the monitors
+type LLMRouting struct {
+	// Triage is the LLM used for the routine runs
+	Triage LLMConfiger
+	// Escalation is the LLM the run is escalated to, nil disables escalation
+	Escalation LLMConfiger
+	// EscalateOn lists the reasons for which a run is escalated
+	EscalateOn []string
+}
+
+// NewLLMRouting returns a routing policy which always uses the given LLM
+func NewLLMRouting(config LLMConfiger) *LLMRouting {
+	return &LLMRouting{Triage: config}
+}
+
+// ShouldEscalate returns true if the run should be escalated for the reason
+func (r *LLMRouting) ShouldEscalate(reason string) bool {
+	if r.Escalation == nil {
+		return false
+	}
+	for _, on := range r.EscalateOn {
+		if on == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *RoutingConfig) validate(profiles map[string]LLMConfig) error {
+	for _, name := range []string{c.Triage, c.Escalation} {
+		if name == "" || name == DefaultProfile {
+			continue
+		}
+		if _, ok := profiles[name]; !ok {
+			return fmt.Errorf("routing refers to unknown profile %q", name)
+		}
+	}
+	for _, reason := range c.EscalateOn {
+		switch reason {
+		case EscalateOnFinding, EscalateOnParseError, EscalateOnIterationLimit:
+		default:
+			return fmt.Errorf("unknown escalation reason %q", reason)
+		}
+	}
+	return nil
+}
+
+func validateProfile(name string, profile LLMConfig) error {
+	if profile.Type == "" {
+		return fmt.Errorf("profile %q: LLM backend type is required", name)
+	}
+	if profile.Model == "" {
+		return fmt.Errorf("profile %q: model name is required", name)
+	}
+	if profile.APIKey == "" && profile.Type != "ollama" {
+		return fmt.Errorf("profile %q: API key is required", name)
+	}
+	if profile.ScratchPadTokens < 0 {
+		return fmt.Errorf("profile %q: invalid scratchpad_tokens %d", name, profile.ScratchPadTokens)
+	}
+	return nil
+}
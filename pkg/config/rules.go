@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+)
+
+// RuleDefinition is a threshold on the collected metrics evaluated without
+// the LLM, like disk.used_percent{mount="/"} > 90 for 10m.
+type RuleDefinition struct {
+	Name string `yaml:"name"`
+	// Expr is the metric, its label matchers, the comparison and how long it must hold
+	Expr string `yaml:"expr"`
+	// Clear is the value the metric must cross back for the rule to resolve, the threshold when not set
+	Clear *float64 `yaml:"clear,omitempty"`
+	// Level is the level of the alerts, warning when empty
+	Level       string `yaml:"level,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	// Investigate starts an investigation of the agent when the rule fires
+	Investigate bool `yaml:"investigate,omitempty"`
+	// Tools are the tools of the investigations, the default tools when empty
+	Tools []string `yaml:"tools,omitempty"`
+}
+
+// Validate checks the required fields and the level, the expression is
+// parsed by the rules engine.
+func (d *RuleDefinition) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if d.Expr == "" {
+		return fmt.Errorf("rule %q: expr is required", d.Name)
+	}
+	if d.Level != "" && !isAlertLevel(d.Level) {
+		return fmt.Errorf("rule %q: invalid level %q", d.Name, d.Level)
+	}
+	return nil
+}
+
+// GetLevel returns the level of the alerts of the rule, warning when not set
+func (d *RuleDefinition) GetLevel() string {
+	if d.Level == "" {
+		return alerts.Warning
+	}
+	return d.Level
+}
@@ -0,0 +1,239 @@
+package depmap
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/cgroup"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+// Discoverer infers the dependency graph of the host from its TCP sockets,
+// the cgroups and parents of their processes and the systemd unit files.
+type Discoverer struct {
+	FS       procfs.FS
+	UnitDirs []string
+}
+
+// NewDiscoverer creates a discoverer of the live system
+func NewDiscoverer(fs procfs.FS) *Discoverer {
+	return &Discoverer{FS: fs, UnitDirs: DefaultUnitDirs}
+}
+
+// discovery is the state of one discovery
+type discovery struct {
+	*graphBuilder
+	fs procfs.FS
+	// processes are the nodes of the processes already resolved
+	processes map[int]*Node
+}
+
+// Discover returns the graph of the services of the host: the services
+// connecting to each other or to remote endpoints, the remote clients of the
+// listening services, the parents of their processes and the services their
+// systemd units depend on. The sockets of the processes of other users are
+// only visible as root.
+func (d *Discoverer) Discover(now time.Time) (*Graph, error) {
+	owners, err := d.FS.SocketOwners()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the socket owners: %w", err)
+	}
+	var sockets []procfs.Socket
+	for _, protocol := range []string{procfs.ProtocolTCP, procfs.ProtocolTCP6} {
+		protocolSockets, err := d.FS.NetSockets(protocol)
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, protocolSockets...)
+	}
+
+	run := &discovery{graphBuilder: newGraphBuilder(now), fs: d.FS, processes: make(map[int]*Node)}
+	// listeners maps the listening ports to the nodes of the services
+	listeners := make(map[int]*Node)
+	for _, socket := range sockets {
+		if socket.State != procfs.TCPStateListen {
+			continue
+		}
+		node := run.listenerNode(owners[socket.Inode], socket.LocalPort)
+		node.Listens = appendUnique(node.Listens, "tcp/"+strconv.Itoa(socket.LocalPort))
+		if node.Service == "" {
+			node.Service = wellKnownPorts[socket.LocalPort]
+		}
+		listeners[socket.LocalPort] = node
+	}
+
+	local := localAddresses()
+	for _, socket := range sockets {
+		owner, ok := owners[socket.Inode]
+		if socket.State == procfs.TCPStateListen || !ok {
+			continue
+		}
+		node := run.processNode(owner.PID, owner.Comm)
+		remoteLocal := local[socket.RemoteAddr] || isLoopback(socket.RemoteAddr)
+		if service, ok := listeners[socket.LocalPort]; ok && service == node {
+			// the server side of a connection, the local clients are found on their side
+			if !remoteLocal {
+				edge := run.edge(clientsID, node.ID, EdgeConnects, socket.LocalPort)
+				run.node(clientsID, NodeClients, "remote clients")
+				addConnection(edge, socket)
+				if run.peers[edge.Key()] == nil {
+					run.peers[edge.Key()] = make(map[string]bool)
+				}
+				run.peers[edge.Key()][socket.RemoteAddr] = true
+			}
+			continue
+		}
+
+		var target *Node
+		if service, ok := listeners[socket.RemotePort]; ok && remoteLocal {
+			target = service
+		} else {
+			address := net.JoinHostPort(socket.RemoteAddr, strconv.Itoa(socket.RemotePort))
+			target = run.node(NodeEndpoint+":"+address, NodeEndpoint, address)
+			target.Service = wellKnownPorts[socket.RemotePort]
+		}
+		if target == node {
+			continue
+		}
+		addConnection(run.edge(node.ID, target.ID, EdgeConnects, socket.RemotePort), socket)
+	}
+
+	run.addParents()
+	run.addUnitDependencies(d.UnitDirs)
+	return run.graph(), nil
+}
+
+// addConnection counts the socket on the edge, a connection retransmitting
+// or still connecting is stalled
+func addConnection(edge *Edge, socket procfs.Socket) {
+	edge.Connections++
+	if edge.States == nil {
+		edge.States = make(map[string]int)
+	}
+	edge.States[socket.State]++
+	edge.TxQueue += socket.TxQueue
+	edge.RxQueue += socket.RxQueue
+	if socket.Retransmits > 0 || socket.State == "SYN_SENT" {
+		edge.Stalled++
+	}
+}
+
+// processNode returns the node of the process: its systemd service, its
+// container or its command.
+func (r *discovery) processNode(pid int, comm string) *Node {
+	if node, ok := r.processes[pid]; ok {
+		return node
+	}
+	id, kind, name := NodeProcess+":"+comm, NodeProcess, comm
+	if path, err := cgroup.PIDGroup(r.fs, pid); err == nil {
+		identity := cgroup.Identify(path)
+		switch {
+		case strings.HasSuffix(identity.Unit, ".service"):
+			id, kind, name = NodeUnit+":"+identity.Unit, NodeUnit, identity.Unit
+		case identity.ContainerID != "":
+			short := identity.ContainerID[:12]
+			id, kind, name = NodeContainer+":"+short, NodeContainer, short
+		}
+	}
+	node := r.node(id, kind, name)
+	node.Processes = appendUnique(node.Processes, comm)
+	node.PIDs = append(node.PIDs, pid)
+	r.processes[pid] = node
+	return node
+}
+
+// listenerNode returns the node of the owner of a listening socket, a node
+// of the port when the owner could not be read
+func (r *discovery) listenerNode(owner procfs.SocketOwner, port int) *Node {
+	if owner.PID > 0 {
+		return r.processNode(owner.PID, owner.Comm)
+	}
+	name := "tcp/" + strconv.Itoa(port)
+	node := r.node(NodeListener+":"+name, NodeListener, name)
+	node.Service = wellKnownPorts[port]
+	return node
+}
+
+// addParents links the nodes to the nodes of the parents of their processes,
+// the processes started by init or by the kernel have no parent worth it
+func (r *discovery) addParents() {
+	pids := make([]int, 0, len(r.processes))
+	for pid := range r.processes {
+		pids = append(pids, pid)
+	}
+	for _, pid := range pids {
+		stat, err := r.fs.ProcStat(pid)
+		if err != nil || stat.PPID <= 2 {
+			continue
+		}
+		parent, err := r.fs.ProcStat(stat.PPID)
+		if err != nil {
+			continue
+		}
+		node, parentNode := r.processes[pid], r.processNode(stat.PPID, parent.Comm)
+		if parentNode != node {
+			r.edge(node.ID, parentNode.ID, EdgeChildOf, 0)
+		}
+	}
+}
+
+// addUnitDependencies links the services to the services their units
+// depend on, the services which are not running are added without process
+func (r *discovery) addUnitDependencies(dirs []string) {
+	var units []*Node
+	for _, node := range r.nodes {
+		if node.Kind == NodeUnit {
+			units = append(units, node)
+		}
+	}
+	// the processes of the units are read once, for the first unit not seen running
+	var unitPIDs map[string][]int
+	for _, unit := range units {
+		for kind, dependencies := range unitDependencies(dirs, unit.Name) {
+			for _, dependency := range dependencies {
+				target, known := r.nodes[NodeUnit+":"+dependency]
+				if !known {
+					if unitPIDs == nil {
+						unitPIDs = r.unitPIDs()
+					}
+					target = r.node(NodeUnit+":"+dependency, NodeUnit, dependency)
+					target.PIDs = unitPIDs[dependency]
+				}
+				r.edge(unit.ID, target.ID, kind, 0)
+			}
+		}
+	}
+}
+
+// unitPIDs returns the processes of the running units, by unit
+func (r *discovery) unitPIDs() map[string][]int {
+	hierarchy, err := cgroup.NewHierarchy(r.fs)
+	if err != nil {
+		return map[string][]int{}
+	}
+	units, _ := hierarchy.UnitPIDs()
+	return units
+}
+
+// localAddresses returns the addresses of the interfaces of the host
+func localAddresses() map[string]bool {
+	local := make(map[string]bool)
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return local
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			local[ipNet.IP.String()] = true
+		}
+	}
+	return local
+}
+
+func isLoopback(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
@@ -0,0 +1,185 @@
+package depmap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// kinds of the nodes
+	NodeUnit      = "unit"
+	NodeContainer = "container"
+	NodeProcess   = "process"
+	// NodeListener is a listening port whose owner could not be read
+	NodeListener = "listener"
+	// NodeEndpoint is a remote address and port the local processes connect to
+	NodeEndpoint = "endpoint"
+	// NodeClients are the remote clients of the local services
+	NodeClients = "clients"
+
+	// kinds of the edges
+	EdgeConnects = "connects"
+	EdgeChildOf  = "child_of"
+	EdgeRequires = "requires"
+	EdgeWants    = "wants"
+	EdgeAfter    = "after"
+
+	// id of the node of the remote clients
+	clientsID = "clients"
+)
+
+// wellKnownPorts names the services usually listening on a port, a hint for
+// the remote endpoints whose process is not visible
+var wellKnownPorts = map[int]string{
+	22: "ssh", 25: "smtp", 53: "dns", 80: "http", 389: "ldap", 443: "https", 636: "ldaps",
+	1433: "mssql", 1521: "oracle", 2181: "zookeeper", 2379: "etcd", 3306: "mysql", 4222: "nats",
+	5432: "postgres", 5672: "amqp", 6379: "redis", 6443: "kubernetes-api", 8080: "http-alt",
+	8125: "statsd", 8200: "vault", 8500: "consul", 9042: "cassandra", 9092: "kafka",
+	9200: "elasticsearch", 11211: "memcached", 27017: "mongodb",
+}
+
+// Node is a service of the host, a remote endpoint or the remote clients
+type Node struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Processes are the commands of the processes of the node
+	Processes []string `json:"processes,omitempty"`
+	PIDs      []int    `json:"pids,omitempty"`
+	// Listens are the listening ports like tcp/5432
+	Listens []string `json:"listens,omitempty"`
+	// Service is the usual service of the port of an endpoint, like redis
+	Service   string    `json:"service,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Edge is a dependency between two nodes, the connections are counted by
+// state and the stalled ones are retransmitting or still connecting.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	// Port is the port of the service connected to
+	Port        int            `json:"port,omitempty"`
+	Connections int            `json:"connections,omitempty"`
+	States      map[string]int `json:"states,omitempty"`
+	Stalled     int            `json:"stalled,omitempty"`
+	// TxQueue are the bytes not acknowledged by the peer, RxQueue the bytes
+	// not read by the local process
+	TxQueue uint64 `json:"tx_queue,omitempty"`
+	RxQueue uint64 `json:"rx_queue,omitempty"`
+	// Peers is the number of distinct remote addresses of the clients
+	Peers     int       `json:"peers,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Seen is the number of discoveries which found the edge
+	Seen int `json:"seen"`
+}
+
+// Key identifies the edge across the discoveries
+func (e *Edge) Key() string {
+	if e.Port > 0 {
+		return fmt.Sprintf("%s %s %s:%d", e.From, e.Kind, e.To, e.Port)
+	}
+	return e.From + " " + e.Kind + " " + e.To
+}
+
+// String describes the edge like "unit:app.service connects endpoint:10.0.0.5:6379 (3 connections, 1 stalled)"
+func (e *Edge) String() string {
+	description := e.From + " " + e.Kind + " " + e.To
+	// the endpoints have the port in their id
+	if e.Port > 0 && e.Kind == EdgeConnects && !strings.HasPrefix(e.To, NodeEndpoint+":") {
+		description += fmt.Sprintf(" port %d", e.Port)
+	}
+	if e.Connections > 0 {
+		description += fmt.Sprintf(" (%d connections", e.Connections)
+		if e.Stalled > 0 {
+			description += fmt.Sprintf(", %d stalled", e.Stalled)
+		}
+		description += ")"
+	}
+	return description
+}
+
+// Graph is the dependency graph found by a discovery
+type Graph struct {
+	Time  time.Time `json:"time"`
+	Nodes []*Node   `json:"nodes"`
+	Edges []*Edge   `json:"edges"`
+}
+
+// graphBuilder accumulates the nodes and the edges of a discovery
+type graphBuilder struct {
+	now   time.Time
+	nodes map[string]*Node
+	edges map[string]*Edge
+	// peers are the distinct remote addresses of the clients edges
+	peers map[string]map[string]bool
+}
+
+func newGraphBuilder(now time.Time) *graphBuilder {
+	return &graphBuilder{
+		now:   now,
+		nodes: make(map[string]*Node),
+		edges: make(map[string]*Edge),
+		peers: make(map[string]map[string]bool),
+	}
+}
+
+// node returns the node with the id, it is created when missing
+func (b *graphBuilder) node(id, kind, name string) *Node {
+	node, ok := b.nodes[id]
+	if !ok {
+		node = &Node{ID: id, Kind: kind, Name: name, FirstSeen: b.now, LastSeen: b.now}
+		b.nodes[id] = node
+	}
+	return node
+}
+
+// edge returns the edge between the nodes, it is created when missing
+func (b *graphBuilder) edge(from, to, kind string, port int) *Edge {
+	edge := &Edge{From: from, To: to, Kind: kind, Port: port}
+	if existing, ok := b.edges[edge.Key()]; ok {
+		return existing
+	}
+	edge.FirstSeen, edge.LastSeen, edge.Seen = b.now, b.now, 1
+	b.edges[edge.Key()] = edge
+	return edge
+}
+
+// graph returns the nodes and the edges sorted by id
+func (b *graphBuilder) graph() *Graph {
+	for key, peers := range b.peers {
+		b.edges[key].Peers = len(peers)
+	}
+	graph := &Graph{Time: b.now, Nodes: make([]*Node, 0, len(b.nodes)), Edges: make([]*Edge, 0, len(b.edges))}
+	for _, node := range b.nodes {
+		sort.Strings(node.Processes)
+		sort.Ints(node.PIDs)
+		sort.Strings(node.Listens)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for _, edge := range b.edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sortGraph(graph)
+	return graph
+}
+
+func sortGraph(graph *Graph) {
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	sort.Slice(graph.Edges, func(i, j int) bool { return graph.Edges[i].Key() < graph.Edges[j].Key() })
+}
+
+// appendUnique appends the value when the slice does not have it
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,286 @@
+package depmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultForgetAfter is the default time after which a dependency not seen anymore is forgotten
+	DefaultForgetAfter = 7 * 24 * time.Hour
+	// an edge found by this many discoveries is a dependency, its absence is reported
+	establishedSeen = 3
+)
+
+// Map is the union of the graphs of the discoveries, the dependencies are
+// kept with the time they were first and last seen until they are forgotten.
+type Map struct {
+	Updated time.Time        `json:"updated"`
+	Nodes   map[string]*Node `json:"nodes"`
+	Edges   map[string]*Edge `json:"edges"`
+}
+
+// Changes are the differences between a discovery and the map
+type Changes struct {
+	Since time.Time `json:"since"`
+	// Initial is set on the first discovery, everything is new then
+	Initial    bool     `json:"initial,omitempty"`
+	AddedNodes []string `json:"added_nodes,omitempty"`
+	AddedEdges []*Edge  `json:"added_edges,omitempty"`
+	// MissingEdges are the established dependencies seen by the previous
+	// discovery and not by this one
+	MissingEdges []*Edge `json:"missing_edges,omitempty"`
+	// StalledEdges are the dependencies whose connections started stalling
+	StalledEdges []*Edge `json:"stalled_edges,omitempty"`
+	// ForgottenEdges are the dependencies not seen for the forget time
+	ForgottenEdges []*Edge `json:"forgotten_edges,omitempty"`
+}
+
+// Empty tells if nothing changed
+func (c *Changes) Empty() bool {
+	return len(c.AddedNodes) == 0 && len(c.AddedEdges) == 0 && len(c.MissingEdges) == 0 &&
+		len(c.StalledEdges) == 0 && len(c.ForgottenEdges) == 0
+}
+
+// NewMap creates an empty map
+func NewMap() *Map {
+	return &Map{Nodes: make(map[string]*Node), Edges: make(map[string]*Edge)}
+}
+
+// Merge adds the graph of a discovery to the map and returns what changed
+// since the previous discovery, the dependencies not seen since forgetAfter
+// are forgotten.
+func (m *Map) Merge(graph *Graph, forgetAfter time.Duration) *Changes {
+	changes := &Changes{Since: m.Updated, Initial: m.Updated.IsZero()}
+	previous := m.Updated
+
+	for _, node := range graph.Nodes {
+		if existing, ok := m.Nodes[node.ID]; ok {
+			node.FirstSeen = existing.FirstSeen
+		} else if !changes.Initial {
+			changes.AddedNodes = append(changes.AddedNodes, node.ID)
+		}
+		m.Nodes[node.ID] = node
+	}
+	seen := make(map[string]bool, len(graph.Edges))
+	for _, edge := range graph.Edges {
+		key := edge.Key()
+		seen[key] = true
+		existing, ok := m.Edges[key]
+		switch {
+		case ok:
+			edge.FirstSeen, edge.Seen = existing.FirstSeen, existing.Seen+1
+			if edge.Stalled > 0 && (existing.Stalled == 0 || !existing.LastSeen.Equal(previous)) {
+				changes.StalledEdges = append(changes.StalledEdges, edge)
+			}
+		case !changes.Initial:
+			changes.AddedEdges = append(changes.AddedEdges, edge)
+			if edge.Stalled > 0 {
+				changes.StalledEdges = append(changes.StalledEdges, edge)
+			}
+		}
+		m.Edges[key] = edge
+	}
+	for key, edge := range m.Edges {
+		if seen[key] {
+			continue
+		}
+		if edge.LastSeen.Equal(previous) && edge.Seen >= establishedSeen {
+			changes.MissingEdges = append(changes.MissingEdges, edge)
+		}
+		if forgetAfter > 0 && graph.Time.Sub(edge.LastSeen) > forgetAfter {
+			changes.ForgottenEdges = append(changes.ForgottenEdges, edge)
+			delete(m.Edges, key)
+		}
+	}
+	m.forgetNodes(graph.Time, forgetAfter)
+	m.Updated = graph.Time
+
+	for _, edges := range [][]*Edge{changes.AddedEdges, changes.MissingEdges, changes.StalledEdges, changes.ForgottenEdges} {
+		sort.Slice(edges, func(i, j int) bool { return edges[i].Key() < edges[j].Key() })
+	}
+	sort.Strings(changes.AddedNodes)
+	return changes
+}
+
+// forgetNodes drops the nodes not seen since forgetAfter and without edge
+func (m *Map) forgetNodes(now time.Time, forgetAfter time.Duration) {
+	if forgetAfter <= 0 {
+		return
+	}
+	linked := make(map[string]bool)
+	for _, edge := range m.Edges {
+		linked[edge.From], linked[edge.To] = true, true
+	}
+	for id, node := range m.Nodes {
+		if !linked[id] && now.Sub(node.LastSeen) > forgetAfter {
+			delete(m.Nodes, id)
+		}
+	}
+}
+
+// clone returns a copy of the map sharing its nodes and edges, Merge replaces
+// them instead of changing them so the copy could be merged alone.
+func (m *Map) clone() *Map {
+	clone := &Map{Updated: m.Updated, Nodes: make(map[string]*Node, len(m.Nodes)), Edges: make(map[string]*Edge, len(m.Edges))}
+	for id, node := range m.Nodes {
+		clone.Nodes[id] = node
+	}
+	for key, edge := range m.Edges {
+		clone.Edges[key] = edge
+	}
+	return clone
+}
+
+// Current returns the graph of the nodes and edges seen by the last discovery
+func (m *Map) Current() *Graph {
+	graph := &Graph{Time: m.Updated}
+	for _, node := range m.Nodes {
+		if node.LastSeen.Equal(m.Updated) {
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+	for _, edge := range m.Edges {
+		if edge.LastSeen.Equal(m.Updated) {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+	sortGraph(graph)
+	return graph
+}
+
+// LoadMap reads the map saved by Save, a missing file is an empty map
+func LoadMap(path string) (*Map, error) {
+	m := NewMap()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("failed to read dependency map: %w", err)
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return NewMap(), fmt.Errorf("failed to parse dependency map %s: %w", path, err)
+	}
+	if m.Nodes == nil || m.Edges == nil {
+		return NewMap(), nil
+	}
+	return m, nil
+}
+
+// Save writes the map, the file is replaced atomically
+func (m *Map) Save(path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create dependency map directory: %w", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode dependency map: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save dependency map: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save dependency map: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save dependency map: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Tracker runs the discoveries and keeps the persisted map up to date, it is
+// shared by the watcher and the tool of the agent.
+type Tracker struct {
+	discoverer  *Discoverer
+	path        string
+	forgetAfter time.Duration
+	mu          sync.Mutex
+	m           *Map
+}
+
+// NewTracker creates a tracker persisting its map in the file
+func NewTracker(discoverer *Discoverer, path string) *Tracker {
+	return &Tracker{discoverer: discoverer, path: path, forgetAfter: DefaultForgetAfter}
+}
+
+// SetForgetAfter sets the time after which a dependency not seen anymore is forgotten
+func (t *Tracker) SetForgetAfter(forgetAfter time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.forgetAfter = forgetAfter
+}
+
+// Refresh discovers the graph, merges it into the map and saves the map,
+// the map is loaded on the first refresh. Only the watcher of the
+// dependencies refreshes the map, so it sees every change once.
+func (t *Tracker) Refresh(now time.Time) (*Graph, *Changes, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.load(); err != nil {
+		return nil, nil, err
+	}
+	graph, err := t.discoverer.Discover(now)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes := t.m.Merge(graph, t.forgetAfter)
+	if err := t.m.Save(t.path); err != nil {
+		return graph, changes, err
+	}
+	return graph, changes, nil
+}
+
+// Discover discovers the graph and returns its changes since the last
+// refresh, the map is neither changed nor saved.
+func (t *Tracker) Discover(now time.Time) (*Graph, *Changes, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.load(); err != nil {
+		return nil, nil, err
+	}
+	graph, err := t.discoverer.Discover(now)
+	if err != nil {
+		return nil, nil, err
+	}
+	return graph, t.m.clone().Merge(graph, t.forgetAfter), nil
+}
+
+// Map returns the nodes and the edges of the map, seen or not by the last discovery
+func (t *Tracker) Map() (*Graph, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	graph := &Graph{Time: t.m.Updated}
+	for _, node := range t.m.Nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for _, edge := range t.m.Edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sortGraph(graph)
+	return graph, nil
+}
+
+// load reads the map on the first use, a map which could not be read is
+// replaced by an empty one, the lock must be held
+func (t *Tracker) load() error {
+	if t.m != nil {
+		return nil
+	}
+	m, err := LoadMap(t.path)
+	t.m = m
+	return err
+}
@@ -0,0 +1,52 @@
+package depmap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+func TestTrackerDiscoverKeepsChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dependency_map.json")
+	updated := time.Now().Add(-time.Minute).Truncate(time.Second)
+	// an established dependency which the empty fake host does not have anymore
+	m := NewMap()
+	m.Updated = updated
+	edge := &Edge{From: "unit:app.service", To: "endpoint:10.0.0.5:5432", Kind: EdgeConnects, Port: 5432, FirstSeen: updated.Add(-time.Hour), LastSeen: updated, Seen: 10}
+	m.Edges[edge.Key()] = edge
+	assert.NoError(t, m.Save(path))
+	saved, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "proc"), 0755))
+	tracker := NewTracker(&Discoverer{FS: procfs.NewFS(filepath.Join(dir, "proc"), filepath.Join(dir, "sys"))}, path)
+
+	// the discoveries of the tool neither consume the changes nor save the map
+	for i := 0; i < 2; i++ {
+		_, changes, err := tracker.Discover(time.Now())
+		assert.NoError(t, err)
+		if assert.Len(t, changes.MissingEdges, 1) {
+			assert.Equal(t, edge.Key(), changes.MissingEdges[0].Key())
+		}
+		current, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, saved, current)
+	}
+	graph, err := tracker.Map()
+	assert.NoError(t, err)
+	assert.Equal(t, updated, graph.Time.Local())
+
+	// the watcher sees the change once
+	_, changes, err := tracker.Refresh(time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, changes.MissingEdges, 1)
+	_, changes, err = tracker.Refresh(time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, changes.MissingEdges)
+}
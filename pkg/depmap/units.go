@@ -0,0 +1,127 @@
+package depmap
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultUnitDirs are the directories of the systemd unit files, by precedence
+var DefaultUnitDirs = []string{"/etc/systemd/system", "/run/systemd/system", "/usr/lib/systemd/system", "/lib/systemd/system"}
+
+// unitDependencyKinds maps the dependency settings of the units to the kinds of the edges
+var unitDependencyKinds = map[string]string{
+	"Requires":  EdgeRequires,
+	"Requisite": EdgeRequires,
+	"BindsTo":   EdgeRequires,
+	"Wants":     EdgeWants,
+	"After":     EdgeAfter,
+}
+
+// unitDependencies returns the services the unit depends on by kind of edge,
+// from its unit file, its drop-ins and its .wants and .requires directories.
+// Only the services are kept, the targets, sockets and mounts are the
+// plumbing of the boot.
+func unitDependencies(dirs []string, unit string) map[string][]string {
+	settings := make(map[string][]string)
+	for _, file := range unitFiles(dirs, unit) {
+		readUnitFile(file, settings)
+	}
+	dependencies := make(map[string][]string)
+	for setting, values := range settings {
+		kind, ok := unitDependencyKinds[setting]
+		if !ok {
+			continue
+		}
+		for _, value := range values {
+			if strings.HasSuffix(value, ".service") && value != unit {
+				dependencies[kind] = appendUnique(dependencies[kind], value)
+			}
+		}
+	}
+	for _, dir := range dirs {
+		for suffix, kind := range map[string]string{".wants": EdgeWants, ".requires": EdgeRequires} {
+			entries, err := os.ReadDir(filepath.Join(dir, unit+suffix))
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				if strings.HasSuffix(entry.Name(), ".service") {
+					dependencies[kind] = appendUnique(dependencies[kind], entry.Name())
+				}
+			}
+		}
+	}
+	for kind := range dependencies {
+		sort.Strings(dependencies[kind])
+	}
+	return dependencies
+}
+
+// unitFiles returns the unit file of the unit with the highest precedence,
+// the template of an instance like getty@tty1.service, then its drop-ins.
+func unitFiles(dirs []string, unit string) []string {
+	names := []string{unit}
+	if prefix, rest, ok := strings.Cut(unit, "@"); ok {
+		if _, suffix, ok := strings.Cut(rest, "."); ok {
+			names = append(names, prefix+"@."+suffix)
+		}
+	}
+
+	var files []string
+	for _, name := range names {
+		for _, dir := range dirs {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				files = append(files, path)
+				break
+			}
+		}
+		if len(files) > 0 {
+			break
+		}
+	}
+	for _, name := range names {
+		for _, dir := range dirs {
+			dropins, _ := filepath.Glob(filepath.Join(dir, name+".d", "*.conf"))
+			files = append(files, dropins...)
+		}
+	}
+	return files
+}
+
+// readUnitFile adds the space separated values of the settings of the
+// [Unit] section to the settings, an empty value resets the setting like in
+// systemd so a drop-in could replace the dependencies of the unit file.
+func readUnitFile(path string, settings map[string][]string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section != "Unit" {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if value == "" {
+			delete(settings, key)
+			continue
+		}
+		settings[key] = append(settings[key], strings.Fields(value)...)
+	}
+}
@@ -0,0 +1,159 @@
+package disk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errBudgetExceeded stops the walk when its time budget is spent
+var errBudgetExceeded = errors.New("time budget exceeded")
+
+// DirSize is the disk space allocated below a directory
+type DirSize struct {
+	Path  string `json:"path"`
+	Bytes uint64 `json:"bytes"`
+	Files uint64 `json:"files"`
+}
+
+// DirReport is the result of a walk for the largest directories
+type DirReport struct {
+	Root         string    `json:"root"`
+	TotalBytes   uint64    `json:"total_bytes"`
+	TotalFiles   uint64    `json:"total_files"`
+	Directories  []DirSize `json:"directories"`
+	SkippedMount []string  `json:"skipped_mounts,omitempty"`
+	Unreadable   int       `json:"unreadable,omitempty"`
+	// Partial is true when the time budget was spent before the end of the walk,
+	// the sizes are then lower bounds.
+	Partial bool   `json:"partial,omitempty"`
+	Elapsed string `json:"elapsed"`
+}
+
+// LargestDirs walks the filesystem of root and returns the limit largest
+// directories at most depth levels below root. The walk never crosses into
+// another filesystem, so /proc and the other mounts are not traversed, and it
+// stops when the budget is spent. The sizes are the allocated blocks like du,
+// the hard linked files are counted once.
+func LargestDirs(root string, depth int, budget time.Duration, limit int) (*DirReport, error) {
+	root = filepath.Clean(root)
+	info, err := os.Lstat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "walk", Path: root, Err: syscall.ENOTDIR}
+	}
+	rootDev := device(info)
+
+	start := time.Now()
+	deadline := start.Add(budget)
+	report := &DirReport{Root: root}
+	sizes := make(map[string]*DirSize)
+	linked := make(map[uint64]bool)
+	visited := 0
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			report.Unreadable++
+			if entry != nil && entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		// Checking the clock for every entry is too costly on large trees.
+		if visited++; visited%1024 == 0 && time.Now().After(deadline) {
+			return errBudgetExceeded
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			report.Unreadable++
+			return nil
+		}
+		if entry.IsDir() && path != root && device(info) != rootDev {
+			report.SkippedMount = append(report.SkippedMount, path)
+			return fs.SkipDir
+		}
+
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return nil
+		}
+		if !entry.IsDir() && stat.Nlink > 1 {
+			if linked[stat.Ino] {
+				return nil
+			}
+			linked[stat.Ino] = true
+		}
+
+		bytes := uint64(stat.Blocks) * 512
+		report.TotalBytes += bytes
+		if !entry.IsDir() {
+			report.TotalFiles++
+		}
+		for _, dir := range ancestors(root, path, entry.IsDir(), depth) {
+			size, ok := sizes[dir]
+			if !ok {
+				size = &DirSize{Path: dir}
+				sizes[dir] = size
+			}
+			size.Bytes += bytes
+			if !entry.IsDir() {
+				size.Files++
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errBudgetExceeded) {
+		report.Partial = true
+	} else if err != nil {
+		return nil, err
+	}
+
+	report.Directories = make([]DirSize, 0, len(sizes))
+	for _, size := range sizes {
+		report.Directories = append(report.Directories, *size)
+	}
+	sort.Slice(report.Directories, func(i, j int) bool {
+		return report.Directories[i].Bytes > report.Directories[j].Bytes
+	})
+	if len(report.Directories) > limit {
+		report.Directories = report.Directories[:limit]
+	}
+	report.Elapsed = time.Since(start).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// ancestors returns the directories below root, up to depth levels, whose
+// size includes the entry, root itself is reported as total only.
+func ancestors(root, path string, isDir bool, depth int) []string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return nil
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if !isDir {
+		parts = parts[:len(parts)-1]
+	}
+
+	dirs := make([]string, 0, min(len(parts), depth))
+	dir := root
+	for i := 0; i < len(parts) && i < depth; i++ {
+		dir = filepath.Join(dir, parts[i])
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func device(info fs.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev)
+	}
+	return 0
+}
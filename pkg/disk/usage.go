@@ -0,0 +1,141 @@
+package disk
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/mathutil"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+const (
+	// maximal time waited for the statfs of one mount, a hung network
+	// filesystem must not block the whole report
+	statfsTimeout = 2 * time.Second
+)
+
+// pseudoFSTypes are the filesystems without disk space worth reporting
+var pseudoFSTypes = map[string]bool{
+	"proc": true, "sysfs": true, "devpts": true, "cgroup": true, "cgroup2": true, "securityfs": true,
+	"pstore": true, "bpf": true, "debugfs": true, "tracefs": true, "configfs": true, "fusectl": true,
+	"mqueue": true, "hugetlbfs": true, "autofs": true, "binfmt_misc": true, "rpc_pipefs": true,
+	"nsfs": true, "efivarfs": true, "selinuxfs": true, "devtmpfs": true,
+}
+
+// networkFSTypes are the filesystems served by a remote host, their statfs
+// hangs while the server is unreachable
+var networkFSTypes = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "smbfs": true, "ceph": true, "glusterfs": true,
+	"9p": true, "afs": true, "lustre": true, "gpfs": true, "davfs": true,
+}
+
+// pendingStatfs are the mount points whose statfs is still running after its timeout
+var pendingStatfs sync.Map
+
+// IsNetworkFS returns whether the filesystem type is a network filesystem,
+// the fuse filesystems count as such since their daemon could hang as well.
+func IsNetworkFS(fsType string) bool {
+	return networkFSTypes[fsType] || fsType == "fuse" || strings.HasPrefix(fsType, "fuse.")
+}
+
+// MountUsage is the usage of the bytes and inodes of a mounted filesystem
+type MountUsage struct {
+	procfs.Mount
+	SizeBytes   uint64  `json:"size_bytes"`
+	UsedBytes   uint64  `json:"used_bytes"`
+	AvailBytes  uint64  `json:"avail_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+	Inodes      uint64  `json:"inodes"`
+	InodesUsed  uint64  `json:"inodes_used"`
+	// InodesUsedPercent is 0 for the filesystems without inode limit like btrfs
+	InodesUsedPercent float64 `json:"inodes_used_percent"`
+	Error             string  `json:"error,omitempty"`
+}
+
+// Usage returns the usage of the mounted filesystems, the pseudo filesystems
+// and the repeated mounts of the same device are skipped.
+func Usage(fs procfs.FS) ([]MountUsage, error) {
+	return UsageOf(fs, nil)
+}
+
+// UsageOf returns the usage of the mounted filesystems accepted by include,
+// all of them when include is nil.
+func UsageOf(fs procfs.FS, include func(procfs.Mount) bool) ([]MountUsage, error) {
+	mounts, err := fs.Mounts()
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]MountUsage, 0, len(mounts))
+	seen := make(map[string]bool)
+	for _, mount := range mounts {
+		if pseudoFSTypes[mount.FSType] || (include != nil && !include(mount)) {
+			continue
+		}
+		// Bind mounts and the mounts of the containers repeat the same filesystem.
+		key := mount.Device + "\x00" + mount.FSType
+		if mount.FSType == "tmpfs" || mount.FSType == "overlay" {
+			key += "\x00" + mount.MountPoint
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		usage := MountUsage{Mount: mount}
+		stat, err := statfs(mount.MountPoint)
+		if err != nil {
+			usage.Error = err.Error()
+			usages = append(usages, usage)
+			continue
+		}
+		if stat.Blocks == 0 {
+			continue
+		}
+		blockSize := uint64(stat.Bsize)
+		usage.SizeBytes = stat.Blocks * blockSize
+		usage.UsedBytes = (stat.Blocks - stat.Bfree) * blockSize
+		usage.AvailBytes = stat.Bavail * blockSize
+		// Like df, the blocks reserved to root are not available to the users.
+		if usable := usage.UsedBytes + usage.AvailBytes; usable > 0 {
+			usage.UsedPercent = mathutil.Round2(float64(usage.UsedBytes) * 100 / float64(usable))
+		}
+		usage.Inodes = stat.Files
+		if stat.Files > 0 {
+			usage.InodesUsed = stat.Files - stat.Ffree
+			usage.InodesUsedPercent = mathutil.Round2(float64(usage.InodesUsed) * 100 / float64(stat.Files))
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// statfs calls statfs with a timeout, the call is left behind on timeout and
+// the next calls for the same path fail at once until it returns, so a hung
+// mount holds a single goroutine.
+func statfs(path string) (syscall.Statfs_t, error) {
+	if _, running := pendingStatfs.LoadOrStore(path, struct{}{}); running {
+		return syscall.Statfs_t{}, fmt.Errorf("statfs of %s is still running since a previous call", path)
+	}
+	type result struct {
+		stat syscall.Statfs_t
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(path, &stat)
+		pendingStatfs.Delete(path)
+		done <- result{stat: stat, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.stat, r.err
+	case <-time.After(statfsTimeout):
+		return syscall.Statfs_t{}, fmt.Errorf("statfs of %s timed out after %s", path, statfsTimeout)
+	}
+}
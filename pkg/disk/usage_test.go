@@ -0,0 +1,53 @@
+package disk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+func TestIsNetworkFS(t *testing.T) {
+	for fsType, network := range map[string]bool{
+		"nfs4": true, "cifs": true, "fuse.sshfs": true, "fuse": true,
+		"ext4": false, "xfs": false, "tmpfs": false, "fuseblk": false,
+	} {
+		assert.Equal(t, network, IsNetworkFS(fsType), fsType)
+	}
+}
+
+func TestUsageOf(t *testing.T) {
+	dir := t.TempDir()
+	mounts := fmt.Sprintf("/dev/sda1 %[1]s ext4 rw 0 0\nserver:/export %[1]s nfs4 rw 0 0\nproc /proc proc rw 0 0\n", dir)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "mounts"), []byte(mounts), 0644))
+	fs := procfs.NewFS(dir, dir)
+
+	usages, err := Usage(fs)
+	assert.NoError(t, err)
+	assert.Len(t, usages, 2)
+
+	usages, err = UsageOf(fs, func(mount procfs.Mount) bool { return !IsNetworkFS(mount.FSType) })
+	assert.NoError(t, err)
+	if assert.Len(t, usages, 1) {
+		assert.Equal(t, "ext4", usages[0].FSType)
+		assert.Empty(t, usages[0].Error)
+	}
+}
+
+func TestStatfsStillRunning(t *testing.T) {
+	dir := t.TempDir()
+	// the statfs of a hung mount has not returned yet
+	pendingStatfs.Store(dir, struct{}{})
+	_, err := statfs(dir)
+	assert.ErrorContains(t, err, "still running")
+
+	pendingStatfs.Delete(dir)
+	_, err = statfs(dir)
+	assert.NoError(t, err)
+	_, running := pendingStatfs.Load(dir)
+	assert.False(t, running)
+}
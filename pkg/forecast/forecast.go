@@ -0,0 +1,296 @@
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+const (
+	KindFilesystem = "filesystem"
+	KindInodes     = "inodes"
+	KindSwap       = "swap"
+	KindProcess    = "process"
+
+	// DefaultHistory is the history the trends are fitted on when not set,
+	// the store keeps less when its retention is shorter
+	DefaultHistory = 24 * time.Hour
+	// DefaultMinHistory is the shortest history a forecast is made on
+	DefaultMinHistory = time.Hour
+
+	// the trends are fitted on the means of the minutes
+	step = time.Minute
+	// 95% confidence of the interval of the exhaustion time
+	confidence = 1.96
+	// the exhaustions beyond a year are not forecast
+	maxProjection = 365 * 24 * time.Hour
+
+	// a leak candidate grows on most of its changes and along a line
+	leakMonotonicity = 0.8
+	leakR2           = 0.6
+)
+
+// Kinds are all the kinds of forecast
+var Kinds = []string{KindFilesystem, KindInodes, KindSwap, KindProcess}
+
+// target is a resource forecast from the series of a metric
+type target struct {
+	kind   string
+	metric string
+	// limit is the value of the exhausted resource, fixed for the percentages
+	limit float64
+}
+
+var targets = []target{
+	{kind: KindFilesystem, metric: "disk.used_percent", limit: 100},
+	{kind: KindInodes, metric: "disk.inodes_used_percent", limit: 100},
+	{kind: KindSwap, metric: "swap.used_percent", limit: 100},
+	// the limit of a process is its memory and the available memory of the host
+	{kind: KindProcess, metric: "process.rss_bytes"},
+}
+
+// Forecast is the projected exhaustion of a resource from the trend of its
+// series, the exhaustion times are nil when the series does not grow.
+type Forecast struct {
+	Kind    string         `json:"kind"`
+	Metric  string         `json:"metric"`
+	Labels  metrics.Labels `json:"labels,omitempty"`
+	Current float64        `json:"current"`
+	Limit   float64        `json:"limit"`
+	// GrowthPerHour is the slope of the trend in the unit of the metric
+	GrowthPerHour float64 `json:"growth_per_hour"`
+	R2            float64 `json:"r2"`
+	History       string  `json:"history"`
+	// Monotonicity is the part of the changes which are increases, for the processes
+	Monotonicity float64 `json:"monotonicity,omitempty"`
+	// ExhaustionAt is the projected time of the exhaustion, between Earliest
+	// and Latest with 95% confidence, Latest is nil when the growth could stop
+	ExhaustionAt *time.Time `json:"exhaustion_at,omitempty"`
+	Earliest     *time.Time `json:"earliest,omitempty"`
+	Latest       *time.Time `json:"latest,omitempty"`
+}
+
+// Series returns the metric and the labels of the forecast
+func (f Forecast) Series() string {
+	return f.Metric + f.Labels.String()
+}
+
+// Within tells if the resource is projected to be exhausted before the time
+func (f Forecast) Within(before time.Time) bool {
+	return f.ExhaustionAt != nil && !f.ExhaustionAt.After(before)
+}
+
+// Message describes the forecast in a sentence for the alerts and the prompts
+func (f Forecast) Message(now time.Time) string {
+	what := "will be full"
+	if f.Kind == KindProcess {
+		what = "could exhaust the memory"
+	}
+	if f.ExhaustionAt == nil {
+		return fmt.Sprintf("%s is at %s of %s and does not grow significantly", f.Series(), f.format(f.Current), f.format(f.Limit))
+	}
+	if !f.ExhaustionAt.After(now) {
+		return fmt.Sprintf("%s is at %s, its limit of %s is reached", f.Series(), f.format(f.Current), f.format(f.Limit))
+	}
+	interval := "at the earliest in " + FormatDuration(f.Earliest.Sub(now))
+	if f.Latest != nil {
+		interval = fmt.Sprintf("between %s and %s", FormatDuration(f.Earliest.Sub(now)), FormatDuration(f.Latest.Sub(now)))
+	}
+	return fmt.Sprintf("%s %s in %s (%s): at %s of %s, growing %s per hour over the last %s",
+		f.Series(), what, FormatDuration(f.ExhaustionAt.Sub(now)), interval,
+		f.format(f.Current), f.format(f.Limit), f.format(f.GrowthPerHour), f.History)
+}
+
+// format formats a value in the unit of the metric, MiB for the memory of
+// the processes and percent otherwise
+func (f Forecast) format(value float64) string {
+	if f.Kind == KindProcess {
+		return fmt.Sprintf("%.0fMiB", value/(1<<20))
+	}
+	return fmt.Sprintf("%.4g%%", value)
+}
+
+// Options select the history of the forecasts
+type Options struct {
+	// History is the history the trends are fitted on, DefaultHistory when not set
+	History time.Duration
+	// MinHistory is the shortest history of a series, DefaultMinHistory when not set
+	MinHistory time.Duration
+	// Stale skips the series without sample since this time before now, like
+	// the processes which exited, 5 minutes when not set
+	Stale time.Duration
+	// Kinds are the forecast kinds, all of them when empty
+	Kinds []string
+}
+
+// Capacity forecasts the exhaustion of the filesystems, the inodes, the swap
+// and the memory of the processes growing like leaks, from the series of the
+// store. The series which do not grow are returned without exhaustion time,
+// except the processes which are returned only when they are leak candidates.
+// The forecasts are sorted by exhaustion time.
+func Capacity(store *metrics.Store, now time.Time, options Options) ([]Forecast, error) {
+	if options.History <= 0 {
+		options.History = DefaultHistory
+	}
+	if options.MinHistory <= 0 {
+		options.MinHistory = DefaultMinHistory
+	}
+	if options.Stale <= 0 {
+		options.Stale = 5 * time.Minute
+	}
+	if err := CheckKinds(options.Kinds); err != nil {
+		return nil, err
+	}
+	kinds := make(map[string]bool)
+	for _, kind := range options.Kinds {
+		kinds[kind] = true
+	}
+
+	available := latest(store, "memory.available_bytes", now, options.Stale)
+	var forecasts []Forecast
+	for _, target := range targets {
+		if len(kinds) > 0 && !kinds[target.kind] {
+			continue
+		}
+		results, err := store.Query(metrics.Query{
+			Name:      target.metric,
+			From:      now.Add(-options.History),
+			To:        now,
+			Step:      step,
+			Aggregate: metrics.AggregateAvg,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			points := result.Points
+			if len(points) == 0 || points[len(points)-1].Time.Before(now.Add(-options.Stale)) {
+				continue
+			}
+			history := points[len(points)-1].Time.Sub(points[0].Time)
+			if history < options.MinHistory {
+				continue
+			}
+			trend, err := Fit(points)
+			if err != nil {
+				continue
+			}
+			forecast := Forecast{
+				Kind:          target.kind,
+				Metric:        result.Name,
+				Labels:        result.Labels,
+				Current:       points[len(points)-1].Value,
+				Limit:         target.limit,
+				GrowthPerHour: round(trend.Slope * 3600),
+				R2:            round(trend.R2),
+				History:       FormatDuration(history),
+			}
+			if target.kind == KindProcess {
+				forecast.Monotonicity = round(Monotonicity(points))
+				if available == nil || forecast.Monotonicity < leakMonotonicity || trend.R2 < leakR2 {
+					continue
+				}
+				forecast.Limit = forecast.Current + *available
+			}
+			project(&forecast, trend, now)
+			if target.kind == KindProcess && forecast.ExhaustionAt == nil {
+				continue
+			}
+			forecasts = append(forecasts, forecast)
+		}
+	}
+	sort.SliceStable(forecasts, func(i, j int) bool {
+		a, b := forecasts[i].ExhaustionAt, forecasts[j].ExhaustionAt
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+	return forecasts, nil
+}
+
+// CheckKinds checks the forecast kinds
+func CheckKinds(kinds []string) error {
+	for _, kind := range kinds {
+		switch kind {
+		case KindFilesystem, KindInodes, KindSwap, KindProcess:
+		default:
+			return fmt.Errorf("unknown forecast kind %q, use %s, %s, %s or %s", kind, KindFilesystem, KindInodes, KindSwap, KindProcess)
+		}
+	}
+	return nil
+}
+
+// project sets the exhaustion times of the forecast from the trend, only
+// when the growth is significant: the lower bound of the slope is positive.
+func project(forecast *Forecast, trend Trend, now time.Time) {
+	if forecast.Current >= forecast.Limit {
+		forecast.ExhaustionAt, forecast.Earliest = &now, &now
+		return
+	}
+	low, high := trend.Slope-confidence*trend.SlopeErr, trend.Slope+confidence*trend.SlopeErr
+	if low <= 0 {
+		return
+	}
+	remaining := forecast.Limit - forecast.Current
+	at := func(slope float64) *time.Time {
+		seconds := remaining / slope
+		if seconds > maxProjection.Seconds() {
+			return nil
+		}
+		t := now.Add(time.Duration(seconds * float64(time.Second)))
+		return &t
+	}
+	forecast.ExhaustionAt = at(trend.Slope)
+	if forecast.ExhaustionAt == nil {
+		return
+	}
+	forecast.Earliest = at(high)
+	forecast.Latest = at(low)
+}
+
+// latest returns the last value of a metric without labels, nil when it is
+// not sampled recently.
+func latest(store *metrics.Store, name string, now time.Time, stale time.Duration) *float64 {
+	results, err := store.Query(metrics.Query{Name: name, From: now.Add(-stale), To: now, Aggregate: metrics.AggregateLast, Step: stale})
+	if err != nil {
+		return nil
+	}
+	for _, result := range results {
+		if len(result.Points) > 0 {
+			value := result.Points[len(result.Points)-1].Value
+			return &value
+		}
+	}
+	return nil
+}
+
+// FormatDuration formats a duration by its two largest units, like 3d4h or 25m
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days, hours, minutes := int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case days > 0:
+		return fmt.Sprintf("%dd", days)
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// round keeps 4 significant digits, the growths could be small
+func round(value float64) float64 {
+	if value == 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	scale := math.Pow(10, 4-math.Ceil(math.Log10(math.Abs(value))))
+	return math.Round(value*scale) / scale
+}
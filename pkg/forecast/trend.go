@@ -0,0 +1,133 @@
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+// Trend is the least squares line of a series
+type Trend struct {
+	// Slope is the growth per second
+	Slope float64
+	// SlopeErr is the standard error of the slope, corrected for the
+	// correlation of the successive residuals and the change of the slope
+	SlopeErr float64
+	// R2 is the part of the variance explained by the line
+	R2     float64
+	Points int
+	Start  time.Time
+	End    time.Time
+}
+
+// Fit fits a line to the points, at least 3 points over some time are needed
+func Fit(points []metrics.Point) (Trend, error) {
+	trend, err := fitLine(points)
+	if err != nil {
+		return trend, err
+	}
+	// The trend of a system changes, the difference of the slopes of the two
+	// halves of the history is added to the error so a growth which slows
+	// down or speeds up widens the interval of the exhaustion.
+	n := len(points)
+	if n >= 6 {
+		first, errFirst := fitLine(points[:n/2])
+		second, errSecond := fitLine(points[n/2:])
+		if errFirst == nil && errSecond == nil {
+			change := (second.Slope - first.Slope) / 2
+			trend.SlopeErr = math.Sqrt(trend.SlopeErr*trend.SlopeErr + change*change)
+		}
+	}
+	return trend, nil
+}
+
+// fitLine fits the least squares line, the error of the slope is only
+// corrected for the correlation of the residuals
+func fitLine(points []metrics.Point) (Trend, error) {
+	n := len(points)
+	if n < 3 {
+		return Trend{}, fmt.Errorf("not enough points to fit a trend: %d", n)
+	}
+	start := points[0].Time
+	var meanX, meanY float64
+	for _, point := range points {
+		meanX += point.Time.Sub(start).Seconds()
+		meanY += point.Value
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var sxx, sxy, syy float64
+	for _, point := range points {
+		dx, dy := point.Time.Sub(start).Seconds()-meanX, point.Value-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return Trend{}, fmt.Errorf("the points have the same time")
+	}
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	residuals := make([]float64, n)
+	var sse float64
+	for i, point := range points {
+		residuals[i] = point.Value - (intercept + slope*point.Time.Sub(start).Seconds())
+		sse += residuals[i] * residuals[i]
+	}
+	trend := Trend{
+		Slope:  slope,
+		R2:     1,
+		Points: n,
+		Start:  start,
+		End:    points[n-1].Time,
+	}
+	if syy > 0 {
+		trend.R2 = math.Max(0, 1-sse/syy)
+	}
+	trend.SlopeErr = math.Sqrt(sse/float64(n-2)/sxx) * math.Sqrt(autocorrelationFactor(residuals))
+	return trend, nil
+}
+
+// autocorrelationFactor returns how much the variance of the slope is
+// underestimated when the residuals follow each other, as they do on the
+// samples of a system, from the lag 1 autocorrelation of the residuals.
+func autocorrelationFactor(residuals []float64) float64 {
+	var num, den float64
+	for i, residual := range residuals {
+		den += residual * residual
+		if i > 0 {
+			num += residual * residuals[i-1]
+		}
+	}
+	if den == 0 {
+		return 1
+	}
+	r := math.Min(num/den, 0.95)
+	if r <= 0 {
+		return 1
+	}
+	return (1 + r) / (1 - r)
+}
+
+// Monotonicity returns the part of the changes between successive points
+// which are increases, 1 for a series which never decreases.
+func Monotonicity(points []metrics.Point) float64 {
+	var increases, changes int
+	for i := 1; i < len(points); i++ {
+		switch {
+		case points[i].Value > points[i-1].Value:
+			increases++
+			changes++
+		case points[i].Value < points[i-1].Value:
+			changes++
+		}
+	}
+	if changes == 0 {
+		return 0
+	}
+	return float64(increases) / float64(changes)
+}
@@ -0,0 +1,217 @@
+package kernel
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+)
+
+const (
+	EventOOMKill       = "oom_kill"
+	EventHungTask      = "hung_task"
+	EventSoftLockup    = "soft_lockup"
+	EventHardLockup    = "hard_lockup"
+	EventFSError       = "fs_error"
+	EventIOError       = "io_error"
+	EventSegfault      = "segfault"
+	EventHardwareError = "hardware_error"
+
+	// number of lines kept before an event, the OOM killer report precedes its last line
+	contextBefore = 20
+	// maximal time an event waits for its trailing lines, like the call trace of a hung task
+	trailingTimeout = 2 * time.Second
+)
+
+// EventKinds are the kinds of events recognized in the kernel messages
+var EventKinds = []string{
+	EventOOMKill, EventHungTask, EventSoftLockup, EventHardLockup,
+	EventFSError, EventIOError, EventSegfault, EventHardwareError,
+}
+
+// Event is a kernel message recognized as an issue, Fields are extracted from
+// the message like the pid and the command of a killed process.
+type Event struct {
+	Kind    string            `json:"kind"`
+	Time    time.Time         `json:"time"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+	// Context are the kernel messages around the event
+	Context []string `json:"context,omitempty"`
+}
+
+// Key identifies the repetitions of an event, like the segfaults of a command
+// or the errors of a device.
+func (e Event) Key() string {
+	for _, field := range []string{"device", "comm", "cpu"} {
+		if value, ok := e.Fields[field]; ok {
+			return e.Kind + ":" + value
+		}
+	}
+	return e.Kind
+}
+
+// rule recognizes an event, the named groups of the expression are the fields
+type rule struct {
+	kind   string
+	regexp *regexp.Regexp
+	// after is the number of lines following the message added to the context
+	after int
+}
+
+var rules = []rule{
+	{EventOOMKill, regexp.MustCompile(`(?i)out of memory.*: Killed process (?P<pid>\d+) \((?P<comm>[^)]*)\)`), 0},
+	{EventHungTask, regexp.MustCompile(`INFO: task (?P<comm>.+):(?P<pid>\d+) blocked for more than (?P<seconds>\d+) seconds`), 15},
+	{EventSoftLockup, regexp.MustCompile(`soft lockup - CPU#(?P<cpu>\d+) stuck for (?P<seconds>\d+)s! \[(?P<comm>.*):(?P<pid>\d+)\]`), 15},
+	{EventSoftLockup, regexp.MustCompile(`rcu: INFO: rcu_\w+ (?:self-)?detected stalls? on CPUs?`), 15},
+	{EventHardLockup, regexp.MustCompile(`Watchdog detected hard LOCKUP on cpu (?P<cpu>\d+)`), 15},
+	{EventFSError, regexp.MustCompile(`(?P<fs>EXT[234]-fs) error \(device (?P<device>[^)]+)\)`), 0},
+	{EventFSError, regexp.MustCompile(`(?P<fs>XFS) \((?P<device>[^)]+)\): .*(?:[Cc]orruption|[Ss]hutdown|[Ss]hut(?:ting)? down|metadata I/O error)`), 0},
+	{EventFSError, regexp.MustCompile(`(?P<fs>BTRFS) (?:error|critical) \(device (?P<device>[^)\s]+)`), 0},
+	{EventFSError, regexp.MustCompile(`(?P<fs>[\w\-]+) \((?P<device>[^)]+)\): [Rr]emounting filesystem read-only`), 0},
+	{EventIOError, regexp.MustCompile(`I/O error, dev (?P<device>[\w\-]+), sector (?P<sector>\d+)`), 0},
+	{EventIOError, regexp.MustCompile(`Buffer I/O error on dev(?:ice)? (?P<device>[\w\-]+)`), 0},
+	{EventSegfault, regexp.MustCompile(`(?P<comm>\S+)\[(?P<pid>\d+)\]: segfault at (?P<address>[0-9a-f]+) ip \S+ sp \S+ error (?P<error>\d+)(?: in (?P<object>\S+?)\[)?`), 1},
+	{EventSegfault, regexp.MustCompile(`traps: (?P<comm>\S+)\[(?P<pid>\d+)\] (?P<trap>general protection fault|trap invalid opcode|trap divide error)`), 0},
+	{EventHardwareError, regexp.MustCompile(`mce: \[Hardware Error\]|Machine check events logged`), 10},
+	{EventHardwareError, regexp.MustCompile(`EDAC (?P<controller>MC\d+): (?P<count>\d+) (?P<type>CE|UE)`), 0},
+	{EventHardwareError, regexp.MustCompile(`\[Hardware Error\]: .*(?:severity|error_type)`), 10},
+	{EventHardwareError, regexp.MustCompile(`PCIe Bus Error: severity=(?P<severity>\w+)`), 5},
+}
+
+var (
+	// the uptime prefix of the kernel messages written to kern.log
+	uptimePrefixRegexp = regexp.MustCompile(`^\[\s*\d+\.\d+\]\s?`)
+	// the oom-kill summary line preceding the killed process, key=value pairs
+	oomSummaryRegexp = regexp.MustCompile(`oom-kill:(\S+)`)
+)
+
+// pending is an event waiting for its trailing lines
+type pending struct {
+	event    Event
+	after    int
+	deadline time.Time
+}
+
+// Detector recognizes the events in a stream of kernel messages
+type Detector struct {
+	recent  []string
+	pending []*pending
+}
+
+func NewDetector() *Detector {
+	return &Detector{}
+}
+
+// Feed adds a kernel message, it returns the events completed by the message
+func (d *Detector) Feed(record logparse.Record, now time.Time) []Event {
+	text := messageText(record)
+	line := text
+	if !record.Time.IsZero() {
+		line = record.Time.Format(time.RFC3339) + " " + text
+	}
+
+	rule, match := matchRule(text)
+	var events []Event
+	kept := d.pending[:0]
+	for _, p := range d.pending {
+		// The trailing lines of an event stop at the next event.
+		if match != nil {
+			events = append(events, p.event)
+			continue
+		}
+		p.event.Context = append(p.event.Context, line)
+		if p.after--; p.after <= 0 {
+			events = append(events, p.event)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	d.pending = kept
+
+	if match != nil {
+		event := Event{
+			Kind:    rule.kind,
+			Time:    record.Time,
+			Message: text,
+			Fields:  make(map[string]string),
+			Context: append(append([]string{}, d.recent...), line),
+		}
+		if event.Time.IsZero() {
+			event.Time = now
+		}
+		for i, name := range rule.regexp.SubexpNames() {
+			if name != "" && match[i] != "" {
+				event.Fields[name] = match[i]
+			}
+		}
+		if rule.kind == EventOOMKill {
+			d.addOOMSummary(&event)
+		}
+		if rule.after == 0 {
+			events = append(events, event)
+		} else {
+			d.pending = append(d.pending, &pending{event: event, after: rule.after, deadline: now.Add(trailingTimeout)})
+		}
+	}
+
+	d.recent = append(d.recent, line)
+	if len(d.recent) > contextBefore {
+		d.recent = d.recent[len(d.recent)-contextBefore:]
+	}
+	return events
+}
+
+// matchRule returns the first rule recognizing the message and its submatches
+func matchRule(text string) (rule, []string) {
+	for _, rule := range rules {
+		if match := rule.regexp.FindStringSubmatch(text); match != nil {
+			return rule, match
+		}
+	}
+	return rule{}, nil
+}
+
+// Flush returns the pending events whose trailing lines did not come in time
+func (d *Detector) Flush(now time.Time) []Event {
+	var events []Event
+	kept := d.pending[:0]
+	for _, p := range d.pending {
+		if now.Before(p.deadline) {
+			kept = append(kept, p)
+			continue
+		}
+		events = append(events, p.event)
+	}
+	d.pending = kept
+	return events
+}
+
+// addOOMSummary adds the fields of the oom-kill line of the report, like the
+// cgroup of the killed task.
+func (d *Detector) addOOMSummary(event *Event) {
+	for i := len(d.recent) - 1; i >= 0; i-- {
+		match := oomSummaryRegexp.FindStringSubmatch(d.recent[i])
+		if match == nil {
+			continue
+		}
+		for _, pair := range strings.Split(match[1], ",") {
+			if key, value, ok := strings.Cut(pair, "="); ok && value != "" {
+				if _, known := event.Fields[key]; !known {
+					event.Fields[key] = value
+				}
+			}
+		}
+		return
+	}
+}
+
+// messageText returns the kernel message with its subsystem prefix
+func messageText(record logparse.Record) string {
+	text := uptimePrefixRegexp.ReplaceAllString(record.Message, "")
+	if record.Format == logparse.FormatDmesg && record.Source != "" && record.Source != "kernel" {
+		text = record.Source + ": " + text
+	}
+	return text
+}
@@ -0,0 +1,233 @@
+package kernel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+)
+
+var testNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// detect feeds the dmesg lines to a new detector and returns all its events,
+// the events waiting for their trailing lines included.
+func detect(lines ...string) []Event {
+	parser := logparse.NewParser(logparse.FormatDmesg)
+	parser.BootTime = testNow.Add(-time.Hour)
+	detector := NewDetector()
+	var events []Event
+	for _, line := range lines {
+		events = append(events, detector.Feed(parser.Parse(line), testNow)...)
+	}
+	return append(events, detector.Flush(testNow.Add(trailingTimeout))...)
+}
+
+func TestDetector(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		kind   string
+		fields map[string]string
+	}{
+		{
+			name: "oom killer",
+			lines: []string{
+				"[ 3521.120101] java invoked oom-killer: gfp_mask=0x140cca(GFP_HIGHUSER_MOVABLE|__GFP_COMP), order=0, oom_score_adj=0",
+				"[ 3521.120311] oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=/,mems_allowed=0,oom_memcg=/system.slice/app.service,task_memcg=/system.slice/app.service,task=java,pid=1234,uid=1000",
+				"[ 3521.120398] Out of memory: Killed process 1234 (java) total-vm:8123456kB, anon-rss:4012345kB, file-rss:0kB, shmem-rss:0kB, UID:1000 pgtables:9000kB oom_score_adj:0",
+			},
+			kind:   EventOOMKill,
+			fields: map[string]string{"pid": "1234", "comm": "java", "oom_memcg": "/system.slice/app.service", "task_memcg": "/system.slice/app.service", "uid": "1000"},
+		},
+		{
+			name:   "cgroup oom killer",
+			lines:  []string{"[ 3600.000001] Memory cgroup out of memory: Killed process 4321 (node) total-vm:1234kB, anon-rss:1000kB"},
+			kind:   EventOOMKill,
+			fields: map[string]string{"pid": "4321", "comm": "node"},
+		},
+		{
+			name: "hung task",
+			lines: []string{
+				"[ 4920.112233] INFO: task jbd2/sda1-8:312 blocked for more than 120 seconds.",
+				"[ 4920.112240]       Not tainted 6.1.0-18-amd64 #1 Debian 6.1.76-1",
+			},
+			kind:   EventHungTask,
+			fields: map[string]string{"comm": "jbd2/sda1-8", "pid": "312", "seconds": "120"},
+		},
+		{
+			name:   "soft lockup",
+			lines:  []string{"[ 5000.000001] watchdog: BUG: soft lockup - CPU#3 stuck for 23s! [kworker/3:1:1234]"},
+			kind:   EventSoftLockup,
+			fields: map[string]string{"cpu": "3", "seconds": "23", "comm": "kworker/3:1", "pid": "1234"},
+		},
+		{
+			name:  "rcu stall",
+			lines: []string{"[ 5100.000001] rcu: INFO: rcu_sched self-detected stall on CPU"},
+			kind:  EventSoftLockup,
+		},
+		{
+			name:   "hard lockup",
+			lines:  []string{"[ 5200.000001] NMI watchdog: Watchdog detected hard LOCKUP on cpu 2"},
+			kind:   EventHardLockup,
+			fields: map[string]string{"cpu": "2"},
+		},
+		{
+			name:   "ext4 error",
+			lines:  []string{"[ 6000.000001] EXT4-fs error (device sda1): ext4_find_entry:1455: inode #2: comm ls: reading directory lblock 0"},
+			kind:   EventFSError,
+			fields: map[string]string{"fs": "EXT4-fs", "device": "sda1"},
+		},
+		{
+			name:   "ext4 remounted read-only",
+			lines:  []string{"[ 6000.000002] EXT4-fs (sda1): Remounting filesystem read-only"},
+			kind:   EventFSError,
+			fields: map[string]string{"fs": "EXT4-fs", "device": "sda1"},
+		},
+		{
+			name:   "xfs corruption",
+			lines:  []string{"[ 6100.000001] XFS (dm-0): Corruption detected. Unmount and run xfs_repair"},
+			kind:   EventFSError,
+			fields: map[string]string{"fs": "XFS", "device": "dm-0"},
+		},
+		{
+			name:   "xfs metadata io error",
+			lines:  []string{`[ 6100.000002] XFS (dm-0): metadata I/O error in "xfs_trans_read_buf_map" at daddr 0x2 len 1 error 5`},
+			kind:   EventFSError,
+			fields: map[string]string{"fs": "XFS", "device": "dm-0"},
+		},
+		{
+			name:   "xfs shut down",
+			lines:  []string{"[ 6100.000003] XFS (sdb1): Log I/O Error Detected. Shutting down filesystem"},
+			kind:   EventFSError,
+			fields: map[string]string{"fs": "XFS", "device": "sdb1"},
+		},
+		{
+			name:   "xfs has been shut down",
+			lines:  []string{"[ 6100.000004] XFS (sdb1): Filesystem has been shut down due to log error (0x2)."},
+			kind:   EventFSError,
+			fields: map[string]string{"fs": "XFS", "device": "sdb1"},
+		},
+		{
+			name:   "block io error",
+			lines:  []string{"[ 7000.000001] blk_update_request: I/O error, dev sdb, sector 2048 op 0x0:(READ) flags 0x0 phys_seg 1 prio class 0"},
+			kind:   EventIOError,
+			fields: map[string]string{"device": "sdb", "sector": "2048"},
+		},
+		{
+			name:   "buffer io error",
+			lines:  []string{"[ 7000.000002] Buffer I/O error on dev sdb1, logical block 0, async page read"},
+			kind:   EventIOError,
+			fields: map[string]string{"device": "sdb1"},
+		},
+		{
+			name: "segfault",
+			lines: []string{
+				"[ 8000.000001] nginx[2345]: segfault at 0 ip 00007f3c2a1b2c3d sp 00007ffd1e2f3a40 error 4 in libc.so.6[7f3c2a000000+195000]",
+				"[ 8000.000002] Code: 48 8b 07 c3 0f 1f 40 00",
+			},
+			kind:   EventSegfault,
+			fields: map[string]string{"comm": "nginx", "pid": "2345", "address": "0", "error": "4", "object": "libc.so.6"},
+		},
+		{
+			name:   "general protection fault",
+			lines:  []string{"[ 8000.000003] traps: python3[999] general protection fault ip:7f0000001000 sp:7ffc00002000 error:0 in libfoo.so[7f0000000000+2000]"},
+			kind:   EventSegfault,
+			fields: map[string]string{"comm": "python3", "pid": "999", "trap": "general protection fault"},
+		},
+		{
+			name:  "machine check",
+			lines: []string{"[ 9000.000001] mce: [Hardware Error]: Machine check events logged"},
+			kind:  EventHardwareError,
+		},
+		{
+			name:   "corrected memory error",
+			lines:  []string{"[ 9000.000002] EDAC MC0: 1 CE memory read error on CPU_SrcID#0_Ha#0_Chan#1_DIMM#0 (channel:1 slot:0 page:0x12345)"},
+			kind:   EventHardwareError,
+			fields: map[string]string{"controller": "MC0", "count": "1", "type": "CE"},
+		},
+		{
+			name:   "pcie error",
+			lines:  []string{"[ 9000.000003] pcieport 0000:00:1c.0: AER: PCIe Bus Error: severity=Corrected, type=Physical Layer, (Receiver ID)"},
+			kind:   EventHardwareError,
+			fields: map[string]string{"severity": "Corrected"},
+		},
+		{
+			name: "usual messages",
+			lines: []string{
+				"[    1.000001] usb 1-1: new high-speed USB device number 2 using xhci_hcd",
+				"[    2.000001] e1000e 0000:00:1f.6 eth0: NIC Link is Up 1000 Mbps Full Duplex",
+				"[    3.000001] EXT4-fs (sda1): mounted filesystem with ordered data mode. Quota mode: none.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := detect(tt.lines...)
+			if tt.kind == "" {
+				assert.Empty(t, events)
+				return
+			}
+			if !assert.Len(t, events, 1) {
+				return
+			}
+			event := events[0]
+			assert.Equal(t, tt.kind, event.Kind)
+			for name, value := range tt.fields {
+				assert.Equal(t, value, event.Fields[name], name)
+			}
+			assert.NotEmpty(t, event.Context)
+			assert.False(t, event.Time.IsZero())
+		})
+	}
+}
+
+func TestDetectorContext(t *testing.T) {
+	// the trailing lines of a hung task stop at the next event
+	events := detect(
+		"[ 100.000001] INFO: task postgres:812 blocked for more than 240 seconds.",
+		"[ 100.000002] Call Trace:",
+		"[ 100.000003]  __schedule+0x2d1/0x830",
+		"[ 100.000004] Buffer I/O error on dev sdc, logical block 8, lost async page write",
+	)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, EventHungTask, events[0].Kind)
+		assert.Len(t, events[0].Context, 3)
+		assert.Contains(t, events[0].Context[1], "Call Trace:")
+		assert.Equal(t, EventIOError, events[1].Kind)
+		// the previous lines are the context of an event
+		assert.Len(t, events[1].Context, 4)
+	}
+
+	// the time of the event is the time of the message
+	events = detect("[ 60.000000] EXT4-fs error (device sda1): ext4_lookup:1700: inode #2: comm ls: deleted inode referenced")
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, testNow.Add(-time.Hour).Add(time.Minute), events[0].Time)
+	}
+}
+
+func TestDetectorFlush(t *testing.T) {
+	parser := logparse.NewParser(logparse.FormatDmesg)
+	detector := NewDetector()
+	events := detector.Feed(parser.Parse("[ 1.0] NMI watchdog: Watchdog detected hard LOCKUP on cpu 0"), testNow)
+	assert.Empty(t, events, "the event waits for its trailing lines")
+	assert.Empty(t, detector.Flush(testNow.Add(trailingTimeout/2)))
+	assert.Len(t, detector.Flush(testNow.Add(trailingTimeout)), 1)
+	assert.Empty(t, detector.Flush(testNow.Add(trailingTimeout)))
+}
+
+func TestEventKey(t *testing.T) {
+	tests := []struct {
+		event Event
+		key   string
+	}{
+		{Event{Kind: EventIOError, Fields: map[string]string{"device": "sdb", "sector": "8"}}, "io_error:sdb"},
+		{Event{Kind: EventSegfault, Fields: map[string]string{"comm": "nginx", "pid": "1"}}, "segfault:nginx"},
+		{Event{Kind: EventHardLockup, Fields: map[string]string{"cpu": "2"}}, "hard_lockup:2"},
+		{Event{Kind: EventHardwareError}, "hardware_error"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.key, tt.event.Key())
+	}
+}
@@ -0,0 +1,178 @@
+package kernel
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+const (
+	// KmsgPath is the device of the kernel ring buffer, reading it needs
+	// root or CAP_SYSLOG when kernel.dmesg_restrict is set
+	KmsgPath = "/dev/kmsg"
+
+	// interval between two reads of a log file at its end
+	pollInterval = time.Second
+	// maximal size of a record of /dev/kmsg
+	maxKmsgRecord = 8192
+)
+
+// Watcher follows the kernel messages written after it started, from
+// /dev/kmsg or from a log file like /var/log/kern.log.
+type Watcher struct {
+	Source string
+	parser *logparse.Parser
+}
+
+// NewWatcher creates a watcher of the source, /dev/kmsg when empty
+func NewWatcher(source string, fs procfs.FS) *Watcher {
+	if source == "" {
+		source = KmsgPath
+	}
+	parser := logparse.NewParser()
+	if source == KmsgPath {
+		parser = logparse.NewParser(logparse.FormatDmesg)
+	}
+	if stat, err := fs.Stat(); err == nil {
+		parser.BootTime = time.Unix(int64(stat.BootTime), 0)
+	}
+	return &Watcher{Source: source, parser: parser}
+}
+
+// Run calls handle with the events of the new kernel messages until the
+// context is done, handle is called from the goroutine of Run.
+func (w *Watcher) Run(ctx context.Context, handle func(Event)) error {
+	lines := make(chan string, 256)
+	errs := make(chan error, 1)
+	go func() {
+		if w.Source == KmsgPath {
+			errs <- readKmsg(ctx, lines)
+		} else {
+			errs <- tailFile(ctx, w.Source, lines)
+		}
+	}()
+
+	detector := NewDetector()
+	ticker := time.NewTicker(trailingTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			return fmt.Errorf("failed to read %s: %w", w.Source, err)
+		case line := <-lines:
+			for _, event := range detector.Feed(w.parser.Parse(line), time.Now()) {
+				handle(event)
+			}
+		case now := <-ticker.C:
+			for _, event := range detector.Flush(now) {
+				handle(event)
+			}
+		}
+	}
+}
+
+// readKmsg sends the records written to /dev/kmsg after it was opened, every
+// read returns one record whose continuation lines are dropped.
+func readKmsg(ctx context.Context, lines chan<- string) error {
+	file, err := os.Open(KmsgPath)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	buf := make([]byte, maxKmsgRecord)
+	for {
+		n, err := file.Read(buf)
+		if errors.Is(err, syscall.EPIPE) {
+			// The records were overwritten before they were read.
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		record, _, _ := strings.Cut(string(buf[:n]), "\n")
+		select {
+		case lines <- record:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// tailFile sends the lines appended to the file, the file is reopened when it
+// is rotated or truncated.
+func tailFile(ctx context.Context, path string, lines chan<- string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { file.Close() }()
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	partial := ""
+	for {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+		if err == nil {
+			select {
+			case lines <- strings.TrimRight(partial+line, "\r\n"):
+			case <-ctx.Done():
+				return nil
+			}
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		partial += line
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+		current, err := os.Stat(path)
+		if err != nil {
+			// The file is being rotated, it is reopened once it exists again.
+			continue
+		}
+		opened, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		if !os.SameFile(current, opened) || current.Size() < offset {
+			reopened, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			file.Close()
+			file, offset, partial = reopened, 0, ""
+			reader.Reset(file)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package logmine
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Wildcard replaces the variable tokens of a template
+	Wildcard = "<*>"
+
+	// DefaultDepth is the depth of the parse tree, including the root and the length layer
+	DefaultDepth = 4
+	// DefaultSimilarity is the minimal ratio of equal tokens to join a template
+	DefaultSimilarity = 0.4
+	// DefaultMaxChildren is the maximal number of children of an inner node
+	DefaultMaxChildren = 100
+)
+
+// masks replace the obvious variables before the tokens are compared
+var masks = []*regexp.Regexp{
+	regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`),
+	regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`),
+	regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`),
+	regexp.MustCompile(`\b[0-9a-fA-F]{16,}\b`),
+	regexp.MustCompile(`[+-]?\b\d+(?:\.\d+)?(?:ms|s|us|ns|[kKMGT]i?B?|%)?\b`),
+}
+
+// Cluster is a log template and its statistics
+type Cluster struct {
+	ID       int      `json:"id"`
+	Template []string `json:"template"`
+	// Path are the keys of the tree nodes leading to the cluster
+	Path []string `json:"path"`
+	Stats
+}
+
+// String returns the template as a line
+func (c *Cluster) String() string {
+	return strings.Join(c.Template, " ")
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*Cluster
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node)}
+}
+
+// Drain clusters the log messages into templates with a fixed depth parse
+// tree, the messages of the same length and with the same first tokens are
+// compared with the templates of their leaf only.
+type Drain struct {
+	Depth       int
+	Similarity  float64
+	MaxChildren int
+	root        *node
+	clusters    []*Cluster
+	lastID      int
+}
+
+func NewDrain() *Drain {
+	return &Drain{
+		Depth:       DefaultDepth,
+		Similarity:  DefaultSimilarity,
+		MaxChildren: DefaultMaxChildren,
+		root:        newNode(),
+	}
+}
+
+// Clusters returns the clusters in their order of creation
+func (d *Drain) Clusters() []*Cluster {
+	return d.clusters
+}
+
+// Tokenize masks the variables of the message and splits it into tokens
+func Tokenize(message string) []string {
+	for _, mask := range masks {
+		message = mask.ReplaceAllString(message, Wildcard)
+	}
+	return strings.Fields(message)
+}
+
+// Add returns the cluster of the message, the template of the cluster is
+// generalized or a new cluster is created when no template is similar enough.
+func (d *Drain) Add(message string) (*Cluster, bool) {
+	tokens := Tokenize(message)
+	leaf, path := d.leaf(tokens)
+
+	var best *Cluster
+	bestSimilarity := -1.0
+	bestWildcards := -1
+	for _, cluster := range leaf.clusters {
+		similarity, wildcards := similarity(cluster.Template, tokens)
+		if similarity > bestSimilarity || (similarity == bestSimilarity && wildcards > bestWildcards) {
+			best, bestSimilarity, bestWildcards = cluster, similarity, wildcards
+		}
+	}
+	if best != nil && bestSimilarity >= d.Similarity {
+		for i, token := range tokens {
+			if best.Template[i] != token {
+				best.Template[i] = Wildcard
+			}
+		}
+		return best, false
+	}
+
+	d.lastID++
+	cluster := &Cluster{ID: d.lastID, Template: tokens, Path: path}
+	leaf.clusters = append(leaf.clusters, cluster)
+	d.clusters = append(d.clusters, cluster)
+	return cluster, true
+}
+
+// Restore inserts a cluster loaded from the state at its path
+func (d *Drain) Restore(cluster *Cluster) {
+	current := d.root
+	for _, key := range cluster.Path {
+		current = d.child(current, key)
+	}
+	current.clusters = append(current.clusters, cluster)
+	d.clusters = append(d.clusters, cluster)
+	d.lastID = max(d.lastID, cluster.ID)
+}
+
+// leaf walks down the tree by the length and the first tokens, the missing
+// nodes are created, the keys of the walked nodes are returned as path.
+func (d *Drain) leaf(tokens []string) (*node, []string) {
+	path := []string{strconv.Itoa(len(tokens))}
+	current := d.child(d.root, path[0])
+	for i := 0; i < d.Depth-2 && i < len(tokens); i++ {
+		token := tokens[i]
+		if strings.ContainsAny(token, "0123456789") {
+			token = Wildcard
+		}
+		if _, ok := current.children[token]; !ok && len(current.children) >= d.MaxChildren {
+			token = Wildcard
+		}
+		path = append(path, token)
+		current = d.child(current, token)
+	}
+	return current, path
+}
+
+func (d *Drain) child(parent *node, key string) *node {
+	next, ok := parent.children[key]
+	if !ok {
+		next = newNode()
+		parent.children[key] = next
+	}
+	return next
+}
+
+// similarity returns the ratio of the template tokens equal to the message
+// tokens and the number of wildcards of the template.
+func similarity(template, tokens []string) (float64, int) {
+	if len(tokens) == 0 {
+		return 1, 0
+	}
+	equal, wildcards := 0, 0
+	for i, token := range template {
+		switch {
+		case token == Wildcard:
+			wildcards++
+		case token == tokens[i]:
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(tokens)), wildcards
+}
@@ -0,0 +1,485 @@
+package logmine
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/mathutil"
+)
+
+const (
+	// DefaultSpikeFactor is how many times its usual rate a template must reach to spike
+	DefaultSpikeFactor = 5.0
+	// DefaultMinSpikeCount is the minimal number of lines of a spiking template
+	DefaultMinSpikeCount = 20
+	// DefaultMaxTemplates is the maximal number of templates kept for each source
+	DefaultMaxTemplates = 5000
+	// DefaultLimit is the default number of templates of each list of the report
+	DefaultLimit = 10
+	// DefaultConsumer is the consumer of the scans which do not name one
+	DefaultConsumer = "agent"
+
+	// maximal number of bytes read from a file by one scan, the oldest are skipped
+	maxScanBytes = 32 * 1024 * 1024
+	// templates are forgotten when they are not seen for this long
+	templateRetention = 30 * 24 * time.Hour
+	// scans a template must have been tracked for before it could spike
+	minSpikeScans = 3
+	// templates seen at most this many times in total are rare
+	rareTotal = 3
+	// weight of the last scan in the moving average of the rates
+	rateSmoothing = 0.3
+	// maximal size of the example line of a template
+	maxExampleChars = 300
+)
+
+// Stats are the statistics of a template
+type Stats struct {
+	Total     int64     `json:"total"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Rate is the moving average of the lines per hour over the scans
+	Rate float64 `json:"rate"`
+	// Scans is the number of scans since the template was created
+	Scans   int    `json:"scans"`
+	Level   string `json:"level,omitempty"`
+	Example string `json:"example,omitempty"`
+}
+
+// TemplateReport is a template of the report with its count during the scan
+type TemplateReport struct {
+	Source    string    `json:"source"`
+	Template  string    `json:"template"`
+	Example   string    `json:"example"`
+	Level     string    `json:"level,omitempty"`
+	Count     int       `json:"count"`
+	Total     int64     `json:"total"`
+	Rate      float64   `json:"usual_rate_per_hour,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// Report is what is unusual in the logs written since the previous scan
+type Report struct {
+	Since *time.Time `json:"since,omitempty"`
+	Until time.Time  `json:"until"`
+	Lines int        `json:"lines"`
+	// Templates is the number of templates seen during the scan
+	Templates int `json:"templates"`
+	// Baseline are the sources scanned for the first time, nothing is new for them yet
+	Baseline []string         `json:"baseline,omitempty"`
+	New      []TemplateReport `json:"new"`
+	Spikes   []TemplateReport `json:"spikes"`
+	Rare     []TemplateReport `json:"rare"`
+	Frequent []TemplateReport `json:"frequent"`
+	// Truncated is true if some lines were skipped because too many were written
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type fileOffset struct {
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+type sourceState struct {
+	LastScan time.Time             `json:"last_scan"`
+	Files    map[string]fileOffset `json:"files"`
+	Clusters []*Cluster            `json:"clusters"`
+}
+
+// consumerState is what a consumer has seen of the sources
+type consumerState struct {
+	Sources map[string]*sourceState `json:"sources"`
+}
+
+type state struct {
+	Consumers map[string]*consumerState `json:"consumers"`
+}
+
+// Miner clusters the lines written to the log sources into templates, every
+// scan reads the lines written since the previous scan of the same consumer
+// and compares the templates with their history, each consumer, like a
+// monitor, has its own offsets and history so the scans of one do not hide
+// the lines from the others. The state is persisted between the runs.
+type Miner struct {
+	sources   []config.LogSource
+	settings  config.LogMiningSettings
+	statePath string
+	mu        sync.Mutex
+}
+
+// NewMiner creates a miner of the plain file sources, the journal sources are ignored
+func NewMiner(sources []config.LogSource, settings config.LogMiningSettings, statePath string) *Miner {
+	files := make([]config.LogSource, 0, len(sources))
+	for _, source := range sources {
+		if source.Type == config.LogSourceFile {
+			files = append(files, source)
+		}
+	}
+	if settings.SpikeFactor == 0 {
+		settings.SpikeFactor = DefaultSpikeFactor
+	}
+	if settings.MinSpikeCount == 0 {
+		settings.MinSpikeCount = DefaultMinSpikeCount
+	}
+	if settings.MaxTemplates == 0 {
+		settings.MaxTemplates = DefaultMaxTemplates
+	}
+	return &Miner{sources: files, settings: settings, statePath: statePath}
+}
+
+// Sources returns the log sources mined
+func (m *Miner) Sources() []config.LogSource {
+	return m.sources
+}
+
+// Scan mines the lines written since the previous scan of the consumer of the
+// given sources, all the sources if none is given, each list of the report has
+// at most limit templates. An empty consumer is the default consumer.
+func (m *Miner) Scan(consumer string, names []string, limit int) (*Report, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sources, err := m.selectSources(names)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	st, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	if consumer == "" {
+		consumer = DefaultConsumer
+	}
+	cs, ok := st.Consumers[consumer]
+	if !ok {
+		cs = &consumerState{Sources: map[string]*sourceState{}}
+		st.Consumers[consumer] = cs
+	}
+
+	now := time.Now()
+	report := &Report{Until: now, New: []TemplateReport{}, Spikes: []TemplateReport{}, Rare: []TemplateReport{}, Frequent: []TemplateReport{}}
+	for _, source := range sources {
+		srcState, ok := cs.Sources[source.Name]
+		if !ok {
+			srcState = &sourceState{Files: map[string]fileOffset{}}
+			cs.Sources[source.Name] = srcState
+			report.Baseline = append(report.Baseline, source.Name)
+		}
+		if ok && (report.Since == nil || srcState.LastScan.Before(*report.Since)) {
+			since := srcState.LastScan
+			report.Since = &since
+		}
+		if err := m.scanSource(source, srcState, now, !ok, report); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, list := range []*[]TemplateReport{&report.New, &report.Spikes, &report.Rare, &report.Frequent} {
+		*list = topTemplates(*list, limit)
+	}
+	if err := m.save(st); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (m *Miner) selectSources(names []string) ([]config.LogSource, error) {
+	if len(m.sources) == 0 {
+		return nil, fmt.Errorf("no log file source is configured")
+	}
+	if len(names) == 0 {
+		return m.sources, nil
+	}
+
+	selected := make([]config.LogSource, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, source := range m.sources {
+			if source.Name == name {
+				selected = append(selected, source)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%q is not a log file source", name)
+		}
+	}
+	return selected, nil
+}
+
+func (m *Miner) newDrain() *Drain {
+	drain := NewDrain()
+	if m.settings.Similarity > 0 {
+		drain.Similarity = m.settings.Similarity
+	}
+	if m.settings.Depth > 0 {
+		drain.Depth = m.settings.Depth
+	}
+	return drain
+}
+
+// scanSource reads the new lines of the files of the source and adds the
+// templates to the report, a baseline scan only learns the templates.
+func (m *Miner) scanSource(source config.LogSource, st *sourceState, now time.Time, baseline bool, report *Report) error {
+	drain := m.newDrain()
+	for _, cluster := range st.Clusters {
+		drain.Restore(cluster)
+	}
+
+	files, err := filepath.Glob(source.Path)
+	if err != nil {
+		return err
+	}
+	counts := make(map[*Cluster]int)
+	created := make(map[*Cluster]bool)
+	offsets := make(map[string]fileOffset, len(files))
+	for _, file := range files {
+		offset, truncated, err := m.scanFile(file, st.Files[file], baseline, drain, func(cluster *Cluster, isNew bool, record logparse.Record, line string) {
+			report.Lines++
+			counts[cluster]++
+			if isNew {
+				created[cluster] = true
+			}
+			seen := record.Time
+			if seen.IsZero() {
+				seen = now
+			}
+			if cluster.FirstSeen.IsZero() || seen.Before(cluster.FirstSeen) {
+				cluster.FirstSeen = seen
+			}
+			if seen.After(cluster.LastSeen) {
+				cluster.LastSeen = seen
+			}
+			cluster.Total++
+			if cluster.Example == "" || logparse.LevelRank(record.Level) >= logparse.LevelRank(cluster.Level) {
+				cluster.Level = record.Level
+				cluster.Example = truncateLine(line)
+			}
+		})
+		if err != nil {
+			// The file is read from its previous offset by the next scan.
+			if previous, ok := st.Files[file]; ok {
+				offsets[file] = previous
+			}
+			continue
+		}
+		report.Truncated = report.Truncated || truncated
+		offsets[file] = offset
+	}
+
+	// Rates are per hour since the previous scan, a baseline scan has no reference.
+	hours := now.Sub(st.LastScan).Hours()
+	for _, cluster := range drain.Clusters() {
+		count := counts[cluster]
+		if count > 0 {
+			report.Templates++
+		}
+		// The period of the lines of a baseline scan is unknown, the rates start with the next scan.
+		if baseline || hours <= 0 {
+			if count > 0 {
+				report.Frequent = append(report.Frequent, templateReport(source.Name, cluster, count))
+			}
+			continue
+		}
+
+		rate := float64(count) / hours
+		entry := templateReport(source.Name, cluster, count)
+		switch {
+		case count == 0:
+		case created[cluster]:
+			report.New = append(report.New, entry)
+		case cluster.Scans >= minSpikeScans && count >= m.settings.MinSpikeCount && rate >= m.settings.SpikeFactor*cluster.Rate:
+			report.Spikes = append(report.Spikes, entry)
+		case cluster.Total <= rareTotal:
+			report.Rare = append(report.Rare, entry)
+		default:
+			report.Frequent = append(report.Frequent, entry)
+		}
+		if cluster.Scans == 0 {
+			cluster.Rate = rate
+		} else {
+			cluster.Rate = rateSmoothing*rate + (1-rateSmoothing)*cluster.Rate
+		}
+		cluster.Scans++
+	}
+
+	st.LastScan = now
+	st.Files = offsets
+	st.Clusters = m.retain(drain.Clusters(), now)
+	return nil
+}
+
+// retain forgets the templates not seen for a long time, then the least
+// recently seen ones over the maximal number of templates.
+func (m *Miner) retain(clusters []*Cluster, now time.Time) []*Cluster {
+	kept := make([]*Cluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		if now.Sub(cluster.LastSeen) <= templateRetention {
+			kept = append(kept, cluster)
+		}
+	}
+	if len(kept) > m.settings.MaxTemplates {
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].LastSeen.After(kept[j].LastSeen)
+		})
+		kept = kept[:m.settings.MaxTemplates]
+	}
+	return kept
+}
+
+// scanFile reads the complete lines written after the previous offset, a file
+// with another inode or smaller than the offset was rotated and is read from
+// its start. The first scan of a source only reads the end of the file.
+func (m *Miner) scanFile(path string, previous fileOffset, baseline bool, drain *Drain, fn func(*Cluster, bool, logparse.Record, string)) (fileOffset, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return previous, false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return previous, false, fmt.Errorf("%s is not a regular file", path)
+	}
+	current := fileOffset{}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		current.Inode = stat.Ino
+	}
+
+	start, resumed := int64(0), false
+	switch {
+	case baseline:
+		start = max(0, info.Size()-maxScanBytes)
+	case previous.Inode == current.Inode && previous.Offset <= info.Size():
+		start, resumed = previous.Offset, true
+	}
+	truncated := false
+	if !baseline && info.Size()-start > maxScanBytes {
+		start, resumed, truncated = info.Size()-maxScanBytes, false, true
+	}
+	// A scan not resuming at a previous offset may start in the middle of a line.
+	skipFirst := start > 0 && !resumed
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		return previous, false, err
+	}
+
+	parser := logparse.NewParser()
+	parser.Reference = info.ModTime()
+	reader := bufio.NewReaderSize(file, 64*1024)
+	current.Offset = start
+	for {
+		data, err := reader.ReadBytes('\n')
+		if err != nil {
+			// An incomplete last line is read again by the next scan.
+			break
+		}
+		current.Offset += int64(len(data))
+		if skipFirst {
+			skipFirst = false
+			continue
+		}
+		line := string(data[:len(data)-1])
+		if line == "" {
+			continue
+		}
+		record := parser.Parse(line)
+		cluster, isNew := drain.Add(record.Message)
+		fn(cluster, isNew, record, line)
+	}
+	return current, truncated, nil
+}
+
+func templateReport(source string, cluster *Cluster, count int) TemplateReport {
+	return TemplateReport{
+		Source:    source,
+		Template:  cluster.String(),
+		Example:   cluster.Example,
+		Level:     cluster.Level,
+		Count:     count,
+		Total:     cluster.Total,
+		Rate:      mathutil.Round2(cluster.Rate),
+		FirstSeen: cluster.FirstSeen,
+	}
+}
+
+// topTemplates keeps the most severe then the most frequent templates
+func topTemplates(templates []TemplateReport, limit int) []TemplateReport {
+	sort.SliceStable(templates, func(i, j int) bool {
+		a, b := templates[i], templates[j]
+		if logparse.LevelRank(a.Level) != logparse.LevelRank(b.Level) {
+			return logparse.LevelRank(a.Level) > logparse.LevelRank(b.Level)
+		}
+		return a.Count > b.Count
+	})
+	if len(templates) > limit {
+		templates = templates[:limit]
+	}
+	return templates
+}
+
+func (m *Miner) load() (*state, error) {
+	st := &state{Consumers: map[string]*consumerState{}}
+	data, err := os.ReadFile(m.statePath)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log patterns state: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse log patterns state %s: %w", m.statePath, err)
+	}
+	if st.Consumers == nil {
+		st.Consumers = map[string]*consumerState{}
+	}
+	return st, nil
+}
+
+// save writes the state, the file is replaced atomically
+func (m *Miner) save(st *state) error {
+	dir := filepath.Dir(m.statePath)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create log patterns directory: %w", err)
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode log patterns state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(m.statePath)+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save log patterns state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save log patterns state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save log patterns state: %w", err)
+	}
+	return os.Rename(tmp.Name(), m.statePath)
+}
+
+func truncateLine(line string) string {
+	if len(line) <= maxExampleChars {
+		return line
+	}
+	return strings.ToValidUTF8(line[:maxExampleChars], "") + "..."
+}
@@ -0,0 +1,99 @@
+package logmine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	defer file.Close()
+	for _, line := range lines {
+		_, err := fmt.Fprintln(file, line)
+		assert.NoError(t, err)
+	}
+}
+
+func newTestMiner(t *testing.T) (*Miner, string) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	appendLines(t, logPath,
+		"worker 1 started",
+		"worker 2 started",
+		"request served in 12 ms",
+		"request served in 15 ms",
+	)
+	sources := []config.LogSource{{Name: "app", Path: logPath, Type: config.LogSourceFile}}
+	return NewMiner(sources, config.LogMiningSettings{}, filepath.Join(dir, "state", "log_patterns.json")), logPath
+}
+
+func templates(reports []TemplateReport) []string {
+	names := make([]string, 0, len(reports))
+	for _, report := range reports {
+		names = append(names, report.Template)
+	}
+	return names
+}
+
+func TestScanConsumers(t *testing.T) {
+	miner, logPath := newTestMiner(t)
+
+	for _, consumer := range []string{"disk", "errors"} {
+		report, err := miner.Scan(consumer, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"app"}, report.Baseline, consumer)
+		assert.Equal(t, 4, report.Lines, consumer)
+	}
+
+	appendLines(t, logPath, "disk sda1 is full")
+
+	// every consumer sees the lines written since its own previous scan
+	for _, consumer := range []string{"disk", "errors"} {
+		report, err := miner.Scan(consumer, nil, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, report.Baseline, consumer)
+		assert.Equal(t, 1, report.Lines, consumer)
+		assert.Equal(t, []string{"disk sda1 is full"}, templates(report.New), consumer)
+	}
+
+	report, err := miner.Scan("disk", nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Lines)
+
+	// a consumer scanning for the first time starts with a baseline
+	report, err = miner.Scan("", []string{"app"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app"}, report.Baseline)
+	assert.Equal(t, 5, report.Lines)
+
+	_, err = miner.Scan("disk", []string{"unknown"}, 0)
+	assert.Error(t, err)
+}
+
+func TestScanKeepsOffsetOfFailedFile(t *testing.T) {
+	miner, logPath := newTestMiner(t)
+	_, err := miner.Scan("disk", nil, 0)
+	assert.NoError(t, err)
+	st, err := miner.load()
+	assert.NoError(t, err)
+	previous := st.Consumers["disk"].Sources["app"].Files[logPath]
+	assert.NotZero(t, previous.Offset)
+
+	// the path cannot be read while it is replaced by a directory
+	assert.NoError(t, os.Remove(logPath))
+	assert.NoError(t, os.Mkdir(logPath, 0755))
+	report, err := miner.Scan("disk", nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Lines)
+
+	st, err = miner.load()
+	assert.NoError(t, err)
+	assert.Equal(t, previous, st.Consumers["disk"].Sources["app"].Files[logPath])
+}
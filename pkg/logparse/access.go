@@ -0,0 +1,81 @@
+package logparse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// The common and combined log formats of nginx and apache, nginx configs often
+	// append the request time or key=value pairs like rt=0.012 to the line.
+	accessRegexp      = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\] "((?:[^"\\]|\\.)*)" (\d{3}) (\d+|-)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?(.*)$`)
+	accessExtraRegexp = regexp.MustCompile(`([A-Za-z_][\w.]*)=("(?:[^"\\]|\\.)*"|\S+)|"((?:[^"\\]|\\.)*)"|(\S+)`)
+)
+
+func parseAccess(p *Parser, line string) (Record, bool) {
+	match := accessRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return Record{}, false
+	}
+	t, err := time.Parse("02/Jan/2006:15:04:05 -0700", match[4])
+	if err != nil {
+		return Record{}, false
+	}
+
+	record := Record{Time: t, Source: "access", Message: match[5]}
+	record.setField("remote_addr", match[1])
+	record.setField("ident", match[2])
+	record.setField("remote_user", match[3])
+	if method, rest, ok := strings.Cut(match[5], " "); ok {
+		record.setField("method", method)
+		path, protocol, _ := strings.Cut(rest, " ")
+		record.setField("path", path)
+		record.setField("protocol", protocol)
+	}
+	record.setField("status", match[6])
+	record.setField("bytes", match[7])
+	record.setField("referer", match[8])
+	record.setField("user_agent", match[9])
+	parseAccessExtra(&record, match[10])
+
+	status, _ := strconv.Atoi(match[6])
+	switch {
+	case status >= 500:
+		record.Level = LevelError
+	case status >= 400:
+		record.Level = LevelWarning
+	default:
+		record.Level = LevelInfo
+	}
+	return record, true
+}
+
+// parseAccessExtra parses the values appended to the combined format, a
+// bare number is the request time in seconds.
+func parseAccessExtra(record *Record, extra string) {
+	position := 0
+	for _, match := range accessExtraRegexp.FindAllStringSubmatch(extra, -1) {
+		switch {
+		case match[1] != "":
+			value := strings.Trim(match[2], `"`)
+			record.setField(match[1], value)
+			if match[1] == "rt" {
+				record.setField("request_time", value)
+			}
+		case match[4] != "":
+			if _, err := strconv.ParseFloat(match[4], 64); err == nil {
+				if _, ok := record.Fields["request_time"]; !ok {
+					record.setField("request_time", match[4])
+					continue
+				}
+			}
+			position++
+			record.setField("extra"+strconv.Itoa(position), match[4])
+		default:
+			position++
+			record.setField("extra"+strconv.Itoa(position), match[3])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package logparse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// [  123.456789] message, optionally prefixed by the <level> of dmesg -r
+	dmesgUptimeRegexp = regexp.MustCompile(`^\[\s*(\d+\.\d+)\]\s?(.*)$`)
+	dmesgRawRegexp    = regexp.MustCompile(`^<(\d)>(.*)$`)
+	// [Mon Jan  2 15:04:05 2006] message of dmesg -T
+	dmesgHumanRegexp = regexp.MustCompile(`^\[([A-Z][a-z]{2} [A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2} \d{4})\]\s?(.*)$`)
+	// level,sequence,microseconds,flags[,...];message of /dev/kmsg
+	kmsgRegexp = regexp.MustCompile(`^(\d+),(\d+),(\d+),[^;]*;(.*)$`)
+	// the subsystem or driver prefix of a kernel message, like "e1000e 0000:00:19.0: " or "EXT4-fs (sda1): "
+	kernelSourceRegexp = regexp.MustCompile(`^([\w.\-]+(?: [\w.:\-]+| \([\w.\-]+\))?): `)
+)
+
+func parseDmesg(p *Parser, line string) (Record, bool) {
+	record := Record{Source: "kernel"}
+	rest := line
+	if match := dmesgRawRegexp.FindStringSubmatch(line); match != nil {
+		level, _ := strconv.Atoi(match[1])
+		record.Level = SyslogLevel(level)
+		rest = match[2]
+	}
+
+	switch match := kmsgRegexp.FindStringSubmatch(rest); {
+	case match != nil:
+		priority, _ := strconv.Atoi(match[1])
+		record.Level = SyslogLevel(priority % 8)
+		record.setField("seq", match[2])
+		usec, _ := strconv.ParseInt(match[3], 10, 64)
+		p.setUptime(&record, time.Duration(usec)*time.Microsecond, strconv.FormatFloat(float64(usec)/1e6, 'f', 6, 64))
+		rest = match[4]
+	default:
+		if uptime := dmesgUptimeRegexp.FindStringSubmatch(rest); uptime != nil {
+			seconds, _ := strconv.ParseFloat(uptime[1], 64)
+			p.setUptime(&record, time.Duration(seconds*float64(time.Second)), uptime[1])
+			rest = uptime[2]
+		} else if human := dmesgHumanRegexp.FindStringSubmatch(rest); human != nil {
+			t, err := time.ParseInLocation("Mon Jan _2 15:04:05 2006", human[1], time.Local)
+			if err != nil {
+				return Record{}, false
+			}
+			record.Time = t
+			rest = human[2]
+		} else {
+			return Record{}, false
+		}
+	}
+
+	record.Message = rest
+	if match := kernelSourceRegexp.FindStringSubmatch(rest); match != nil {
+		record.Source = match[1]
+		record.Message = strings.TrimPrefix(rest, match[0])
+	}
+	if record.Level == "" {
+		record.Level = guessLevel(record.Message)
+	}
+	return record, true
+}
+
+// setUptime keeps the monotonic timestamp of a kernel message, it is converted
+// to a time when the boot time is known.
+func (p *Parser) setUptime(record *Record, uptime time.Duration, value string) {
+	record.setField("uptime", value)
+	if !p.BootTime.IsZero() {
+		record.Time = p.BootTime.Add(uptime)
+	}
+}
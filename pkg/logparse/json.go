@@ -0,0 +1,153 @@
+package logparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	jsonTimeKeys    = []string{"time", "timestamp", "@timestamp", "ts", "t", "datetime", "date"}
+	jsonLevelKeys   = []string{"level", "lvl", "severity", "log.level", "loglevel", "levelname"}
+	jsonMessageKeys = []string{"msg", "message", "log", "@message", "text"}
+	jsonSourceKeys  = []string{"logger", "source", "service", "app", "name", "component", "caller"}
+)
+
+func parseJSON(p *Parser, line string) (Record, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return Record{}, false
+	}
+	object := map[string]any{}
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return Record{}, false
+	}
+
+	fields := make(map[string]string, len(object))
+	flatten(fields, "", object)
+
+	record := Record{}
+	if key, value := takeField(fields, jsonTimeKeys); key != "" {
+		record.Time = jsonTime(value)
+		if record.Time.IsZero() {
+			fields[key] = value
+		}
+	}
+	if key, value := takeField(fields, jsonLevelKeys); key != "" {
+		record.Level = jsonLevel(value)
+		if record.Level == "" {
+			fields[key] = value
+		}
+	}
+	_, record.Message = takeField(fields, jsonMessageKeys)
+	_, record.Source = takeField(fields, jsonSourceKeys)
+	if record.Level == "" {
+		record.Level = guessLevel(record.Message)
+	}
+	for key, value := range fields {
+		record.setField(key, value)
+	}
+	return record, true
+}
+
+// flatten converts the values of the object to strings, the keys of the
+// nested objects are joined with a dot.
+func flatten(fields map[string]string, prefix string, object map[string]any) {
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(fields, key, v)
+		case string:
+			fields[key] = v
+		case json.Number:
+			fields[key] = v.String()
+		case nil:
+		case []any:
+			data, _ := json.Marshal(v)
+			fields[key] = string(data)
+		default:
+			fields[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// takeField removes and returns the first of the keys present in the fields,
+// the keys are compared case insensitively.
+func takeField(fields map[string]string, keys []string) (string, string) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, key := range keys {
+		for _, name := range names {
+			if strings.EqualFold(name, key) {
+				value := fields[name]
+				delete(fields, name)
+				return name, value
+			}
+		}
+	}
+	return "", ""
+}
+
+// jsonTime parses RFC 3339 timestamps and unix timestamps in seconds, milliseconds or nanoseconds
+func jsonTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700", "2006-01-02 15:04:05.999999999Z07:00"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999", "2006-01-02 15:04:05,999"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return time.Time{}
+	}
+	switch {
+	case number > 1e17:
+		return time.Unix(0, int64(number))
+	case number > 1e11:
+		return time.UnixMilli(int64(number))
+	default:
+		seconds, fraction := math.Modf(number)
+		return time.Unix(int64(seconds), int64(fraction*1e9))
+	}
+}
+
+// jsonLevel normalizes the level names and the numeric levels of bunyan and pino
+func jsonLevel(value string) string {
+	if level := NormalizeLevel(value); level != "" {
+		return level
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case number >= 60:
+		return LevelCritical
+	case number >= 50:
+		return LevelError
+	case number >= 40:
+		return LevelWarning
+	case number >= 30:
+		return LevelInfo
+	case number >= 10:
+		return LevelDebug
+	}
+	return ""
+}
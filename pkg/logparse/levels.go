@@ -0,0 +1,73 @@
+package logparse
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	LevelDebug    = "debug"
+	LevelInfo     = "info"
+	LevelNotice   = "notice"
+	LevelWarning  = "warning"
+	LevelError    = "error"
+	LevelCritical = "critical"
+	LevelAlert    = "alert"
+	LevelEmerg    = "emerg"
+)
+
+// SyslogLevels are the levels indexed by the syslog severity
+var SyslogLevels = []string{LevelEmerg, LevelAlert, LevelCritical, LevelError, LevelWarning, LevelNotice, LevelInfo, LevelDebug}
+
+// LevelRank returns the rank of the level, the higher the more severe, -1 for unknown levels
+func LevelRank(level string) int {
+	normalized := NormalizeLevel(level)
+	for i, l := range SyslogLevels {
+		if l == normalized {
+			return len(SyslogLevels) - 1 - i
+		}
+	}
+	return -1
+}
+
+// NormalizeLevel maps the usual level names to the Level constants, an empty
+// string is returned for unknown names.
+func NormalizeLevel(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug", "trace", "dbug":
+		return LevelDebug
+	case "info", "information", "informational":
+		return LevelInfo
+	case "notice":
+		return LevelNotice
+	case "warn", "warning":
+		return LevelWarning
+	case "err", "error", "eror":
+		return LevelError
+	case "crit", "critical", "fatal":
+		return LevelCritical
+	case "alert":
+		return LevelAlert
+	case "emerg", "emergency", "panic":
+		return LevelEmerg
+	}
+	return ""
+}
+
+// SyslogLevel returns the level of a syslog severity or kernel log level, empty if out of range
+func SyslogLevel(severity int) string {
+	if severity < 0 || severity >= len(SyslogLevels) {
+		return ""
+	}
+	return SyslogLevels[severity]
+}
+
+var levelKeywordRegexp = regexp.MustCompile(`(?i)\b(emerg|emergency|panic|alert|crit|critical|fatal|err|error|warn|warning|notice|info|debug)\b`)
+
+// guessLevel guesses the level of a free text message from its first level keyword
+func guessLevel(message string) string {
+	if len(message) > 256 {
+		message = message[:256]
+	}
+	return NormalizeLevel(levelKeywordRegexp.FindString(message))
+}
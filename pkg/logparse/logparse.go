@@ -0,0 +1,141 @@
+package logparse
+
+import (
+	"time"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatRFC5424 = "rfc5424"
+	FormatRFC3164 = "rfc3164"
+	FormatAccess  = "access"
+	FormatDmesg   = "dmesg"
+	FormatJournal = "journal"
+	// FormatPlain is the format of the lines no parser recognized
+	FormatPlain = "plain"
+)
+
+// Record is a structured log line
+type Record struct {
+	// Time is zero when the line has no usable timestamp
+	Time time.Time `json:"time,omitempty"`
+	// Level is one of the Level constants, empty when unknown
+	Level string `json:"level,omitempty"`
+	// Source is the program, logger or kernel subsystem which wrote the line
+	Source  string `json:"source,omitempty"`
+	Message string `json:"message"`
+	// Fields are the other values of the line, like the status of an access log
+	Fields map[string]string `json:"fields,omitempty"`
+	Format string            `json:"format"`
+}
+
+// Field returns the value of the field, the time, level, source and message
+// of the record are fields as well.
+func (r Record) Field(name string) (string, bool) {
+	switch name {
+	case "level":
+		return r.Level, r.Level != ""
+	case "source":
+		return r.Source, r.Source != ""
+	case "message":
+		return r.Message, true
+	case "time":
+		return r.Time.Format(time.RFC3339Nano), !r.Time.IsZero()
+	}
+	value, ok := r.Fields[name]
+	return value, ok
+}
+
+func (r *Record) setField(name, value string) {
+	if value == "" || value == "-" {
+		return
+	}
+	if r.Fields == nil {
+		r.Fields = make(map[string]string)
+	}
+	r.Fields[name] = value
+}
+
+// Parser turns log lines into records, the formats are tried in order and the
+// lines no format recognizes are returned as plain records.
+type Parser struct {
+	// Reference completes the timestamps without year, the year is chosen so
+	// the time is not after Reference, zero means now.
+	Reference time.Time
+	// BootTime converts the monotonic timestamps of the kernel messages,
+	// zero keeps them as the uptime field only.
+	BootTime time.Time
+	formats  []string
+}
+
+type parseFunc func(p *Parser, line string) (Record, bool)
+
+var parsers = map[string]parseFunc{
+	FormatJSON:    parseJSON,
+	FormatRFC5424: parseRFC5424,
+	FormatRFC3164: parseRFC3164,
+	FormatAccess:  parseAccess,
+	FormatDmesg:   parseDmesg,
+}
+
+// DefaultFormats are the formats tried by a parser created without formats
+var DefaultFormats = []string{FormatJSON, FormatRFC5424, FormatRFC3164, FormatAccess, FormatDmesg}
+
+// NewParser creates a parser trying the given formats, all the formats if none is given
+func NewParser(formats ...string) *Parser {
+	if len(formats) == 0 {
+		formats = DefaultFormats
+	}
+	known := make([]string, 0, len(formats))
+	for _, format := range formats {
+		if _, ok := parsers[format]; ok {
+			known = append(known, format)
+		}
+	}
+	return &Parser{formats: known}
+}
+
+// Parse parses the line with the first format recognizing it
+func (p *Parser) Parse(line string) Record {
+	for _, format := range p.formats {
+		if record, ok := parsers[format](p, line); ok {
+			record.Format = format
+			return record
+		}
+	}
+	return parsePlain(p, line)
+}
+
+// ParseAs parses the line with the given format only
+func (p *Parser) ParseAs(format, line string) (Record, bool) {
+	parse, ok := parsers[format]
+	if !ok {
+		return Record{}, false
+	}
+	record, ok := parse(p, line)
+	record.Format = format
+	return record, ok
+}
+
+func (p *Parser) reference() time.Time {
+	if p.Reference.IsZero() {
+		return time.Now()
+	}
+	return p.Reference
+}
+
+// withYear completes a timestamp parsed without year, a time more than one day
+// after the reference is from the previous year and a new year within one day
+// of the reference, with a clock skew at the end of december, is the next one.
+func (p *Parser) withYear(t time.Time) time.Time {
+	reference := p.reference()
+	limit := reference.Add(24 * time.Hour)
+	t = t.AddDate(reference.Year(), 0, 0)
+	if next := t.AddDate(1, 0, 0); !next.After(limit) {
+		return next
+	}
+	if t.After(limit) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
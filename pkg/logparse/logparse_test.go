@@ -0,0 +1,205 @@
+package logparse
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// the timestamps without year of the fixtures are completed from the reference
+	fixtureReference = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	fixtureBootTime  = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// fixtureCase is a case of the testdata fixtures, the line is parsed with
+// ParseAs when the format is given, with Parse otherwise
+type fixtureCase struct {
+	Name string  `json:"name"`
+	Line string  `json:"line"`
+	As   string  `json:"as"`
+	OK   *bool   `json:"ok"`
+	Want *Record `json:"want"`
+}
+
+func TestMain(m *testing.M) {
+	// the timestamps without zone are local times
+	time.Local = time.UTC
+	os.Exit(m.Run())
+}
+
+func TestFixtures(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		var cases []fixtureCase
+		if !assert.NoError(t, json.Unmarshal(data, &cases), file) {
+			continue
+		}
+
+		group := strings.TrimSuffix(filepath.Base(file), ".json")
+		for _, tt := range cases {
+			t.Run(group+"/"+tt.Name, func(t *testing.T) {
+				parser := NewParser()
+				parser.Reference = fixtureReference
+				parser.BootTime = fixtureBootTime
+
+				var got Record
+				ok := true
+				if tt.As != "" {
+					got, ok = parser.ParseAs(tt.As, tt.Line)
+				} else {
+					got = parser.Parse(tt.Line)
+				}
+				if tt.OK != nil && !*tt.OK {
+					assert.False(t, ok)
+					return
+				}
+				assert.True(t, ok)
+				if !assert.NotNil(t, tt.Want) {
+					return
+				}
+				assertRecord(t, *tt.Want, got)
+			})
+		}
+	}
+}
+
+func assertRecord(t *testing.T, want, got Record) {
+	t.Helper()
+	assert.True(t, want.Time.Equal(got.Time), "time: want %s, got %s", want.Time, got.Time)
+	want.Time, got.Time = time.Time{}, time.Time{}
+	assert.Equal(t, want, got)
+}
+
+func TestParseFormats(t *testing.T) {
+	line := "<13>Feb 28 12:00:01 host backup: disk almost full"
+
+	// the formats not given to the parser are not tried
+	record := NewParser(FormatJSON, FormatAccess).Parse(line)
+	assert.Equal(t, FormatPlain, record.Format)
+	assert.Equal(t, line, record.Message)
+
+	record = NewParser(FormatRFC3164).Parse(line)
+	assert.Equal(t, FormatRFC3164, record.Format)
+
+	// the unknown formats are ignored
+	record = NewParser("unknown", FormatRFC3164).Parse(line)
+	assert.Equal(t, FormatRFC3164, record.Format)
+	_, ok := NewParser().ParseAs("unknown", line)
+	assert.False(t, ok)
+}
+
+func TestParseWithoutBootTime(t *testing.T) {
+	parser := NewParser(FormatDmesg)
+	record := parser.Parse("[  123.456789] EXT4-fs (sda1): error count since last fsck: 4")
+	assert.True(t, record.Time.IsZero(), "the uptime is not converted without boot time")
+	value, ok := record.Field("uptime")
+	assert.True(t, ok)
+	assert.Equal(t, "123.456789", value)
+}
+
+func TestYearRollover(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference time.Time
+		line      string
+		want      time.Time
+	}{
+		{
+			name:      "new year eve seen on new year",
+			reference: time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC),
+			line:      "Dec 31 23:59:59 host cron[1]: job done",
+			want:      time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC),
+		},
+		{
+			name:      "new year seen just before midnight with a clock skew",
+			reference: time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC),
+			line:      "Jan  1 00:00:30 host cron[1]: job done",
+			want:      time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC),
+		},
+		{
+			name:      "same day",
+			reference: time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC),
+			line:      "Jun 15 11:00:00 host cron[1]: job done",
+			want:      time.Date(2024, 6, 15, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "leap day",
+			reference: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+			line:      "Feb 29 08:00:00 host cron[1]: job done",
+			want:      time.Date(2024, 2, 29, 8, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser(FormatRFC3164)
+			parser.Reference = tt.reference
+			record := parser.Parse(tt.line)
+			assert.Equal(t, FormatRFC3164, record.Format)
+			assert.True(t, tt.want.Equal(record.Time), "want %s, got %s", tt.want, record.Time)
+		})
+	}
+}
+
+func TestRecordField(t *testing.T) {
+	record := Record{
+		Time:    time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		Level:   LevelError,
+		Source:  "nginx",
+		Message: "upstream timed out",
+		Fields:  map[string]string{"status": "504"},
+	}
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{"time", "2024-03-01T10:00:00Z", true},
+		{"level", LevelError, true},
+		{"source", "nginx", true},
+		{"message", "upstream timed out", true},
+		{"status", "504", true},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		value, ok := record.Field(tt.name)
+		assert.Equal(t, tt.ok, ok, tt.name)
+		assert.Equal(t, tt.value, value, tt.name)
+	}
+
+	_, ok := Record{}.Field("time")
+	assert.False(t, ok)
+}
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		level      string
+		normalized string
+		rank       int
+	}{
+		{"WARN", LevelWarning, 3},
+		{" Error ", LevelError, 4},
+		{"fatal", LevelCritical, 5},
+		{"panic", LevelEmerg, 7},
+		{"trace", LevelDebug, 0},
+		{"informational", LevelInfo, 1},
+		{"verbose", "", -1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.normalized, NormalizeLevel(tt.level), tt.level)
+		assert.Equal(t, tt.rank, LevelRank(tt.level), tt.level)
+	}
+	assert.Equal(t, LevelEmerg, SyslogLevel(0))
+	assert.Equal(t, LevelDebug, SyslogLevel(7))
+	assert.Equal(t, "", SyslogLevel(8))
+}
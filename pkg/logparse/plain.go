@@ -0,0 +1,32 @@
+package logparse
+
+import (
+	"regexp"
+	"time"
+)
+
+var (
+	plainRFC3339Regexp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+	plainSyslogRegexp  = regexp.MustCompile(`^[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`)
+)
+
+// parsePlain keeps the whole line as message, the time and level are guessed
+// from the first timestamp and level keyword of the line.
+func parsePlain(p *Parser, line string) Record {
+	record := Record{Message: line, Level: guessLevel(line), Format: FormatPlain}
+
+	head := line
+	if len(head) > 128 {
+		head = head[:128]
+	}
+	if match := plainSyslogRegexp.FindString(head); match != "" {
+		if t, err := time.ParseInLocation("Jan _2 15:04:05", match, time.Local); err == nil {
+			record.Time = p.withYear(t)
+			return record
+		}
+	}
+	if match := plainRFC3339Regexp.FindString(head); match != "" {
+		record.Time = jsonTime(match)
+	}
+	return record
+}
@@ -0,0 +1,111 @@
+package logparse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	rfc5424Regexp   = regexp.MustCompile(`^<(\d{1,3})>(\d{1,2}) (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]"\\]|\\.|"(?:[^"\\]|\\.)*")*\])+)(?: (.*))?$`)
+	sdElementRegexp = regexp.MustCompile(`\[([^\s\]]+)((?:\s+[^\s=\]]+="(?:[^"\\]|\\.)*")*)\]`)
+	sdParamRegexp   = regexp.MustCompile(`([^\s=\]]+)="((?:[^"\\]|\\.)*)"`)
+
+	// <PRI>TIMESTAMP HOSTNAME TAG[PID]: MSG, the timestamp is either the
+	// traditional one without year or the RFC 3339 one of rsyslog.
+	rfc3164Regexp = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})) (\S+) ([^\s:\[]+)(?:\[(\d+)\])?: ?(.*)$`)
+)
+
+var facilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp",
+	"ntp", "security", "console", "solaris-cron", "local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// setPriority sets the level and facility of the record from the syslog PRI
+func (r *Record) setPriority(pri string) bool {
+	value, err := strconv.Atoi(pri)
+	if err != nil || value > 191 {
+		return false
+	}
+	r.Level = SyslogLevel(value % 8)
+	r.setField("facility", facilities[value/8])
+	return true
+}
+
+func parseRFC5424(p *Parser, line string) (Record, bool) {
+	match := rfc5424Regexp.FindStringSubmatch(line)
+	if match == nil {
+		return Record{}, false
+	}
+
+	record := Record{}
+	if !record.setPriority(match[1]) {
+		return Record{}, false
+	}
+	if match[3] != "-" {
+		t, err := time.Parse(time.RFC3339Nano, match[3])
+		if err != nil {
+			return Record{}, false
+		}
+		record.Time = t
+	}
+	record.setField("host", match[4])
+	if match[5] != "-" {
+		record.Source = match[5]
+	}
+	record.setField("pid", match[6])
+	record.setField("msgid", match[7])
+	for _, element := range sdElementRegexp.FindAllStringSubmatch(match[8], -1) {
+		for _, param := range sdParamRegexp.FindAllStringSubmatch(element[2], -1) {
+			record.setField(element[1]+"."+param[1], unescapeParam(param[2]))
+		}
+	}
+	// The message may start with the UTF-8 byte order mark.
+	record.Message = strings.TrimPrefix(match[9], "\ufeff")
+	if record.Level == "" {
+		record.Level = guessLevel(record.Message)
+	}
+	return record, true
+}
+
+func unescapeParam(value string) string {
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\]`, `]`).Replace(value)
+}
+
+func parseRFC3164(p *Parser, line string) (Record, bool) {
+	match := rfc3164Regexp.FindStringSubmatch(line)
+	if match == nil {
+		return Record{}, false
+	}
+
+	record := Record{}
+	if match[1] != "" && !record.setPriority(match[1]) {
+		return Record{}, false
+	}
+	if t, err := time.ParseInLocation("Jan _2 15:04:05", match[2], time.Local); err == nil {
+		record.Time = p.withYear(t)
+	} else if t, err := time.Parse(time.RFC3339Nano, match[2]); err == nil {
+		record.Time = t
+	} else {
+		return Record{}, false
+	}
+	record.setField("host", match[3])
+	record.Source = match[4]
+	record.setField("pid", match[5])
+	record.Message = match[6]
+
+	// The kernel messages forwarded to syslog keep their uptime prefix.
+	if record.Source == "kernel" {
+		if uptime := dmesgUptimeRegexp.FindStringSubmatch(record.Message); uptime != nil {
+			record.setField("uptime", uptime[1])
+			record.Message = uptime[2]
+		}
+	}
+	if level := guessLevel(record.Message); match[1] == "" || (record.Level == LevelNotice && level != "") {
+		// Without PRI, or with the default user.notice of logger, the message tells more.
+		record.Level = level
+	}
+	return record, true
+}
@@ -0,0 +1,280 @@
+package logsearch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+)
+
+const (
+	// maximal size of a log line, longer lines are skipped
+	maxLineSize = 1024 * 1024
+)
+
+// entry is one log line or journal entry read from a file
+type entry struct {
+	line   string
+	record logparse.Record
+}
+
+// rotatedFiles returns the files matching the glob and their rotated files,
+// the most recently modified first.
+func rotatedFiles(glob string) ([]string, error) {
+	patterns := []string{glob, glob + ".*", glob + "-*"}
+	seen := make(map[string]bool)
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.Mode().IsRegular() || seen[match] {
+				continue
+			}
+			seen[match] = true
+			files = append(files, match)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return modTime(files[i]).After(modTime(files[j]))
+	})
+	return files, nil
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// openLog opens a log file, gzip compressed files are decompressed
+func openLog(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	return &gzipFile{Reader: reader, file: file}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// lineReader reads the lines of a log, unlike bufio.Scanner it skips the
+// lines longer than maxLineSize instead of failing on them.
+type lineReader struct {
+	reader *bufio.Reader
+	line   []byte
+}
+
+func newLineReader(reader io.Reader) *lineReader {
+	return &lineReader{reader: bufio.NewReaderSize(reader, 64*1024)}
+}
+
+// next returns the next line without its line ending, it is only valid until
+// the next call. The error is io.EOF after the last line.
+func (r *lineReader) next() ([]byte, error) {
+	r.line = r.line[:0]
+	tooLong := false
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		if !tooLong && len(r.line)+len(chunk) <= maxLineSize+1 {
+			r.line = append(r.line, chunk...)
+		} else {
+			tooLong = true
+		}
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err != nil && err != io.EOF:
+			return nil, err
+		case tooLong && err == nil:
+			r.line, tooLong = r.line[:0], false
+			continue
+		case tooLong || len(r.line) == 0:
+			return nil, io.EOF
+		}
+		line := bytes.TrimSuffix(r.line, []byte("\n"))
+		if len(line) > maxLineSize {
+			// the last line has no newline
+			return nil, io.EOF
+		}
+		return bytes.TrimSuffix(line, []byte("\r")), nil
+	}
+}
+
+// readLines calls fn for every line of a plain log file, fn returns false to stop
+func readLines(reader io.Reader, parser *logparse.Parser, fn func(entry) bool) error {
+	lines := newLineReader(reader)
+	for {
+		data, err := lines.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			continue
+		}
+		line := string(data)
+		if !fn(entry{line: line, record: parser.Parse(line)}) {
+			return nil
+		}
+	}
+}
+
+// readJournal calls fn for every entry of a journal export, both the export
+// format of "journalctl -o export" and the JSON lines of "journalctl -o json"
+// are supported. fn returns false to stop.
+func readJournal(reader io.Reader, fn func(entry) bool) error {
+	buffered := bufio.NewReaderSize(reader, 64*1024)
+	first, err := buffered.Peek(1)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if first[0] == '{' {
+		return readJournalJSON(buffered, fn)
+	}
+	return readJournalExport(buffered, fn)
+}
+
+func readJournalJSON(reader io.Reader, fn func(entry) bool) error {
+	lines := newLineReader(reader)
+	for {
+		data, err := lines.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fields := map[string]any{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			continue
+		}
+		values := make(map[string]string, len(fields))
+		for key, value := range fields {
+			if str, ok := value.(string); ok {
+				values[key] = str
+			}
+		}
+		if !fn(journalEntry(values)) {
+			return nil
+		}
+	}
+}
+
+func readJournalExport(reader *bufio.Reader, fn func(entry) bool) error {
+	fields := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if len(fields) > 0 {
+				if !fn(journalEntry(fields)) {
+					return nil
+				}
+				fields = map[string]string{}
+			}
+		case strings.Contains(line, "="):
+			key, value, _ := strings.Cut(line, "=")
+			fields[key] = value
+		default:
+			// Binary field: the name is followed by a little endian 64 bit size and the data.
+			var size uint64
+			if err := binary.Read(reader, binary.LittleEndian, &size); err != nil {
+				return nil
+			}
+			data := make([]byte, min(size, maxLineSize))
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return nil
+			}
+			if _, err := reader.Discard(int(size - uint64(len(data)))); err != nil {
+				return nil
+			}
+			fields[line] = string(data)
+			reader.ReadString('\n')
+		}
+
+		if err == io.EOF {
+			if len(fields) > 0 {
+				fn(journalEntry(fields))
+			}
+			return nil
+		}
+	}
+}
+
+// journalEntry converts the fields of a journal entry, the level comes from PRIORITY
+func journalEntry(fields map[string]string) entry {
+	record := logparse.Record{
+		Source:  fields["SYSLOG_IDENTIFIER"],
+		Message: fields["MESSAGE"],
+		Format:  logparse.FormatJournal,
+		Fields:  map[string]string{},
+	}
+	if record.Source == "" {
+		record.Source = fields["_COMM"]
+	}
+	for _, name := range []string{"_SYSTEMD_UNIT", "_PID", "_HOSTNAME", "_TRANSPORT"} {
+		if value := fields[name]; value != "" {
+			record.Fields[strings.ToLower(strings.TrimPrefix(name, "_"))] = value
+		}
+	}
+	if usec, err := strconv.ParseInt(fields["__REALTIME_TIMESTAMP"], 10, 64); err == nil {
+		record.Time = time.UnixMicro(usec)
+	}
+	if priority, err := strconv.Atoi(fields["PRIORITY"]); err == nil {
+		record.Level = logparse.SyslogLevel(priority)
+	}
+
+	line := record.Message
+	if record.Source != "" {
+		line = record.Source + ": " + line
+	}
+	if unit := record.Fields["systemd_unit"]; unit != "" {
+		line = "[" + unit + "] " + line
+	}
+	return entry{line: line, record: record}
+}
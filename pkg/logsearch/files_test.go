@@ -0,0 +1,82 @@
+package logsearch
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+)
+
+func TestSearchSkipsLongLines(t *testing.T) {
+	dir := t.TempDir()
+	long := strings.Repeat("x", 2*1024*1024)
+	plain := filepath.Join(dir, "app.log")
+	content := "error: first failure\n" + long + " error\n" + "error: second failure\n"
+	assert.NoError(t, os.WriteFile(plain, []byte(content), 0644))
+	journal := filepath.Join(dir, "journal.json")
+	content = `{"MESSAGE": "error: first failure", "PRIORITY": "3"}` + "\n" +
+		`{"MESSAGE": "` + long + `", "PRIORITY": "3"}` + "\n" +
+		`{"MESSAGE": "error: second failure", "PRIORITY": "3"}` + "\n"
+	assert.NoError(t, os.WriteFile(journal, []byte(content), 0644))
+
+	searcher := NewSearcher([]config.LogSource{
+		{Name: "app", Path: plain, Type: config.LogSourceFile},
+		{Name: "journal", Path: journal, Type: config.LogSourceJournal},
+	})
+	for _, source := range []string{"app", "journal"} {
+		result, err := searcher.Search(Query{Pattern: "error", Sources: []string{source}})
+		assert.NoError(t, err, source)
+		if assert.NotNil(t, result, source) {
+			assert.Equal(t, 2, result.Matches, source)
+		}
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		lines   []string
+	}{
+		{"lines", "a\nb\n", []string{"a", "b"}},
+		{"without final newline", "a\nb", []string{"a", "b"}},
+		{"crlf", "a\r\nb\r\n", []string{"a", "b"}},
+		{"empty lines", "a\n\nb\n", []string{"a", "", "b"}},
+		{"longest line", strings.Repeat("y", maxLineSize) + "\nb\n", []string{strings.Repeat("y", maxLineSize), "b"}},
+		{"too long", "a\n" + strings.Repeat("z", maxLineSize+1) + "\nb\n", []string{"a", "b"}},
+		{"too long at the end", "a\n" + strings.Repeat("z", maxLineSize+1), []string{"a"}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newLineReader(strings.NewReader(tt.content))
+			var lines []string
+			for {
+				line, err := reader.next()
+				if err == io.EOF {
+					break
+				}
+				if !assert.NoError(t, err) {
+					return
+				}
+				lines = append(lines, string(line))
+			}
+			assert.Equal(t, tt.lines, lines)
+		})
+	}
+
+	// readLines skips the empty lines
+	var lines []string
+	err := readLines(strings.NewReader("a\n\nb\n"), logparse.NewParser(), func(e entry) bool {
+		lines = append(lines, e.line)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, lines)
+}
@@ -0,0 +1,88 @@
+package logsearch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+)
+
+// FieldFilter is a condition on a field of the parsed lines, like status>=500
+type FieldFilter struct {
+	Name     string
+	Operator string
+	Value    string
+	pattern  *regexp.Regexp
+}
+
+// the longer operators first so >= is not read as >
+var filterOperators = []string{">=", "<=", "!=", "=~", "=", ">", "<"}
+
+// ParseFieldFilter parses a condition like status>=500, method=POST or path=~^/api,
+// the ordering operators compare numbers.
+func ParseFieldFilter(expr string) (FieldFilter, error) {
+	for i := range expr {
+		for _, operator := range filterOperators {
+			if !strings.HasPrefix(expr[i:], operator) {
+				continue
+			}
+			filter := FieldFilter{
+				Name:     strings.TrimSpace(expr[:i]),
+				Operator: operator,
+				Value:    strings.TrimSpace(expr[i+len(operator):]),
+			}
+			if filter.Name == "" {
+				return FieldFilter{}, fmt.Errorf("invalid field filter %q, no field name", expr)
+			}
+			switch operator {
+			case "=~":
+				pattern, err := regexp.Compile(filter.Value)
+				if err != nil {
+					return FieldFilter{}, fmt.Errorf("invalid field filter %q: %w", expr, err)
+				}
+				filter.pattern = pattern
+			case ">=", "<=", ">", "<":
+				if _, err := strconv.ParseFloat(filter.Value, 64); err != nil {
+					return FieldFilter{}, fmt.Errorf("invalid field filter %q, %s needs a number", expr, operator)
+				}
+			}
+			return filter, nil
+		}
+	}
+	return FieldFilter{}, fmt.Errorf("invalid field filter %q, use one of the operators %s", expr, strings.Join(filterOperators, " "))
+}
+
+// Match checks the record has the field and its value satisfies the condition
+func (f FieldFilter) Match(record logparse.Record) bool {
+	value, ok := record.Field(f.Name)
+	if !ok {
+		return f.Operator == "!="
+	}
+
+	switch f.Operator {
+	case "=":
+		return value == f.Value
+	case "!=":
+		return value != f.Value
+	case "=~":
+		return f.pattern.MatchString(value)
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false
+	}
+	limit, _ := strconv.ParseFloat(f.Value, 64)
+	switch f.Operator {
+	case ">=":
+		return number >= limit
+	case "<=":
+		return number <= limit
+	case ">":
+		return number > limit
+	default:
+		return number < limit
+	}
+}
@@ -0,0 +1,80 @@
+package logsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+)
+
+func TestParseFieldFilter(t *testing.T) {
+	tests := []struct {
+		expr     string
+		name     string
+		operator string
+		value    string
+		err      bool
+	}{
+		{expr: "status>=500", name: "status", operator: ">=", value: "500"},
+		{expr: "status <= 399", name: "status", operator: "<=", value: "399"},
+		{expr: "method!=GET", name: "method", operator: "!=", value: "GET"},
+		{expr: "path=~^/api", name: "path", operator: "=~", value: "^/api"},
+		{expr: "method=POST", name: "method", operator: "=", value: "POST"},
+		{expr: "bytes>0", name: "bytes", operator: ">", value: "0"},
+		{expr: "bytes<1e6", name: "bytes", operator: "<", value: "1e6"},
+		{expr: "=500", err: true},
+		{expr: "status", err: true},
+		{expr: "status>=high", err: true},
+		{expr: "path=~[", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := ParseFieldFilter(tt.expr)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.name, filter.Name)
+			assert.Equal(t, tt.operator, filter.Operator)
+			assert.Equal(t, tt.value, filter.Value)
+		})
+	}
+}
+
+func TestFieldFilterMatch(t *testing.T) {
+	record := logparse.NewParser(logparse.FormatAccess).Parse(
+		`10.0.0.7 - - [01/Mar/2024:11:58:02 +0000] "POST /api/orders HTTP/1.1" 502 157 "-" "curl/8.5.0"`)
+	assert.Equal(t, logparse.FormatAccess, record.Format)
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"status>=500", true},
+		{"status<500", false},
+		{"status>502", false},
+		{"status<=502", true},
+		{"method=POST", true},
+		{"method=post", false},
+		{"method!=GET", true},
+		{"path=~^/api/", true},
+		{"path=~^/static/", false},
+		{"level=error", true},
+		// a field which is not a number never satisfies an ordering operator
+		{"method>1", false},
+		// a missing field only satisfies !=
+		{"referer=x", false},
+		{"referer!=x", true},
+		{"referer=~.", false},
+		{"latency>0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := ParseFieldFilter(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, filter.Match(record))
+		})
+	}
+}
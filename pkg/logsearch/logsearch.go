@@ -0,0 +1,344 @@
+package logsearch
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/logparse"
+)
+
+const (
+	// DefaultLimit is the default number of excerpts returned
+	DefaultLimit = 20
+	// MaxLimit is the maximal number of excerpts returned
+	MaxLimit = 100
+	// maximal size of the line of an excerpt
+	maxExcerptChars = 300
+	// maximal number of lines read by one search, over all the files
+	maxScannedLines = 5_000_000
+)
+
+// Query is a search of the log sources
+type Query struct {
+	// Pattern is a Go regular expression, empty matches every line
+	Pattern string
+	// Sources are the names of the log sources or paths allowed by them, empty means all sources
+	Sources []string
+	Since   time.Time
+	Until   time.Time
+	// Severity is the minimal severity of the lines, empty means any severity
+	Severity string
+	// Fields are conditions on the fields of the parsed lines, like status>=500
+	Fields []FieldFilter
+	// Limit is the maximal number of excerpts
+	Limit int
+}
+
+// Excerpt is a deduplicated matching line, Count is the number of lines
+// with the same template, the line is the latest of them.
+type Excerpt struct {
+	Source   string `json:"source"`
+	File     string `json:"file"`
+	Line     string `json:"line"`
+	Severity string `json:"severity,omitempty"`
+	// Fields are the fields of the parsed line
+	Fields    map[string]string `json:"fields,omitempty"`
+	Count     int               `json:"count"`
+	FirstSeen time.Time         `json:"first_seen,omitempty"`
+	LastSeen  time.Time         `json:"last_seen,omitempty"`
+}
+
+// Result is the result of a search
+type Result struct {
+	Matches      int       `json:"matches"`
+	Distinct     int       `json:"distinct"`
+	FilesScanned []string  `json:"files_scanned"`
+	Excerpts     []Excerpt `json:"excerpts"`
+	// Truncated is true if the search stopped before reading all the lines
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Searcher searches the log sources of the agent config, nothing outside of
+// the configured sources is ever read.
+type Searcher struct {
+	sources []config.LogSource
+}
+
+func NewSearcher(sources []config.LogSource) *Searcher {
+	return &Searcher{sources: sources}
+}
+
+// Sources returns the configured log sources
+func (s *Searcher) Sources() []config.LogSource {
+	return s.sources
+}
+
+type target struct {
+	source config.LogSource
+	glob   string
+}
+
+// Search runs the query over the selected sources and their rotated files
+func (s *Searcher) Search(query Query) (*Result, error) {
+	pattern, err := regexp.Compile(query.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", query.Pattern, err)
+	}
+	minRank := -1
+	if query.Severity != "" {
+		if minRank = logparse.LevelRank(query.Severity); minRank < 0 {
+			return nil, fmt.Errorf("unknown severity %q", query.Severity)
+		}
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	targets, err := s.targets(query.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{FilesScanned: []string{}}
+	excerpts := make(map[string]*Excerpt)
+	scanned := 0
+	for _, t := range targets {
+		files, err := rotatedFiles(t.glob)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if !s.allowed(file) {
+				continue
+			}
+			// A file last written before the start of the window has no matching line.
+			lastWrite := modTime(file)
+			if !query.Since.IsZero() && lastWrite.Before(query.Since) {
+				continue
+			}
+			result.FilesScanned = append(result.FilesScanned, file)
+
+			reader, err := openLog(file)
+			if err != nil {
+				continue
+			}
+			visit := func(e entry) bool {
+				scanned++
+				if scanned > maxScannedLines {
+					result.Truncated = true
+					return false
+				}
+				if !matchEntry(e, pattern, query, minRank) {
+					return true
+				}
+				result.Matches++
+				addExcerpt(excerpts, t.source.Name, file, e)
+				return true
+			}
+			if t.source.Type == config.LogSourceJournal {
+				err = readJournal(reader, visit)
+			} else {
+				// The timestamps without year are completed from the last write of the file.
+				parser := logparse.NewParser()
+				parser.Reference = lastWrite
+				err = readLines(reader, parser, visit)
+			}
+			reader.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", file, err)
+			}
+			if result.Truncated {
+				break
+			}
+		}
+	}
+
+	result.Distinct = len(excerpts)
+	result.Excerpts = selectExcerpts(excerpts, limit)
+	if len(result.Excerpts) < result.Distinct {
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// targets resolves the requested sources, a request is either the name of a
+// source or a path matched by the glob of a source.
+func (s *Searcher) targets(requested []string) ([]target, error) {
+	if len(s.sources) == 0 {
+		return nil, fmt.Errorf("no log source is configured")
+	}
+	if len(requested) == 0 {
+		targets := make([]target, 0, len(s.sources))
+		for _, source := range s.sources {
+			targets = append(targets, target{source: source, glob: source.Path})
+		}
+		return targets, nil
+	}
+
+	targets := make([]target, 0, len(requested))
+	for _, name := range requested {
+		found := false
+		for _, source := range s.sources {
+			if source.Name == name {
+				targets = append(targets, target{source: source, glob: source.Path})
+				found = true
+				break
+			}
+			if filepath.IsAbs(name) && matchSource(source, filepath.Clean(name)) {
+				targets = append(targets, target{source: source, glob: filepath.Clean(name)})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%q is not an allowed log source, allowed sources: %s", name, s.sourceNames())
+		}
+	}
+	return targets, nil
+}
+
+// allowed checks the file and, after resolving the symbolic links, its target
+// are both covered by a configured source.
+func (s *Searcher) allowed(file string) bool {
+	resolved, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return false
+	}
+	return s.covered(file) && s.covered(resolved)
+}
+
+func (s *Searcher) covered(path string) bool {
+	for _, source := range s.sources {
+		if matchSource(source, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Searcher) sourceNames() string {
+	names := make([]string, 0, len(s.sources))
+	for _, source := range s.sources {
+		names = append(names, source.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// matchSource checks the path is matched by the glob of the source or is one of the rotated files
+func matchSource(source config.LogSource, path string) bool {
+	for _, pattern := range []string{source.Path, source.Path + ".*", source.Path + "-*"} {
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchEntry(e entry, pattern *regexp.Regexp, query Query, minRank int) bool {
+	if t := e.record.Time; !t.IsZero() {
+		if !query.Since.IsZero() && t.Before(query.Since) {
+			return false
+		}
+		if !query.Until.IsZero() && t.After(query.Until) {
+			return false
+		}
+	}
+	if minRank >= 0 && logparse.LevelRank(e.record.Level) < minRank {
+		return false
+	}
+	for _, filter := range query.Fields {
+		if !filter.Match(e.record) {
+			return false
+		}
+	}
+	return pattern.MatchString(e.line)
+}
+
+var (
+	// parts of a line replaced to find the lines of the same template
+	templateRegexps = []*regexp.Regexp{
+		regexp.MustCompile(`^(?:<\d+>)?[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}\s+`),
+		regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?`),
+		regexp.MustCompile(`0x[0-9a-fA-F]+|[0-9a-fA-F]{8}-[0-9a-fA-F-]{27}|\d+(?:\.\d+)*`),
+	}
+)
+
+func addExcerpt(excerpts map[string]*Excerpt, source, file string, e entry) {
+	record := e.record
+	key := record.Message
+	for _, re := range templateRegexps {
+		key = re.ReplaceAllString(key, "#")
+	}
+	key = source + "\x00" + record.Source + "\x00" + record.Level + "\x00" + key
+
+	excerpt, ok := excerpts[key]
+	if !ok {
+		excerpt = &Excerpt{Source: source, File: file, Severity: record.Level, FirstSeen: record.Time, LastSeen: record.Time}
+		excerpts[key] = excerpt
+	}
+	excerpt.Count++
+	if record.Time.IsZero() || !record.Time.Before(excerpt.LastSeen) {
+		excerpt.Line = truncateLine(e.line)
+		excerpt.Fields = record.Fields
+		excerpt.File = file
+		excerpt.LastSeen = record.Time
+	}
+	if !record.Time.IsZero() && (excerpt.FirstSeen.IsZero() || record.Time.Before(excerpt.FirstSeen)) {
+		excerpt.FirstSeen = record.Time
+	}
+}
+
+// selectExcerpts returns the most severe then the most frequent excerpts
+func selectExcerpts(excerpts map[string]*Excerpt, limit int) []Excerpt {
+	selected := make([]Excerpt, 0, len(excerpts))
+	for _, excerpt := range excerpts {
+		selected = append(selected, *excerpt)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		a, b := selected[i], selected[j]
+		if logparse.LevelRank(a.Severity) != logparse.LevelRank(b.Severity) {
+			return logparse.LevelRank(a.Severity) > logparse.LevelRank(b.Severity)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.LastSeen.After(b.LastSeen)
+	})
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected
+}
+
+func truncateLine(line string) string {
+	if len(line) <= maxExcerptChars {
+		return line
+	}
+	return strings.ToValidUTF8(line[:maxExcerptChars], "") + "..."
+}
+
+// ParseTime parses the bound of a time window, either a duration before now
+// like 30m or 2h, or an RFC3339 timestamp.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use a duration like 2h or a RFC3339 timestamp", value)
+}
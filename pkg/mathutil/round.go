@@ -0,0 +1,8 @@
+package mathutil
+
+import "math"
+
+// Round2 rounds to 2 decimals, enough for the percentages and rates reported to the agent
+func Round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
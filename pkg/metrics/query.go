@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	AggregateAvg  = "avg"
+	AggregateMin  = "min"
+	AggregateMax  = "max"
+	AggregateLast = "last"
+	AggregateSum  = "sum"
+	AggregateP95  = "p95"
+	// AggregateRate is the change per second of the metric, for the counters like fd.allocated
+	AggregateRate = "rate"
+)
+
+// Query selects the samples of the series of a metric within a time range,
+// the samples are downsampled to one point by step when the step is set.
+type Query struct {
+	Name string
+	// Labels select the series having these values, all the series of the metric when empty
+	Labels Labels
+	From   time.Time
+	To     time.Time
+	Step   time.Duration
+	// Aggregate combines the samples of a step, one of the Aggregate constants, avg when empty
+	Aggregate string
+}
+
+// Result is a series selected by a query
+type Result struct {
+	Name   string  `json:"name"`
+	Labels Labels  `json:"labels,omitempty"`
+	Points []Point `json:"points"`
+}
+
+// bucket accumulates the samples of a step, the rate of a step is computed
+// from the last sample of the previous step so no change is lost.
+type bucket struct {
+	start  int64
+	count  int
+	sum    float64
+	min    float64
+	max    float64
+	last   float64
+	values []float64
+	// base is the sample the rate is computed from
+	baseTime int64
+	base     float64
+	lastTime int64
+	hasBase  bool
+	keepsAll bool
+}
+
+func newBucket(start int64, aggregate string, previous *bucket) *bucket {
+	b := &bucket{start: start, keepsAll: aggregate == AggregateP95}
+	if previous != nil && previous.count > 0 {
+		b.baseTime, b.base, b.hasBase = previous.lastTime, previous.last, true
+	}
+	return b
+}
+
+func (b *bucket) add(t int64, value float64) {
+	if b.count == 0 {
+		b.min, b.max = value, value
+		if !b.hasBase {
+			b.baseTime, b.base, b.hasBase = t, value, true
+		}
+	}
+	b.count++
+	b.sum += value
+	b.min = math.Min(b.min, value)
+	b.max = math.Max(b.max, value)
+	b.last = value
+	b.lastTime = t
+	if b.keepsAll {
+		b.values = append(b.values, value)
+	}
+}
+
+func (b *bucket) value(aggregate string) float64 {
+	switch aggregate {
+	case AggregateMin:
+		return b.min
+	case AggregateMax:
+		return b.max
+	case AggregateLast:
+		return b.last
+	case AggregateSum:
+		return b.sum
+	case AggregateP95:
+		return Percentile(b.values, 95)
+	case AggregateRate:
+		return rate(b.base, b.last, b.baseTime, b.lastTime)
+	}
+	return b.sum / float64(b.count)
+}
+
+// ValidateAggregate checks the aggregate is one of the Aggregate constants, empty is avg
+func ValidateAggregate(aggregate string) error {
+	switch aggregate {
+	case "", AggregateAvg, AggregateMin, AggregateMax, AggregateLast, AggregateSum, AggregateP95, AggregateRate:
+		return nil
+	}
+	return fmt.Errorf("unknown aggregate %q, use avg, min, max, last, sum, p95 or rate", aggregate)
+}
+
+// Query returns the series of the metric matching the labels of the query
+func (s *Store) Query(query Query) ([]Result, error) {
+	if err := ValidateAggregate(query.Aggregate); err != nil {
+		return nil, err
+	}
+	if query.To.IsZero() {
+		query.To = time.Now()
+	}
+	if query.From.After(query.To) {
+		return nil, fmt.Errorf("the start of the range %s is after its end %s", query.From, query.To)
+	}
+	from, to := query.From.UnixMilli(), query.To.UnixMilli()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var results []Result
+	for _, key := range s.keys() {
+		current := s.series[key]
+		if current.name != query.Name || !current.labels.Matches(query.Labels) {
+			continue
+		}
+		var points []Point
+		current.each(func(t int64, value float64) {
+			if t >= from && t <= to {
+				points = append(points, Point{Time: time.UnixMilli(t), Value: value})
+			}
+		})
+		results = append(results, Result{
+			Name:   current.name,
+			Labels: current.labels,
+			Points: Downsample(points, query.From, query.Step, query.Aggregate),
+		})
+	}
+	return results, nil
+}
+
+// Downsample combines the points of every step starting at from with the
+// aggregate, the points are returned as is without step unless the aggregate
+// is the rate which is then computed between consecutive points.
+func Downsample(points []Point, from time.Time, step time.Duration, aggregate string) []Point {
+	if step <= 0 && aggregate != AggregateRate {
+		return append([]Point{}, points...)
+	}
+	start, size := from.UnixMilli(), step.Milliseconds()
+	downsampled := []Point{}
+	var b *bucket
+	flush := func() {
+		if b == nil || b.count == 0 {
+			return
+		}
+		if aggregate == AggregateRate && b.lastTime == b.baseTime {
+			// a single sample without any previous one has no rate
+			return
+		}
+		downsampled = append(downsampled, Point{Time: time.UnixMilli(b.start), Value: round(b.value(aggregate))})
+	}
+	for _, point := range points {
+		t := point.Time.UnixMilli()
+		bucketStart := t
+		if size > 0 {
+			bucketStart = start + (t-start)/size*size
+		}
+		if b == nil || b.start != bucketStart {
+			flush()
+			b = newBucket(bucketStart, aggregate, b)
+		}
+		b.add(t, point.Value)
+	}
+	flush()
+	return downsampled
+}
+
+// rate returns the change per second between two samples
+func rate(from, to float64, fromTime, toTime int64) float64 {
+	if toTime <= fromTime {
+		return 0
+	}
+	return (to - from) * 1000 / float64(toTime-fromTime)
+}
+
+// round keeps 4 decimals, enough for percentages and rates
+func round(value float64) float64 {
+	return math.Round(value*1e4) / 1e4
+}
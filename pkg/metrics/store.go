@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxSeries bounds the memory of a store whose maximal number of series is not set
+	DefaultMaxSeries = 2000
+)
+
+// Labels distinguish the series of a metric, like the device of a disk metric
+type Labels map[string]string
+
+// String returns the labels sorted by name like {device="sda"}
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(l))
+	for name := range l {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, l[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Matches tells if the labels have all the given values
+func (l Labels) Matches(selector Labels) bool {
+	for name, value := range selector {
+		if l[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Point is a sample of a series
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// series is a ring buffer of the samples of a metric, the times are kept as
+// unix milliseconds to keep the store small. The buffers grow with the samples
+// up to the capacity, so the series of a short history stay small.
+type series struct {
+	name     string
+	labels   Labels
+	capacity int
+	times    []int64
+	values   []float64
+	// next is the index of the next sample, the buffer is full once wrapped
+	next    int
+	wrapped bool
+}
+
+// initial size of the buffers of a series
+const initialSeriesSize = 16
+
+func (s *series) append(t time.Time, value float64) {
+	if !s.wrapped && len(s.times) < s.capacity {
+		if len(s.times) == cap(s.times) {
+			s.grow()
+		}
+		s.times = append(s.times, t.UnixMilli())
+		s.values = append(s.values, value)
+		s.next = len(s.times)
+	} else {
+		s.times[s.next] = t.UnixMilli()
+		s.values[s.next] = value
+		s.next++
+	}
+	if s.next == s.capacity {
+		s.next = 0
+		s.wrapped = true
+	}
+}
+
+// grow doubles the buffers, without exceeding the capacity
+func (s *series) grow() {
+	size := min(max(2*cap(s.times), initialSeriesSize), s.capacity)
+	times := make([]int64, len(s.times), size)
+	copy(times, s.times)
+	values := make([]float64, len(s.values), size)
+	copy(values, s.values)
+	s.times, s.values = times, values
+}
+
+// last returns the time of the last sample
+func (s *series) last() int64 {
+	if s.next == 0 {
+		return s.times[len(s.times)-1]
+	}
+	return s.times[s.next-1]
+}
+
+// each calls fn with the samples from the oldest to the newest
+func (s *series) each(fn func(t int64, value float64)) {
+	if s.wrapped {
+		for i := s.next; i < len(s.times); i++ {
+			fn(s.times[i], s.values[i])
+		}
+	}
+	for i := 0; i < s.next; i++ {
+		fn(s.times[i], s.values[i])
+	}
+}
+
+// Store keeps the last samples of every series in a ring buffer, the
+// memory is bounded by the capacity of the buffers and the number of series.
+type Store struct {
+	capacity  int
+	maxSeries int
+	mu        sync.RWMutex
+	series    map[string]*series
+	// dropped counts the samples of the new series refused once the store is full
+	dropped uint64
+}
+
+// NewStore creates a store keeping capacity samples by series, at most maxSeries series
+func NewStore(capacity, maxSeries int) *Store {
+	if maxSeries <= 0 {
+		maxSeries = DefaultMaxSeries
+	}
+	return &Store{capacity: max(capacity, 1), maxSeries: maxSeries, series: make(map[string]*series)}
+}
+
+// Append adds a sample to the series of the metric with the labels
+func (s *Store) Append(name string, labels Labels, t time.Time, value float64) {
+	key := name + labels.String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.series[key]
+	if !ok {
+		if len(s.series) >= s.maxSeries {
+			s.dropped++
+			return
+		}
+		copied := make(Labels, len(labels))
+		for label, value := range labels {
+			copied[label] = value
+		}
+		current = &series{name: name, labels: copied, capacity: s.capacity}
+		s.series[key] = current
+	}
+	current.append(t, value)
+}
+
+// Prune removes the series without sample since before, like the series of a
+// removed network interface.
+func (s *Store) Prune(before time.Time) {
+	s.PrunePrefix("", before)
+}
+
+// PrunePrefix removes the series whose name starts with prefix without sample
+// since before, like the series of the exited processes.
+func (s *Store) PrunePrefix(prefix string, before time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, current := range s.series {
+		if strings.HasPrefix(current.name, prefix) && current.last() < before.UnixMilli() {
+			delete(s.series, key)
+		}
+	}
+}
+
+// SeriesInfo describes a series of the store
+type SeriesInfo struct {
+	Name   string    `json:"name"`
+	Labels Labels    `json:"labels,omitempty"`
+	Points int       `json:"points"`
+	First  time.Time `json:"first"`
+	Last   time.Time `json:"last"`
+}
+
+// List returns the series whose name starts with prefix, sorted by name and labels
+func (s *Store) List(prefix string) []SeriesInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var infos []SeriesInfo
+	for _, key := range s.keys() {
+		current := s.series[key]
+		if !strings.HasPrefix(current.name, prefix) {
+			continue
+		}
+		info := SeriesInfo{Name: current.name, Labels: current.labels}
+		current.each(func(t int64, _ float64) {
+			if info.Points == 0 {
+				info.First = time.UnixMilli(t)
+			}
+			info.Last = time.UnixMilli(t)
+			info.Points++
+		})
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Stats are the size of the store
+type Stats struct {
+	Series    int    `json:"series"`
+	MaxSeries int    `json:"max_series"`
+	Capacity  int    `json:"capacity"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Stats{Series: len(s.series), MaxSeries: s.maxSeries, Capacity: s.capacity, Dropped: s.dropped}
+}
+
+// keys returns the keys of the series sorted, the lock must be held
+func (s *Store) keys() []string {
+	keys := make([]string, 0, len(s.series))
+	for key := range s.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func points(s *series) []int64 {
+	var times []int64
+	s.each(func(t int64, _ float64) { times = append(times, t) })
+	return times
+}
+
+func TestSeriesGrowsUpToCapacity(t *testing.T) {
+	start := time.UnixMilli(0)
+	s := &series{capacity: 40}
+	s.append(start, 0)
+	assert.Equal(t, initialSeriesSize, cap(s.times), "the buffers start small")
+	assert.Equal(t, []int64{0}, points(s))
+	assert.Equal(t, int64(0), s.last())
+
+	for i := 1; i < 40; i++ {
+		s.append(start.Add(time.Duration(i)*time.Millisecond), float64(i))
+		assert.Equal(t, int64(i), s.last())
+	}
+	assert.Len(t, points(s), 40)
+	assert.Equal(t, 40, cap(s.times), "the buffers never exceed the capacity")
+	assert.Equal(t, 40, cap(s.values))
+	assert.True(t, s.wrapped)
+
+	// the oldest samples are replaced once the buffer is full
+	for i := 40; i < 45; i++ {
+		s.append(start.Add(time.Duration(i)*time.Millisecond), float64(i))
+	}
+	times := points(s)
+	assert.Len(t, times, 40)
+	assert.Equal(t, int64(5), times[0])
+	assert.Equal(t, int64(44), times[39])
+	assert.Equal(t, int64(44), s.last())
+	assert.Equal(t, 40, cap(s.times))
+}
+
+func TestStoreAppend(t *testing.T) {
+	store := NewStore(3, 2)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		store.Append("cpu.usage_percent", nil, now.Add(time.Duration(i)*time.Second), float64(i))
+	}
+	store.Append("disk.util_percent", Labels{"device": "sda"}, now, 1)
+	store.Append("disk.util_percent", Labels{"device": "sdb"}, now, 1)
+
+	stats := store.Stats()
+	assert.Equal(t, 2, stats.Series)
+	assert.Equal(t, uint64(1), stats.Dropped)
+
+	infos := store.List("cpu.")
+	if assert.Len(t, infos, 1) {
+		assert.Equal(t, 3, infos[0].Points)
+		assert.Equal(t, now.Add(2*time.Second).UnixMilli(), infos[0].First.UnixMilli())
+		assert.Equal(t, now.Add(4*time.Second).UnixMilli(), infos[0].Last.UnixMilli())
+	}
+
+	store.Prune(now.Add(time.Second))
+	assert.Equal(t, 1, store.Stats().Series, "the series without recent sample are removed")
+}
+
+func TestDownsample(t *testing.T) {
+	from := time.UnixMilli(0)
+	var points []Point
+	// a counter growing by 10 per second with a spike of 100 at 5s
+	for i := 0; i < 10; i++ {
+		value := float64(i * 10)
+		if i == 5 {
+			value = 100
+		}
+		points = append(points, Point{Time: from.Add(time.Duration(i) * time.Second), Value: value})
+	}
+
+	tests := []struct {
+		aggregate string
+		step      time.Duration
+		want      []float64
+	}{
+		{AggregateAvg, 5 * time.Second, []float64{20, 80}},
+		{AggregateMax, 5 * time.Second, []float64{40, 100}},
+		{AggregateMin, 5 * time.Second, []float64{0, 60}},
+		{AggregateLast, 5 * time.Second, []float64{40, 90}},
+		{AggregateSum, 10 * time.Second, []float64{500}},
+		// the rate of a step starts from the last sample of the previous step
+		{AggregateRate, 5 * time.Second, []float64{10, 10}},
+		{AggregateAvg, 0, []float64{0, 10, 20, 30, 40, 100, 60, 70, 80, 90}},
+	}
+	for _, tt := range tests {
+		downsampled := Downsample(points, from, tt.step, tt.aggregate)
+		values := make([]float64, 0, len(downsampled))
+		for _, point := range downsampled {
+			values = append(values, point.Value)
+		}
+		assert.Equal(t, tt.want, values, "%s by %s", tt.aggregate, tt.step)
+	}
+
+	// without step, the rate is computed between consecutive samples
+	rates := Downsample(points[:3], from, 0, AggregateRate)
+	if assert.Len(t, rates, 2) {
+		assert.Equal(t, 10.0, rates[0].Value)
+		assert.Equal(t, from.Add(time.Second), rates[0].Time)
+	}
+	assert.NotNil(t, Downsample(nil, from, time.Second, AggregateAvg))
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Summary are the statistics of the points of a series
+type Summary struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	P95   float64 `json:"p95"`
+	First float64 `json:"first"`
+	Last  float64 `json:"last"`
+	// MaxTime is when the maximum was reached, to correlate a spike with the logs
+	MaxTime time.Time `json:"max_time"`
+}
+
+// Summarize returns the statistics of the points, the zero Summary when there is no point
+func Summarize(points []Point) Summary {
+	if len(points) == 0 {
+		return Summary{}
+	}
+	summary := Summary{
+		Count:   len(points),
+		Min:     math.Inf(1),
+		Max:     math.Inf(-1),
+		First:   points[0].Value,
+		Last:    points[len(points)-1].Value,
+		MaxTime: points[0].Time,
+	}
+	values := make([]float64, len(points))
+	sum := 0.0
+	for i, point := range points {
+		values[i] = point.Value
+		sum += point.Value
+		summary.Min = math.Min(summary.Min, point.Value)
+		if point.Value > summary.Max {
+			summary.Max = point.Value
+			summary.MaxTime = point.Time
+		}
+	}
+	summary.Avg = round(sum / float64(len(points)))
+	summary.P95 = round(Percentile(values, 95))
+	return summary
+}
+
+// Percentile returns the nearest-rank percentile of the values, the values are sorted in place
+func Percentile(values []float64, percentile float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(percentile / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(values) {
+		rank = len(values)
+	}
+	return values[rank-1]
+}
@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/anomaly"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// name of the anomalies in the run history
+	anomaliesName = "anomalies"
+	// file where the seasonal baselines are kept across the restarts
+	AnomalyBaselinesFile = "anomaly_baselines.json"
+	// interval between two saves of the baselines
+	baselinesSaveInterval = 10 * time.Minute
+	// the anomalies of the other series within this time are given to the investigations
+	anomalyContext = time.Hour
+)
+
+// AnomalyWatcher runs the anomaly detectors on the collected metrics, the
+// anomalies are alerted and could start an investigation with the anomalies
+// of the other metrics as context.
+type AnomalyWatcher struct {
+	store         *metrics.Store
+	interval      time.Duration
+	metrics       []config.AnomalyMetric
+	detector      *anomaly.Detector
+	def           config.AnomaliesDefinition
+	alerts        alerts.AlertsManager
+	investigator  *investigator
+	cooldown      time.Duration
+	baselinesPath string
+	// lastAlerts are the times of the last alerts by series and detector
+	lastAlerts map[string]time.Time
+	// recent are the anomalies of the last hour
+	recent []anomaly.Anomaly
+}
+
+// NewAnomalyWatcher creates the watcher of the anomalies definition on the
+// metrics of the collector of the registry.
+func NewAnomalyWatcher(def config.AnomaliesDefinition, routing *config.LLMRouting, registry *agents.ToolRegistry, alertsManager alerts.AlertsManager) (*AnomalyWatcher, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	detector, err := anomaly.NewDetector(def.Detectors, def.ZScore)
+	if err != nil {
+		return nil, fmt.Errorf("anomalies: %w", err)
+	}
+	if _, err := registry.NewToolsByName(def.Tools); err != nil {
+		return nil, fmt.Errorf("anomalies: %w", err)
+	}
+
+	collector := registry.MetricsCollector()
+	cooldown, _ := def.GetCooldown()
+	return &AnomalyWatcher{
+		store:         collector.Store(),
+		interval:      collector.Interval(),
+		metrics:       def.GetMetrics(),
+		detector:      detector,
+		def:           def,
+		alerts:        alertsManager,
+		investigator:  &investigator{routing: routing, registry: registry, tools: def.Tools, alerts: alertsManager},
+		cooldown:      cooldown,
+		baselinesPath: filepath.Join(os.Getenv("HOME"), agents.Catchdir, AnomalyBaselinesFile),
+		lastAlerts:    make(map[string]time.Time),
+	}, nil
+}
+
+// Run observes the new samples at every interval until the context is done,
+// the seasonal baselines are saved regularly and when it stops.
+func (w *AnomalyWatcher) Run(ctx context.Context) error {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	if err := w.detector.LoadBaselines(w.baselinesPath); err != nil {
+		logger.Warn("ai-agentic-monitor: anomaly baselines not loaded, they are learnt again,", logger.Args("err", err.Error()))
+	}
+	save := func() {
+		if err := w.detector.SaveBaselines(w.baselinesPath); err != nil {
+			logger.Warn("ai-agentic-monitor: failed to save the anomaly baselines,", logger.Args("err", err.Error()))
+		}
+	}
+	defer save()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	lastSave := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			w.Observe(now)
+			if now.Sub(lastSave) >= baselinesSaveInterval {
+				w.detector.Forget(now.Add(-baselinesSaveInterval))
+				save()
+				lastSave = now
+			}
+		}
+	}
+}
+
+// Observe gives the samples collected since the previous observation to the
+// detectors, the samples already observed are skipped by the detector.
+func (w *AnomalyWatcher) Observe(now time.Time) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	for _, metric := range w.metrics {
+		results, err := w.store.Query(metrics.Query{
+			Name:   metric.Name,
+			Labels: metrics.Labels(metric.Labels),
+			From:   now.Add(-staleIntervals * w.interval),
+			To:     now,
+		})
+		if err != nil {
+			logger.Warn("ai-agentic-monitor: failed to query the metrics,", logger.Args("metric", metric.Name, "err", err.Error()))
+			continue
+		}
+		for _, result := range results {
+			for _, point := range result.Points {
+				for _, found := range w.detector.Observe(anomaly.Sample{
+					Metric:       result.Name,
+					Labels:       result.Labels,
+					Time:         point.Time,
+					Value:        point.Value,
+					MinDeviation: metric.MinDeviation,
+				}) {
+					w.handle(found)
+				}
+			}
+		}
+	}
+}
+
+func (w *AnomalyWatcher) handle(found anomaly.Anomaly) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	cutoff := time.Now().Add(-anomalyContext)
+	recent := w.recent[:0]
+	for _, previous := range w.recent {
+		if previous.Time.After(cutoff) {
+			recent = append(recent, previous)
+		}
+	}
+	w.recent = append(recent, found)
+
+	key := found.Series() + "/" + found.Detector
+	if last, ok := w.lastAlerts[key]; ok && time.Since(last) < w.cooldown {
+		return
+	}
+	w.lastAlerts[key] = time.Now()
+
+	summary := "anomaly: " + found.Message()
+	logger.Warn("ai-agentic-monitor: "+summary, logger.Args("detector", found.Detector))
+	saveRunRecord(RunRecord{
+		Monitor: anomaliesName,
+		Time:    time.Now(),
+		Model:   found.Detector,
+		Answer:  summary,
+		Anomaly: &found,
+	})
+
+	if w.alerts != nil {
+		level := w.def.Level
+		if level == "" {
+			level = alerts.Warning
+		}
+		details, _ := json.MarshalIndent(found, "", "  ")
+		w.alerts.AddAlert(level, summary, string(details))
+	}
+	if w.def.Investigate {
+		go w.investigator.investigate(anomaliesName+"-"+found.Metric, w.anomalyPrompt(found))
+	}
+}
+
+// anomalyPrompt builds the task of the agent investigating an anomaly, the
+// anomalies of the other series hint at the cause.
+func (w *AnomalyWatcher) anomalyPrompt(found anomaly.Anomaly) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "An anomaly was detected at %s on the metrics of the host: %s.\n",
+		found.Time.Local().Format(time.RFC3339), found.Message())
+	var others []string
+	for _, previous := range w.recent {
+		if previous.Series() != found.Series() {
+			others = append(others, fmt.Sprintf("- %s: %s", previous.Time.Local().Format("15:04:05"), previous.Message()))
+		}
+	}
+	if len(others) > 0 {
+		fmt.Fprintf(&builder, "\nOther anomalies within the last %s:\n%s\n", anomalyContext, strings.Join(others, "\n"))
+	}
+	builder.WriteString("\nFind what changed on the host to cause it, tell if it is harmful and what should be done. " +
+		"The history of the metrics is available with the MetricsQuery tool.")
+	return builder.String()
+}
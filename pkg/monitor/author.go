@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/llmback"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/parser"
+	sysprmpts "github.com/darmenliu/ai-agentic-monitor/pkg/prompts"
+
+	"github.com/pterm/pterm"
+	"github.com/tmc/langchaingo/prompts"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	// times the LLM is asked again when the generated definition is invalid
+	maxAuthoringAttempts = 3
+)
+
+// AuthorMonitor asks the LLM to turn the natural language request into a monitor
+// definition, the definition is validated before it is returned.
+func AuthorMonitor(ctx context.Context, generator llmback.ContentGenerator, registry *agents.ToolRegistry, request string) (*config.MonitorDefinition, error) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	availableTools := registry.AvailableTools()
+	template := prompts.PromptTemplate{
+		Template:       sysprmpts.SysPromptForMonitorAuthoring,
+		TemplateFormat: prompts.TemplateFormatGoTemplate,
+		InputVariables: []string{"input", "feedback"},
+		PartialVariables: map[string]any{
+			"tools":                    agents.ToolDescriptions(availableTools),
+			"tool_names":               agents.ToolNames(availableTools),
+			"MonitorDefinitionExample": sysprmpts.MonitorDefinitionExample,
+		},
+	}
+
+	feedback := ""
+	var lastErr error
+	for attempt := 1; attempt <= maxAuthoringAttempts; attempt++ {
+		prompt, err := template.Format(map[string]any{"input": request, "feedback": feedback})
+		if err != nil {
+			return nil, fmt.Errorf("failed to format authoring prompt: %w", err)
+		}
+
+		response, err := generator.GenerateText(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+
+		def, err := parseMonitorDefinition(registry, response)
+		if err == nil {
+			return def, nil
+		}
+
+		logger.Warn("ai-agentic-monitor: generated monitor definition is invalid,", logger.Args("attempt", attempt, "err", err.Error()))
+		lastErr = err
+		feedback = fmt.Sprintf("\nYour previous answer was:\n\n%s\n\nIt was rejected with the error: %s\nFix the definition.\n", response, err.Error())
+	}
+
+	return nil, fmt.Errorf("failed to author a valid monitor after %d attempts: %w", maxAuthoringAttempts, lastErr)
+}
+
+func parseMonitorDefinition(registry *agents.ToolRegistry, response string) (*config.MonitorDefinition, error) {
+	sources, err := parser.NewGoCodeParser().ParseCode(response)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no yaml block found in the answer")
+	}
+	sources[0].ParseFileContent()
+
+	def := &config.MonitorDefinition{}
+	decoder := yaml.NewDecoder(bytes.NewBufferString(sources[0].FileContent))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(def); err != nil {
+		return nil, fmt.Errorf("invalid yaml: %w", err)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := registry.NewToolsByName(def.Tools); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+func TestParseMonitorDefinition(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry := agents.NewToolRegistry(nil)
+
+	tests := []struct {
+		name     string
+		response string
+		err      string
+	}{
+		{
+			name: "valid",
+			response: "Here is the monitor:\n```yaml\n" +
+				"name: disk\n" +
+				"prompt: Check the disks.\n" +
+				"schedule: 1h\n" +
+				"tools:\n" +
+				"  - DiskInspector\n" +
+				"severity:\n" +
+				"  - match: \"(?i)full\"\n" +
+				"    level: error\n" +
+				"```\n",
+		},
+		{
+			name:     "no yaml block",
+			response: "name: disk\nprompt: Check the disks.\nschedule: 1h\n",
+			err:      "no yaml block found",
+		},
+		{
+			name:     "unknown field",
+			response: "```yaml\nname: disk\nprompt: Check the disks.\nschedule: 1h\ninterval: 1h\n```\n",
+			err:      "invalid yaml",
+		},
+		{
+			name:     "unknown tool",
+			response: "```yaml\nname: disk\nprompt: Check the disks.\nschedule: 1h\ntools:\n  - DiskInspector\n  - Kubectl\n```\n",
+			err:      `unknown tool "Kubectl"`,
+		},
+		{
+			name:     "bad severity regexp",
+			response: "```yaml\nname: disk\nprompt: Check the disks.\nschedule: 1h\nseverity:\n  - match: \"(full\"\n    level: error\n```\n",
+			err:      "invalid severity match",
+		},
+		{
+			name:     "bad severity level",
+			response: "```yaml\nname: disk\nprompt: Check the disks.\nschedule: 1h\nseverity:\n  - match: full\n    level: urgent\n```\n",
+			err:      `invalid severity level "urgent"`,
+		},
+		{
+			name:     "schedule too short",
+			response: "```yaml\nname: disk\nprompt: Check the disks.\nschedule: 10s\n```\n",
+			err:      "is shorter than 1m0s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def, err := parseMonitorDefinition(registry, tt.response)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				assert.Nil(t, def)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, &config.MonitorDefinition{
+					Name:     "disk",
+					Prompt:   "Check the disks.",
+					Schedule: "1h",
+					Tools:    []string{"DiskInspector"},
+					Severity: []config.SeverityRule{{Match: "(?i)full", Level: "error"}},
+				}, def)
+			}
+		})
+	}
+}
+
+func TestAuthoredMonitorDuplicateName(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry := agents.NewToolRegistry(nil)
+	configPath := filepath.Join(t.TempDir(), "monitors.yaml")
+	response := "```yaml\nname: disk\nprompt: Check the disks.\nschedule: 1h\n```\n"
+
+	def, err := parseMonitorDefinition(registry, response)
+	assert.NoError(t, err)
+	assert.NoError(t, config.AppendMonitor(configPath, *def))
+
+	// the same monitor authored again is rejected when it is added
+	def, err = parseMonitorDefinition(registry, response)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, config.AppendMonitor(configPath, *def), `duplicated monitor name "disk"`)
+
+	cfg, err := config.NewMonitorsYamlConfig(configPath)
+	assert.NoError(t, err)
+	assert.Len(t, cfg.Monitors, 1)
+}
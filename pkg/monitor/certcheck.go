@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/certs"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+// CertificateCheck scans certificate files and endpoints for the certificates
+// about to expire, a certificate raises a warning alert once it enters the
+// warning days and an alert of the check level once it enters the critical days.
+type CertificateCheck struct {
+	name  string
+	level string
+	spec  config.CertificateScan
+	// alerted maps the fingerprints of the certificates to the severity
+	// already alerted, a renewed certificate has a new fingerprint
+	alerted map[string]string
+	alerts  alerts.AlertsManager
+}
+
+func newCertificateCheck(def config.CheckDefinition, alertsManager alerts.AlertsManager) *CertificateCheck {
+	return &CertificateCheck{
+		name:    def.Name,
+		level:   def.GetLevel(),
+		spec:    *def.Certificates,
+		alerted: make(map[string]string),
+		alerts:  alertsManager,
+	}
+}
+
+func (c *CertificateCheck) Run() error {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	report := certs.Scan(context.Background(), c.spec)
+	for _, err := range report.Errors {
+		logger.Warn("ai-agentic-monitor: certificate scan error,", logger.Args("check", c.name, "err", err))
+	}
+
+	warningDays, criticalDays := c.spec.GetThresholds()
+	expiring := make(map[string]string)
+	var findings []string
+	for _, cert := range report.Certificates {
+		severity := cert.Severity(warningDays, criticalDays)
+		if severity == "" {
+			continue
+		}
+		expiring[cert.Fingerprint] = severity
+		findings = append(findings, describeCertificate(cert))
+		// The alert is only raised when the certificate enters a more severe range.
+		if c.alerted[cert.Fingerprint] == severity || c.alerted[cert.Fingerprint] == certs.SeverityCritical {
+			continue
+		}
+		c.raiseAlert(cert, severity)
+	}
+	c.alerted = expiring
+
+	record := RunRecord{
+		Monitor: c.name,
+		Time:    time.Now(),
+		Model:   "certificates",
+		Answer: fmt.Sprintf("%d certificates in %d files and %d endpoints, %d expiring within %d days",
+			len(report.Certificates), report.Files, report.Endpoints, len(findings), warningDays),
+		Error: strings.Join(report.Errors, "\n"),
+	}
+	if len(findings) > 0 {
+		record.Answer += ":\n" + strings.Join(findings, "\n")
+	}
+	saveRunRecord(record)
+	return nil
+}
+
+func (c *CertificateCheck) raiseAlert(cert certs.Certificate, severity string) {
+	if c.alerts == nil {
+		return
+	}
+	level := alerts.Warning
+	if severity == certs.SeverityCritical {
+		level = c.level
+	}
+	summary := fmt.Sprintf("certificate %s expires in %d days", cert.Subject, cert.DaysLeft)
+	if cert.DaysLeft < 0 {
+		summary = fmt.Sprintf("certificate %s expired %d days ago", cert.Subject, -cert.DaysLeft)
+	}
+	description := fmt.Sprintf("check %s found an expiring certificate:\n%s\nissuer: %s\nserial: %s\nsha256: %s",
+		c.name, describeCertificate(cert), cert.Issuer, cert.Serial, cert.Fingerprint)
+	c.alerts.AddAlert(level, summary, description)
+}
+
+// describeCertificate describes the certificate in one line
+func describeCertificate(cert certs.Certificate) string {
+	line := fmt.Sprintf("%s (%d days left, not after %s) in %s", cert.Subject, cert.DaysLeft,
+		cert.NotAfter.Format("2006-01-02"), strings.Join(cert.Sources, ", "))
+	if len(cert.SANs) > 0 {
+		line += fmt.Sprintf(", SANs: %s", strings.Join(cert.SANs, ", "))
+	}
+	return line
+}
@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/probe"
+	"github.com/pterm/pterm"
+)
+
+// CheckImpl runs the probe of a check definition, it does not involve the LLM
+// so it could run much more often than the monitors.
+type CheckImpl struct {
+	name   string
+	level  string
+	spec   config.ProbeSpec
+	alerts alerts.AlertsManager
+	// failing is set while the probe fails, the alert is only added when the
+	// probe starts failing instead of at every run
+	failing bool
+}
+
+// NewCheck creates a monitor running the probe or the certificates scan of the
+// check definition, the issues found are added to alertsManager.
+func NewCheck(def config.CheckDefinition, alertsManager alerts.AlertsManager) (Monitor, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	if def.Certificates != nil {
+		return newCertificateCheck(def, alertsManager), nil
+	}
+	return &CheckImpl{
+		name:   def.Name,
+		level:  def.GetLevel(),
+		spec:   *def.Probe,
+		alerts: alertsManager,
+	}, nil
+}
+
+func (c *CheckImpl) Run() error {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	result := probe.Run(context.Background(), c.spec)
+	record := RunRecord{
+		Monitor: c.name,
+		Time:    result.Time,
+		Model:   "probe",
+		Answer:  result.Summary(),
+		Error:   result.Error,
+		Probe:   &result,
+	}
+	saveRunRecord(record)
+
+	if result.Success {
+		if c.failing {
+			logger.Info("ai-agentic-monitor: check recovered,", logger.Args("check", c.name, "target", c.spec.Target))
+			if c.alerts != nil {
+				c.alerts.AddAlert(alerts.Info, fmt.Sprintf("check %s recovered", c.name), result.Summary())
+			}
+		}
+		c.failing = false
+		return nil
+	}
+
+	logger.Warn("ai-agentic-monitor: check failed,", logger.Args("check", c.name, "target", c.spec.Target, "err", result.Error))
+	if !c.failing && c.alerts != nil {
+		details, _ := json.MarshalIndent(result, "", "  ")
+		c.alerts.AddAlert(c.level, fmt.Sprintf("check %s failed", c.name), fmt.Sprintf("%s\n\n%s", result.Summary(), details))
+	}
+	c.failing = true
+	return nil
+}
@@ -0,0 +1,162 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/depmap"
+	"github.com/pterm/pterm"
+)
+
+// name of the dependency discoveries in the run history
+const dependenciesName = "dependencies"
+
+// DependencyWatcher discovers the dependency map of the host at every
+// interval, records its changes and alerts the dependencies which disappeared
+// or whose connections started stalling.
+type DependencyWatcher struct {
+	tracker      *depmap.Tracker
+	interval     time.Duration
+	def          config.DependenciesDefinition
+	alerts       alerts.AlertsManager
+	investigator *investigator
+}
+
+// NewDependencyWatcher creates the watcher of the dependencies definition on
+// the dependency tracker of the registry.
+func NewDependencyWatcher(def config.DependenciesDefinition, routing *config.LLMRouting, registry *agents.ToolRegistry, alertsManager alerts.AlertsManager) (*DependencyWatcher, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := registry.NewToolsByName(def.Tools); err != nil {
+		return nil, fmt.Errorf("dependencies: %w", err)
+	}
+
+	tracker := registry.DependencyTracker()
+	if forgetAfter, _ := def.GetForgetAfter(); forgetAfter > 0 {
+		tracker.SetForgetAfter(forgetAfter)
+	}
+	interval, _ := def.GetInterval()
+	return &DependencyWatcher{
+		tracker:      tracker,
+		interval:     interval,
+		def:          def,
+		alerts:       alertsManager,
+		investigator: &investigator{routing: routing, registry: registry, tools: def.Tools, alerts: alertsManager},
+	}, nil
+}
+
+// Run discovers the dependencies at start and at every interval until the context is done
+func (w *DependencyWatcher) Run(ctx context.Context) error {
+	w.Discover(time.Now())
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			w.Discover(now)
+		}
+	}
+}
+
+// Discover refreshes the dependency map and handles its changes
+func (w *DependencyWatcher) Discover(now time.Time) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	graph, changes, err := w.tracker.Refresh(now)
+	if err != nil {
+		logger.Warn("ai-agentic-monitor: failed to refresh the dependency map,", logger.Args("err", err.Error()))
+	}
+	if graph == nil {
+		return
+	}
+	if changes.Initial {
+		logger.Info("ai-agentic-monitor: dependency map discovered,", logger.Args("nodes", len(graph.Nodes), "edges", len(graph.Edges)))
+		return
+	}
+	if changes.Empty() {
+		return
+	}
+	w.handle(changes, now)
+}
+
+func (w *DependencyWatcher) handle(changes *depmap.Changes, now time.Time) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	summary := "dependencies: " + dependencyChangesSummary(changes)
+	logger.Info("ai-agentic-monitor: "+summary, logger.Args(
+		"added", len(changes.AddedEdges), "missing", len(changes.MissingEdges), "stalled", len(changes.StalledEdges)))
+	saveRunRecord(RunRecord{
+		Monitor:      dependenciesName,
+		Time:         now,
+		Answer:       summary,
+		Dependencies: changes,
+	})
+
+	degraded := len(changes.MissingEdges) > 0 || len(changes.StalledEdges) > 0
+	if w.alerts != nil && (degraded || (w.def.AlertAdded && len(changes.AddedEdges) > 0)) {
+		level := w.def.Level
+		if level == "" {
+			level = alerts.Warning
+		}
+		details, _ := json.MarshalIndent(changes, "", "  ")
+		w.alerts.AddAlert(level, summary, string(details))
+	}
+	if w.def.Investigate && degraded {
+		go w.investigator.investigate(dependenciesName, dependencyPrompt(changes, now))
+	}
+}
+
+// dependencyChangesSummary describes the changes in one line
+func dependencyChangesSummary(changes *depmap.Changes) string {
+	var parts []string
+	for _, group := range []struct {
+		name  string
+		edges []*depmap.Edge
+	}{
+		{"stalled", changes.StalledEdges},
+		{"missing", changes.MissingEdges},
+		{"new", changes.AddedEdges},
+		{"forgotten", changes.ForgottenEdges},
+	} {
+		if len(group.edges) == 0 {
+			continue
+		}
+		part := fmt.Sprintf("%d %s (%s", len(group.edges), group.name, group.edges[0])
+		if len(group.edges) > 1 {
+			part += fmt.Sprintf(" and %d more", len(group.edges)-1)
+		}
+		parts = append(parts, part+")")
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%d new nodes", len(changes.AddedNodes))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// dependencyPrompt builds the task of the agent investigating the degraded dependencies
+func dependencyPrompt(changes *depmap.Changes, now time.Time) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "The dependency map of the host changed at %s.\n", now.Local().Format(time.RFC3339))
+	if len(changes.StalledEdges) > 0 {
+		builder.WriteString("\nThe connections of these dependencies started stalling, they retransmit or are still connecting:\n")
+		for _, edge := range changes.StalledEdges {
+			builder.WriteString("- " + edge.String() + "\n")
+		}
+	}
+	if len(changes.MissingEdges) > 0 {
+		builder.WriteString("\nThese established dependencies disappeared:\n")
+		for _, edge := range changes.MissingEdges {
+			builder.WriteString("- " + edge.String() + "\n")
+		}
+	}
+	builder.WriteString("\nFind if the services depending on them are degraded and what is wrong with the dependencies, the network or the remote side." +
+		" The current map is available with the DependencyMap tool.")
+	return builder.String()
+}
@@ -0,0 +1,155 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/forecast"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// name of the forecasts in the run history
+	forecastsName = "forecasts"
+	// an alerted series is alerted again once its exhaustion went beyond this many horizons
+	forecastRearm = 2
+)
+
+// ForecastWatcher forecasts the exhaustion of the resources from the trends
+// of the collected metrics, an exhaustion projected within the horizon is
+// alerted once until the series goes well beyond the horizon.
+type ForecastWatcher struct {
+	store        *metrics.Store
+	interval     time.Duration
+	horizon      time.Duration
+	options      forecast.Options
+	def          config.ForecastsDefinition
+	alerts       alerts.AlertsManager
+	investigator *investigator
+	// alerted are the series whose exhaustion within the horizon was alerted
+	alerted map[string]bool
+}
+
+// NewForecastWatcher creates the watcher of the forecasts definition on the
+// metrics of the collector of the registry.
+func NewForecastWatcher(def config.ForecastsDefinition, routing *config.LLMRouting, registry *agents.ToolRegistry, alertsManager alerts.AlertsManager) (*ForecastWatcher, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	if err := forecast.CheckKinds(def.Kinds); err != nil {
+		return nil, fmt.Errorf("forecasts: %w", err)
+	}
+	if _, err := registry.NewToolsByName(def.Tools); err != nil {
+		return nil, fmt.Errorf("forecasts: %w", err)
+	}
+
+	collector := registry.MetricsCollector()
+	history, _ := def.GetHistory()
+	minHistory, _ := def.GetMinHistory()
+	horizon, _ := def.GetHorizon()
+	interval, _ := def.GetInterval()
+	return &ForecastWatcher{
+		store:    collector.Store(),
+		interval: interval,
+		horizon:  horizon,
+		options: forecast.Options{
+			History:    history,
+			MinHistory: minHistory,
+			Stale:      staleIntervals * collector.Interval(),
+			Kinds:      def.Kinds,
+		},
+		def:          def,
+		alerts:       alertsManager,
+		investigator: &investigator{routing: routing, registry: registry, tools: def.Tools, alerts: alertsManager},
+		alerted:      make(map[string]bool),
+	}, nil
+}
+
+// Run forecasts at every interval until the context is done
+func (w *ForecastWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			w.Forecast(now)
+		}
+	}
+}
+
+// Forecast alerts the exhaustions newly projected within the horizon
+func (w *ForecastWatcher) Forecast(now time.Time) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	forecasts, err := forecast.Capacity(w.store, now, w.options)
+	if err != nil {
+		logger.Warn("ai-agentic-monitor: failed to forecast the capacity,", logger.Args("err", err.Error()))
+		return
+	}
+	alerted := make(map[string]bool)
+	for _, found := range forecasts {
+		series := found.Series()
+		switch {
+		case found.Within(now.Add(w.horizon)):
+			if !w.alerted[series] {
+				w.handle(found, now)
+			}
+			alerted[series] = true
+		case w.alerted[series] && found.Within(now.Add(forecastRearm*w.horizon)):
+			// the projection moves at every forecast, the series is alerted
+			// again only after it went well beyond the horizon
+			alerted[series] = true
+		}
+	}
+	w.alerted = alerted
+}
+
+func (w *ForecastWatcher) handle(found forecast.Forecast, now time.Time) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	summary := "forecast: " + found.Message(now)
+	logger.Warn("ai-agentic-monitor: "+summary, logger.Args("kind", found.Kind))
+	saveRunRecord(RunRecord{
+		Monitor:  forecastsName,
+		Time:     now,
+		Model:    found.Kind,
+		Answer:   summary,
+		Forecast: &found,
+	})
+
+	if w.alerts != nil {
+		level := w.def.Level
+		if level == "" {
+			level = alerts.Warning
+		}
+		details, _ := json.MarshalIndent(found, "", "  ")
+		w.alerts.AddAlert(level, summary, string(details))
+	}
+	if w.def.Investigate {
+		go w.investigator.investigate(forecastsName+"-"+found.Metric, forecastPrompt(found, now))
+	}
+}
+
+// forecastPrompt builds the task of the agent investigating a projected exhaustion
+func forecastPrompt(found forecast.Forecast, now time.Time) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "The capacity forecast of the host at %s projects an exhaustion: %s.\n",
+		now.Local().Format(time.RFC3339), found.Message(now))
+	switch found.Kind {
+	case forecast.KindFilesystem, forecast.KindInodes:
+		builder.WriteString("\nFind what is filling the filesystem, tell if the growth is expected and what could be cleaned or extended.")
+	case forecast.KindSwap:
+		builder.WriteString("\nFind the processes pushing the memory to the swap and tell if the host lacks memory.")
+	case forecast.KindProcess:
+		builder.WriteString("\nTell if the process leaks memory or only grows its caches, and what should be done before the host runs out of memory.")
+	}
+	builder.WriteString(" The trends are available with the CapacityForecast and MetricsQuery tools.")
+	return builder.String()
+}
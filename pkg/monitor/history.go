@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/anomaly"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/depmap"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/forecast"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/kernel"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/probe"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// file where the records of the monitor runs are appended
+	RunHistoryFile = "runs.jsonl"
+)
+
+// RunRecord records the outcome of one monitor run
+type RunRecord struct {
+	Monitor          string    `json:"monitor"`
+	Time             time.Time `json:"time"`
+	Model            string    `json:"model"`
+	SessionID        string    `json:"session_id,omitempty"`
+	EscalationReason string    `json:"escalation_reason,omitempty"`
+	Answer           string    `json:"answer,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	// Probe is the result of the probe of a check
+	Probe *probe.Result `json:"probe,omitempty"`
+	// KernelEvent is the kernel event recorded by the kernel watcher
+	KernelEvent *kernel.Event `json:"kernel_event,omitempty"`
+	// Anomaly is the anomaly of the metrics recorded by the anomaly watcher
+	Anomaly *anomaly.Anomaly `json:"anomaly,omitempty"`
+	// Forecast is the projected exhaustion recorded by the forecast watcher
+	Forecast *forecast.Forecast `json:"forecast,omitempty"`
+	// Dependencies are the changes of the dependency map recorded by the dependency watcher
+	Dependencies *depmap.Changes `json:"dependencies,omitempty"`
+}
+
+// saveRunRecord appends the record to the run history, failures are only logged
+// since the history must never break a monitor run.
+func saveRunRecord(record RunRecord) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	homedir := os.Getenv("HOME")
+	historydir := filepath.Join(homedir, agents.Catchdir)
+	if err := os.MkdirAll(historydir, os.ModePerm); err != nil {
+		logger.Error("ai-agentic-monitor: failed to create history directory,", logger.Args("err", err.Error()))
+		return
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		logger.Error("ai-agentic-monitor: failed to encode run record,", logger.Args("err", err.Error()))
+		return
+	}
+
+	file, err := os.OpenFile(filepath.Join(historydir, RunHistoryFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Error("ai-agentic-monitor: failed to open run history,", logger.Args("err", err.Error()))
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		logger.Error("ai-agentic-monitor: failed to write run record,", logger.Args("err", err.Error()))
+	}
+}
@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"sync"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+// investigator runs the investigations of the agent started by the watchers,
+// one at a time, the events coming during an investigation are only alerted.
+type investigator struct {
+	routing  *config.LLMRouting
+	registry *agents.ToolRegistry
+	tools    []string
+	alerts   alerts.AlertsManager
+	running  sync.Mutex
+}
+
+// investigate runs the agent with the prompt, the conclusion is added as an
+// info alert next to the alert of the event.
+func (i *investigator) investigate(name, prompt string) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	if !i.running.TryLock() {
+		logger.Warn("ai-agentic-monitor: investigation already running, event not investigated,", logger.Args("investigation", name))
+		return
+	}
+	defer i.running.Unlock()
+
+	def := config.MonitorDefinition{
+		Name:     name,
+		Prompt:   prompt,
+		Tools:    i.tools,
+		Severity: []config.SeverityRule{{Match: "(?s).", Level: alerts.Info}},
+	}
+	mon, err := NewMonitorFromDefinition(i.routing, i.registry, def, i.alerts)
+	if err != nil {
+		logger.Error("ai-agentic-monitor: failed to create investigation,", logger.Args("investigation", name, "err", err.Error()))
+		return
+	}
+	if err := mon.Run(); err != nil {
+		logger.Error("ai-agentic-monitor: investigation failed,", logger.Args("investigation", name, "err", err.Error()))
+	}
+}
@@ -0,0 +1,137 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/kernel"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// name of the kernel events in the run history
+	kernelEventsName = "kernel-events"
+)
+
+// kernelEventLevels are the default alert levels of the kernel events
+var kernelEventLevels = map[string]string{
+	kernel.EventOOMKill:       alerts.Error,
+	kernel.EventHungTask:      alerts.Warning,
+	kernel.EventSoftLockup:    alerts.Error,
+	kernel.EventHardLockup:    alerts.Fatal,
+	kernel.EventFSError:       alerts.Error,
+	kernel.EventIOError:       alerts.Error,
+	kernel.EventSegfault:      alerts.Warning,
+	kernel.EventHardwareError: alerts.Error,
+}
+
+// KernelWatcher raises an alert as soon as the kernel reports an issue like an
+// OOM kill or a filesystem error, and investigates the configured kinds of
+// events with the agent, the kernel message given as context.
+type KernelWatcher struct {
+	watcher      *kernel.Watcher
+	def          config.KernelEventsDefinition
+	alerts       alerts.AlertsManager
+	investigator *investigator
+	cooldown     time.Duration
+	// lastAlerts are the times of the last alerts by event key
+	lastAlerts map[string]time.Time
+}
+
+// NewKernelWatcher creates the watcher of the kernel events definition
+func NewKernelWatcher(def config.KernelEventsDefinition, routing *config.LLMRouting, registry *agents.ToolRegistry, alertsManager alerts.AlertsManager) (*KernelWatcher, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	kinds := append(append([]string{}, def.Ignore...), def.Investigate...)
+	for kind := range def.Levels {
+		kinds = append(kinds, kind)
+	}
+	for _, kind := range kinds {
+		if !slices.Contains(kernel.EventKinds, kind) {
+			return nil, fmt.Errorf("kernel_events: unknown event %q, use %s", kind, strings.Join(kernel.EventKinds, ", "))
+		}
+	}
+	if _, err := registry.NewToolsByName(def.Tools); err != nil {
+		return nil, fmt.Errorf("kernel_events: %w", err)
+	}
+
+	cooldown, _ := def.GetCooldown()
+	return &KernelWatcher{
+		watcher:      kernel.NewWatcher(def.Source, procfs.DefaultFS()),
+		def:          def,
+		alerts:       alertsManager,
+		investigator: &investigator{routing: routing, registry: registry, tools: def.Tools, alerts: alertsManager},
+		cooldown:     cooldown,
+		lastAlerts:   make(map[string]time.Time),
+	}, nil
+}
+
+// Run watches the kernel messages until the context is done
+func (w *KernelWatcher) Run(ctx context.Context) error {
+	return w.watcher.Run(ctx, w.handle)
+}
+
+func (w *KernelWatcher) handle(event kernel.Event) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	if slices.Contains(w.def.Ignore, event.Kind) {
+		return
+	}
+	key := event.Key()
+	if last, ok := w.lastAlerts[key]; ok && time.Since(last) < w.cooldown {
+		logger.Debug("ai-agentic-monitor: kernel event in cooldown,", logger.Args("event", key, "message", event.Message))
+		return
+	}
+	w.lastAlerts[key] = time.Now()
+
+	logger.Warn("ai-agentic-monitor: kernel event,", logger.Args("event", event.Kind, "message", event.Message))
+	saveRunRecord(RunRecord{
+		Monitor:     kernelEventsName,
+		Time:        event.Time,
+		Model:       "kernel",
+		Answer:      event.Message,
+		KernelEvent: &event,
+	})
+	if w.alerts != nil {
+		details, _ := json.MarshalIndent(event, "", "  ")
+		w.alerts.AddAlert(w.level(event.Kind), fmt.Sprintf("kernel reported %s", strings.ReplaceAll(event.Kind, "_", " ")), string(details))
+	}
+	if slices.Contains(w.def.Investigate, event.Kind) {
+		go w.investigator.investigate("kernel-"+event.Kind, kernelEventPrompt(event))
+	}
+}
+
+func (w *KernelWatcher) level(kind string) string {
+	if level, ok := w.def.Levels[kind]; ok {
+		return level
+	}
+	return kernelEventLevels[kind]
+}
+
+// kernelEventPrompt builds the task of the agent investigating the event
+func kernelEventPrompt(event kernel.Event) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "The kernel just reported a %s event at %s:\n%s\n", strings.ReplaceAll(event.Kind, "_", " "), event.Time.Format(time.RFC3339), event.Message)
+	if len(event.Fields) > 0 {
+		fields := make([]string, 0, len(event.Fields))
+		for name, value := range event.Fields {
+			fields = append(fields, name+"="+value)
+		}
+		slices.Sort(fields)
+		fmt.Fprintf(&builder, "\nFields extracted from the message: %s\n", strings.Join(fields, " "))
+	}
+	if len(event.Context) > 0 {
+		fmt.Fprintf(&builder, "\nKernel messages around the event:\n%s\n", strings.Join(event.Context, "\n"))
+	}
+	builder.WriteString("\nInvestigate the cause of the event and its impact on the services of the host, " +
+		"tell if it is still ongoing and what should be done.")
+	return builder.String()
+}
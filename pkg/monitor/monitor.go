@@ -2,55 +2,228 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
 	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
 	"github.com/darmenliu/ai-agentic-monitor/pkg/llmback"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/session"
 
 	"github.com/pterm/pterm"
 	lcagents "github.com/tmc/langchaingo/agents"
 	"github.com/tmc/langchaingo/chains"
+	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/tools"
 )
 
+// findingStatus matches the status line of a final answer reporting an issue
+var findingStatus = regexp.MustCompile(`(?im)^\s*status:\s*finding`)
+
 type Monitor interface {
 	// Monitor will monitor the system and return the status of the system.
 	Run() error
 }
 
 type MonitorImpl struct {
-	config config.LLMConfiger
-	prompt string
+	routing  *config.LLMRouting
+	registry *agents.ToolRegistry
+	name     string
+	prompt   string
+	tools    []tools.Tool
+	severity []config.SeverityRule
+	alerts   alerts.AlertsManager
 }
 
-func NewMonitor(config config.LLMConfiger, prompt string) Monitor {
+func NewMonitor(llmConfig config.LLMConfiger, registry *agents.ToolRegistry, prompt string) Monitor {
 	return &MonitorImpl{
-		config: config,
-		prompt: prompt,
+		routing:  config.NewLLMRouting(llmConfig),
+		registry: registry,
+		name:     "monitor",
+		prompt:   prompt,
+		tools:    registry.DefaultTools(),
 	}
 }
 
-func (m *MonitorImpl) Run() error {
+// NewMonitorFromDefinition creates a monitor from a monitor definition of the monitors config,
+// the final answers matching the severity rules of the definition are added to alertsManager.
+func NewMonitorFromDefinition(routing *config.LLMRouting, registry *agents.ToolRegistry, def config.MonitorDefinition, alertsManager alerts.AlertsManager) (Monitor, error) {
+	agentTools, err := registry.NewToolsByName(def.Tools)
+	if err != nil {
+		return nil, fmt.Errorf("monitor %q: %w", def.Name, err)
+	}
+
+	return &MonitorImpl{
+		routing:  routing,
+		registry: registry,
+		name:     def.Name,
+		prompt:   definitionPrompt(def),
+		tools:    agentTools,
+		severity: def.Severity,
+		alerts:   alertsManager,
+	}, nil
+}
 
+func (m *MonitorImpl) Run() error {
 	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
-	llmbak, err := llmback.NewLLMBackend(context.Background(), m.config)
-	if err != nil {
-		logger.Error("ai-agentic-monitor: failed to get LLM backend,", logger.Args("err", err.Error()))
-		return err
+	record := RunRecord{
+		Monitor: m.name,
+		Time:    time.Now(),
+		Model:   m.routing.Triage.GetModel(),
 	}
 
-	agentTools := []tools.Tool{
-		&agents.ScriptExecutor{},
+	answer, err := m.runAgent(m.routing.Triage, session.NewSession(m.name, TriageRole, toolNames(m.tools)), &record)
+	note := ""
+	reason := m.escalationReason(answer, err)
+	if reason != "" && m.routing.ShouldEscalate(reason) {
+		logger.Warn("ai-agentic-monitor: escalating monitor run,", logger.Args("monitor", m.name, "reason", reason, "model", m.routing.Escalation.GetModel()))
+		record.EscalationReason = reason
+		triageSession := record.SessionID
+		escalated, escalationErr := m.runAgent(m.routing.Escalation, session.NewSession(m.name, EscalationRole, toolNames(m.tools)), &record)
+		switch {
+		case escalationErr == nil:
+			answer, err = escalated, nil
+			record.Model = m.routing.Escalation.GetModel()
+			note = fmt.Sprintf("escalated on %s", reason)
+		case err == nil:
+			// The finding of the triage model is still alerted when the escalation fails.
+			logger.Warn("ai-agentic-monitor: escalation failed, keeping the triage answer,", logger.Args("monitor", m.name, "err", escalationErr.Error()))
+			record.Error = fmt.Sprintf("escalation failed: %s", escalationErr)
+			record.SessionID = triageSession
+			note = fmt.Sprintf("escalation on %s failed, answer of the triage model", reason)
+		default:
+			err = escalationErr
+			record.Model = m.routing.Escalation.GetModel()
+		}
 	}
-	agent := agents.NewMonitorAgent(llmbak.GetModel(), agentTools, "output", nil)
-	executor := lcagents.NewExecutor(agent)
-	answer, err := chains.Run(context.Background(), executor, m.prompt)
 	if err != nil {
-		logger.Error("ai-agentic-monitor: failed to run agent,", logger.Args("err", err.Error()))
+		record.Error = err.Error()
+		saveRunRecord(record)
 		return err
 	}
 
+	record.Answer = answer
+	saveRunRecord(record)
 	fmt.Println("ai-agentic-monitor: " + answer)
+	m.raiseAlert(answer, note)
 	return nil
-}
\ No newline at end of file
+}
+
+// runAgent runs the agent with the LLM, the run is persisted in the session
+// so it could be resumed later, the session is referenced in the record.
+func (m *MonitorImpl) runAgent(llmConfig config.LLMConfiger, sess *session.Session, record *RunRecord) (string, error) {
+	record.SessionID = sess.ID
+	return runAgentSession(llmConfig, m.registry, m.tools, sess, map[string]any{"input": m.prompt})
+}
+
+// newLLMModel creates the model of the LLM backend, the tests replace it with a fake model
+var newLLMModel = func(ctx context.Context, llmConfig config.LLMConfiger) (llms.Model, error) {
+	llmbak, err := llmback.NewLLMBackend(ctx, llmConfig)
+	if err != nil {
+		return nil, err
+	}
+	return llmbak.GetModel(), nil
+}
+
+func runAgentSession(llmConfig config.LLMConfiger, registry *agents.ToolRegistry, agentTools []tools.Tool, sess *session.Session, inputs map[string]any) (string, error) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	model, err := newLLMModel(context.Background(), llmConfig)
+	if err != nil {
+		logger.Error("ai-agentic-monitor: failed to get LLM backend,", logger.Args("err", err.Error()))
+		return "", err
+	}
+
+	agentTools = registry.RestrictTools(agentTools, llmConfig.GetUntrusted())
+	agentTools = registry.BindTools(agentTools, sess.Monitor)
+	agent := agents.NewMonitorAgent(model, agentTools, "output", nil,
+		agents.WithScratchPadCompression(model, llmConfig.GetModel(), llmConfig.GetScratchPadTokens()),
+		agents.WithHostProfile(registry.HostProfile()),
+	)
+	store := registry.SessionStore()
+	executor := agents.NewMonitorExecutor(agent, agents.WithSession(store, sess))
+	answer, err := chains.Predict(context.Background(), executor, inputs)
+	pruneSessions(store, registry.SessionsSettings())
+	if err != nil {
+		logger.Error("ai-agentic-monitor: failed to run agent,", logger.Args("session", sess.ID, "err", err.Error()))
+		return "", err
+	}
+	return answer, nil
+}
+
+// pruneSessions removes the old sessions so a daemon does not fill the disk,
+// failures are only logged
+func pruneSessions(store *session.Store, settings config.SessionsSettings) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	maxAge, err := settings.GetMaxAge()
+	if err != nil {
+		maxAge = config.DefaultSessionsMaxAge
+	}
+	if _, err := store.Prune(time.Now(), maxAge, settings.GetMaxCount()); err != nil {
+		logger.Warn("ai-agentic-monitor: failed to prune the sessions,", logger.Args("err", err.Error()))
+	}
+}
+
+// escalationReason returns why the result of a run should be checked by a stronger model,
+// an empty reason means the result could be trusted.
+func (m *MonitorImpl) escalationReason(answer string, err error) string {
+	switch {
+	case errors.Is(err, lcagents.ErrUnableToParseOutput):
+		return config.EscalateOnParseError
+	case errors.Is(err, lcagents.ErrNotFinished):
+		return config.EscalateOnIterationLimit
+	case err != nil:
+		return ""
+	}
+
+	if findingStatus.MatchString(answer) {
+		return config.EscalateOnFinding
+	}
+	for _, rule := range m.severity {
+		if rule.Level == alerts.Info {
+			continue
+		}
+		if matched, _ := regexp.MatchString(rule.Match, answer); matched {
+			return config.EscalateOnFinding
+		}
+	}
+	return ""
+}
+
+// raiseAlert adds an alert with the level of the first severity rule matching
+// the answer, the note tells how the answer was obtained
+func (m *MonitorImpl) raiseAlert(answer, note string) {
+	if m.alerts == nil {
+		return
+	}
+	for _, rule := range m.severity {
+		matched, err := regexp.MatchString(rule.Match, answer)
+		if err != nil || !matched {
+			continue
+		}
+		description := strings.TrimSpace(answer)
+		if note != "" {
+			description += fmt.Sprintf("\n\n(%s)", note)
+		}
+		m.alerts.AddAlert(rule.Level, fmt.Sprintf("monitor %s reported an issue", m.name), description)
+		return
+	}
+}
+
+// definitionPrompt builds the task of the agent from the prompt and thresholds of the definition
+func definitionPrompt(def config.MonitorDefinition) string {
+	if len(def.Thresholds) == 0 {
+		return def.Prompt
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(strings.TrimSpace(def.Prompt))
+	prompt.WriteString("\n\nCheck the following thresholds and report the level of each exceeded one:\n")
+	for _, rule := range def.Thresholds {
+		prompt.WriteString(fmt.Sprintf("- %s %s %g: %s\n", rule.Metric, rule.Operator, rule.Value, rule.Level))
+	}
+	return prompt.String()
+}
@@ -0,0 +1,134 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+// fakeModel answers every prompt with the same text
+type fakeModel struct {
+	answer string
+}
+
+func (f *fakeModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: f.answer}}}, nil
+}
+
+func (f *fakeModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return f.answer, nil
+}
+
+// fakeModels returns the fake model of the LLM configs by model name, the
+// other models fail like an unreachable backend
+func fakeModels(t *testing.T, models map[string]llms.Model) {
+	previous := newLLMModel
+	newLLMModel = func(ctx context.Context, llmConfig config.LLMConfiger) (llms.Model, error) {
+		if model, ok := models[llmConfig.GetModel()]; ok {
+			return model, nil
+		}
+		return nil, errors.New("connection refused")
+	}
+	t.Cleanup(func() { newLLMModel = previous })
+}
+
+func readRunRecords(t *testing.T) []RunRecord {
+	file, err := os.Open(filepath.Join(os.Getenv("HOME"), agents.Catchdir, RunHistoryFile))
+	assert.NoError(t, err)
+	defer file.Close()
+	var records []RunRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record RunRecord
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func newTestMonitor(alertsManager alerts.AlertsManager) *MonitorImpl {
+	registry := agents.NewToolRegistry(nil)
+	return &MonitorImpl{
+		routing: &config.LLMRouting{
+			Triage:     config.LLMConfig{Type: "ollama", Model: "triage"},
+			Escalation: config.LLMConfig{Type: "ollama", Model: "escalation"},
+			EscalateOn: []string{config.EscalateOnFinding},
+		},
+		registry: registry,
+		name:     "disk",
+		prompt:   "check the disks",
+		severity: []config.SeverityRule{{Match: "(?i)full", Level: alerts.Warning}},
+		alerts:   alertsManager,
+	}
+}
+
+func TestRunKeepsTriageAnswerWhenEscalationFails(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fakeModels(t, map[string]llms.Model{
+		"triage": &fakeModel{answer: "Thought: I know the answer\nFinal Answer: status: finding\n/var is full"},
+	})
+	alertsManager := alerts.NewAlertsManager()
+
+	assert.NoError(t, newTestMonitor(alertsManager).Run())
+
+	found := alertsManager.ListAlerts()
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, alerts.Warning, found[0].Level)
+		assert.Contains(t, found[0].Description, "/var is full")
+		assert.Contains(t, found[0].Description, "escalation on finding failed")
+	}
+	records := readRunRecords(t)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "triage", records[0].Model)
+		assert.Equal(t, config.EscalateOnFinding, records[0].EscalationReason)
+		assert.Contains(t, records[0].Answer, "/var is full")
+		assert.Contains(t, records[0].Error, "escalation failed: connection refused")
+		assert.NotEmpty(t, records[0].SessionID)
+	}
+}
+
+func TestRunEscalatesFinding(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fakeModels(t, map[string]llms.Model{
+		"triage":     &fakeModel{answer: "Final Answer: status: finding\n/var is full"},
+		"escalation": &fakeModel{answer: "Final Answer: status: finding\n/var is full of core dumps"},
+	})
+	alertsManager := alerts.NewAlertsManager()
+
+	assert.NoError(t, newTestMonitor(alertsManager).Run())
+
+	found := alertsManager.ListAlerts()
+	if assert.Len(t, found, 1) {
+		assert.Contains(t, found[0].Description, "core dumps")
+		assert.Contains(t, found[0].Description, "(escalated on finding)")
+	}
+	records := readRunRecords(t)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "escalation", records[0].Model)
+		assert.Empty(t, records[0].Error)
+	}
+}
+
+func TestRunFailsWithoutAnswer(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fakeModels(t, nil)
+	alertsManager := alerts.NewAlertsManager()
+
+	assert.Error(t, newTestMonitor(alertsManager).Run())
+	assert.Empty(t, alertsManager.ListAlerts())
+	records := readRunRecords(t)
+	if assert.Len(t, records, 1) {
+		assert.Contains(t, records[0].Error, "connection refused")
+	}
+}
@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/cgroup"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/psi"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// name of the pressure triggers in the run history
+	pressureName = "pressure"
+)
+
+// PressureWatcher registers the pressure stall triggers with the kernel and
+// raises an alert, and optionally starts an investigation, when one fires.
+type PressureWatcher struct {
+	collector *psi.Collector
+	triggers  []psi.Trigger
+	// definitions are the configured triggers by name
+	definitions  map[string]config.PressureTrigger
+	alerts       alerts.AlertsManager
+	investigator *investigator
+	cooldown     time.Duration
+	// lastAlerts are the times of the last alerts by trigger name
+	lastAlerts map[string]time.Time
+}
+
+// NewPressureWatcher creates the watcher of the pressure definition
+func NewPressureWatcher(def config.PressureDefinition, routing *config.LLMRouting, registry *agents.ToolRegistry, alertsManager alerts.AlertsManager) (*PressureWatcher, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := registry.NewToolsByName(def.Tools); err != nil {
+		return nil, fmt.Errorf("pressure: %w", err)
+	}
+
+	fs := procfs.DefaultFS()
+	var hierarchy *cgroup.Hierarchy
+	definitions := make(map[string]config.PressureTrigger, len(def.Triggers))
+	triggers := make([]psi.Trigger, 0, len(def.Triggers))
+	for _, triggerDef := range def.Triggers {
+		stall, window, _ := triggerDef.GetThreshold()
+		trigger := psi.Trigger{Name: triggerDef.Name, Resource: triggerDef.Resource, Cgroup: triggerDef.Cgroup, Kind: triggerDef.Kind, Stall: stall, Window: window}
+		if trigger.Kind == "" {
+			trigger.Kind = psi.KindSome
+		}
+		if err := trigger.Validate(); err != nil {
+			return nil, fmt.Errorf("pressure trigger %q: %w", triggerDef.Name, err)
+		}
+		if trigger.Cgroup != "" && hierarchy == nil {
+			var err error
+			if hierarchy, err = cgroup.NewHierarchy(fs); err != nil {
+				return nil, fmt.Errorf("pressure trigger %q: %w", triggerDef.Name, err)
+			}
+		}
+		definitions[triggerDef.Name] = triggerDef
+		triggers = append(triggers, trigger)
+	}
+
+	cooldown, _ := def.GetCooldown()
+	return &PressureWatcher{
+		collector:    psi.NewCollector(fs, hierarchy),
+		triggers:     triggers,
+		definitions:  definitions,
+		alerts:       alertsManager,
+		investigator: &investigator{routing: routing, registry: registry, tools: def.Tools, alerts: alertsManager},
+		cooldown:     cooldown,
+		lastAlerts:   make(map[string]time.Time),
+	}, nil
+}
+
+// Run watches the triggers until the context is done
+func (w *PressureWatcher) Run(ctx context.Context) error {
+	return w.collector.Watch(ctx, w.triggers, w.fire)
+}
+
+func (w *PressureWatcher) fire(trigger psi.Trigger) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	def := w.definitions[trigger.Name]
+	if last, ok := w.lastAlerts[def.Name]; ok && time.Since(last) < w.cooldown {
+		return
+	}
+	w.lastAlerts[def.Name] = time.Now()
+
+	var cgroups []string
+	if trigger.Cgroup != "" {
+		cgroups = []string{trigger.Cgroup}
+	}
+	samples, err := w.collector.Collect(cgroups)
+	if err != nil {
+		logger.Warn("ai-agentic-monitor: failed to read the pressure,", logger.Args("err", err.Error()))
+	}
+	summary := fmt.Sprintf("pressure trigger %s fired: %s", def.Name, trigger)
+	logger.Warn("ai-agentic-monitor: "+summary, logger.Args("trigger", def.Name))
+	details, _ := json.MarshalIndent(samples, "", "  ")
+	saveRunRecord(RunRecord{
+		Monitor: pressureName + "-" + def.Name,
+		Time:    time.Now(),
+		Model:   "psi",
+		Answer:  summary + "\n" + string(details),
+	})
+
+	if w.alerts != nil {
+		level := def.Level
+		if level == "" {
+			level = alerts.Warning
+		}
+		w.alerts.AddAlert(level, summary, string(details))
+	}
+	if def.Investigate {
+		go w.investigator.investigate(pressureName+"-"+def.Name, pressurePrompt(trigger, samples))
+	}
+}
+
+// pressurePrompt builds the task of the agent investigating the pressure
+func pressurePrompt(trigger psi.Trigger, samples []psi.Sample) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "The kernel reported that the tasks were stalled on %s: the %s.\n", trigger.Resource, trigger)
+	builder.WriteString("\nCurrent pressure stall information, the averages are percentages of the time:\n")
+	for _, sample := range samples {
+		target := "system"
+		if sample.Cgroup != "" {
+			target = "cgroup " + sample.Cgroup
+		}
+		fmt.Fprintf(&builder, "- %s %s: some avg10=%.2f avg60=%.2f", target, sample.Resource, sample.Some.Avg10, sample.Some.Avg60)
+		if sample.Full != nil {
+			fmt.Fprintf(&builder, ", full avg10=%.2f avg60=%.2f", sample.Full.Avg10, sample.Full.Avg60)
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString("\nFind which processes or cgroups cause the pressure and which ones suffer from it, " +
+		"tell if it is still ongoing and what should be done.")
+	return builder.String()
+}
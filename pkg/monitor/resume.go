@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/session"
+
+	"github.com/tmc/langchaingo/tools"
+)
+
+const (
+	// TriageRole is the profile role of the routine runs
+	TriageRole = "triage"
+	// EscalationRole is the profile role of the escalated runs
+	EscalationRole = "escalation"
+)
+
+// ResumeSession continues the investigation of the session from its last step,
+// the optional hint of the operator is appended to the input of the agent.
+func ResumeSession(routing *config.LLMRouting, registry *agents.ToolRegistry, id, hint string) (string, error) {
+	sess, err := registry.SessionStore().Load(id)
+	if err != nil {
+		return "", err
+	}
+	if sess.Status == session.Finished && hint == "" {
+		return "", fmt.Errorf("session %s is already finished, give a hint to continue it", id)
+	}
+
+	llmConfig := routing.Triage
+	if sess.Profile == EscalationRole && routing.Escalation != nil {
+		llmConfig = routing.Escalation
+	}
+
+	agentTools, err := registry.NewToolsByName(sess.Tools)
+	if err != nil {
+		return "", fmt.Errorf("session %s: %w", id, err)
+	}
+
+	sess.AppendHint("input", hint)
+	inputs := make(map[string]any, len(sess.Inputs))
+	for key, value := range sess.Inputs {
+		inputs[key] = value
+	}
+
+	answer, err := runAgentSession(llmConfig, registry, agentTools, sess, inputs)
+	saveRunRecord(RunRecord{
+		Monitor:   sess.Monitor,
+		Time:      sess.UpdatedAt,
+		Model:     llmConfig.GetModel(),
+		SessionID: sess.ID,
+		Answer:    answer,
+		Error:     errorString(err),
+	})
+	return answer, err
+}
+
+func toolNames(agentTools []tools.Tool) []string {
+	names := make([]string, 0, len(agentTools))
+	for _, tool := range agentTools {
+		names = append(names, tool.Name())
+	}
+	return names
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
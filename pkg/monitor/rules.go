@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/agents"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/alerts"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/rules"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// a series without sample within this many intervals is stale
+	staleIntervals = 3
+	// history of the metric given to the investigations
+	ruleHistory = 30 * time.Minute
+)
+
+// engineRule is a rule of the engine with its definition
+type engineRule struct {
+	def          config.RuleDefinition
+	evaluator    *rules.Evaluator
+	investigator *investigator
+}
+
+// RuleEngine evaluates the rules against the collected metrics at every
+// interval, the firing and resolved rules are alerted without the LLM so
+// they keep working when the LLM provider is down.
+type RuleEngine struct {
+	store    *metrics.Store
+	interval time.Duration
+	rules    []*engineRule
+	alerts   alerts.AlertsManager
+}
+
+// NewRuleEngine parses the rules and creates the engine evaluating them on
+// the metrics of the collector of the registry.
+func NewRuleEngine(defs []config.RuleDefinition, routing *config.LLMRouting, registry *agents.ToolRegistry, alertsManager alerts.AlertsManager) (*RuleEngine, error) {
+	collector := registry.MetricsCollector()
+	engine := &RuleEngine{
+		store:    collector.Store(),
+		interval: collector.Interval(),
+		alerts:   alertsManager,
+	}
+	for _, def := range defs {
+		if err := def.Validate(); err != nil {
+			return nil, err
+		}
+		rule, err := rules.NewRule(def.Name, def.Expr, def.Clear)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		if def.Investigate {
+			if _, err := registry.NewToolsByName(def.Tools); err != nil {
+				return nil, fmt.Errorf("rule %q: %w", def.Name, err)
+			}
+		}
+		engine.rules = append(engine.rules, &engineRule{
+			def:          def,
+			evaluator:    rules.NewEvaluator(rule),
+			investigator: &investigator{routing: routing, registry: registry, tools: def.Tools, alerts: alertsManager},
+		})
+	}
+	return engine, nil
+}
+
+// Run evaluates the rules at every interval until the context is done
+func (e *RuleEngine) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			e.Evaluate(now)
+		}
+	}
+}
+
+// Evaluate evaluates all the rules on the latest samples
+func (e *RuleEngine) Evaluate(now time.Time) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	for _, rule := range e.rules {
+		samples, err := e.latest(rule.evaluator.Rule().Expr, now)
+		if err != nil {
+			logger.Warn("ai-agentic-monitor: failed to evaluate rule,", logger.Args("rule", rule.def.Name, "err", err.Error()))
+			continue
+		}
+		for _, transition := range rule.evaluator.Evaluate(samples) {
+			e.handle(rule, transition)
+		}
+	}
+}
+
+// latest returns the latest sample of the series matching the expression
+func (e *RuleEngine) latest(expr rules.Expr, now time.Time) ([]rules.Sample, error) {
+	results, err := e.store.Query(metrics.Query{
+		Name: expr.Metric,
+		From: now.Add(-staleIntervals * e.interval),
+		To:   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]rules.Sample, 0, len(results))
+	for _, result := range results {
+		if len(result.Points) == 0 || !expr.Matches(result.Name, result.Labels) {
+			continue
+		}
+		point := result.Points[len(result.Points)-1]
+		samples = append(samples, rules.Sample{Labels: result.Labels, Time: point.Time, Value: point.Value})
+	}
+	return samples, nil
+}
+
+func (e *RuleEngine) handle(rule *engineRule, transition rules.Transition) {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	expr := rule.evaluator.Rule().Expr
+	series := expr.Metric + transition.Labels.String()
+	var summary string
+	switch {
+	case transition.Firing:
+		summary = fmt.Sprintf("rule %s firing: %s is %g (%s)", rule.def.Name, series, transition.Value, expr)
+	case transition.Stale:
+		summary = fmt.Sprintf("rule %s resolved: %s has no sample anymore", rule.def.Name, series)
+	default:
+		summary = fmt.Sprintf("rule %s resolved: %s is %g", rule.def.Name, series, transition.Value)
+	}
+	details := summary
+	if transition.Firing {
+		details += fmt.Sprintf("\nthe condition holds since %s", transition.Since.Format(time.RFC3339))
+	}
+	if rule.def.Description != "" {
+		details += "\n" + rule.def.Description
+	}
+	if transition.Firing {
+		logger.Warn("ai-agentic-monitor: "+summary, logger.Args("rule", rule.def.Name))
+	} else {
+		logger.Info("ai-agentic-monitor: "+summary, logger.Args("rule", rule.def.Name))
+	}
+	saveRunRecord(RunRecord{
+		Monitor: rule.def.Name,
+		Time:    time.Now(),
+		Model:   "rules",
+		Answer:  details,
+	})
+
+	if e.alerts != nil {
+		level := alerts.Info
+		if transition.Firing {
+			level = rule.def.GetLevel()
+		}
+		e.alerts.AddAlert(level, summary, details)
+	}
+	if transition.Firing && rule.def.Investigate {
+		go rule.investigator.investigate(rule.def.Name, e.rulePrompt(rule, transition))
+	}
+}
+
+// rulePrompt builds the task of the agent investigating a firing rule, with
+// the recent history of the series.
+func (e *RuleEngine) rulePrompt(rule *engineRule, transition rules.Transition) string {
+	expr := rule.evaluator.Rule().Expr
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "The rule %s fired: %s%s is %g, the condition is %s since %s.\n",
+		rule.def.Name, expr.Metric, transition.Labels, transition.Value, expr, transition.Since.Format(time.RFC3339))
+	if rule.def.Description != "" {
+		fmt.Fprintf(&builder, "%s\n", rule.def.Description)
+	}
+
+	now := time.Now()
+	results, err := e.store.Query(metrics.Query{
+		Name:      expr.Metric,
+		Labels:    transition.Labels,
+		From:      now.Add(-ruleHistory),
+		To:        now,
+		Step:      time.Minute,
+		Aggregate: metrics.AggregateMax,
+	})
+	if err == nil && len(results) > 0 {
+		fmt.Fprintf(&builder, "\nMaximum of the metric by minute over the last %s:\n", ruleHistory)
+		for _, point := range results[0].Points {
+			fmt.Fprintf(&builder, "- %s %g\n", point.Time.Local().Format("15:04"), point.Value)
+		}
+	}
+	builder.WriteString("\nFind the cause of the metric crossing the threshold, tell if it is still getting worse " +
+		"and what should be done.")
+	return builder.String()
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/rules"
+)
+
+func TestRuleEngineLatest(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	store := metrics.NewStore(100, 0)
+	for i := 10; i >= 1; i-- {
+		at := now.Add(-time.Duration(i) * 10 * time.Second)
+		store.Append("disk.used_percent", metrics.Labels{"mount": "/"}, at, float64(90-i))
+		store.Append("disk.used_percent", metrics.Labels{"mount": "/var/log"}, at, float64(80-i))
+		store.Append("disk.used_percent", metrics.Labels{"mount": "/boot"}, at, float64(50-i))
+		store.Append("disk.avail_bytes", metrics.Labels{"mount": "/"}, at, 1000)
+	}
+	// the last sample of the unmounted disk is older than the stale intervals
+	store.Append("disk.used_percent", metrics.Labels{"mount": "/mnt"}, now.Add(-5*time.Minute), 99)
+	engine := &RuleEngine{store: store, interval: 10 * time.Second}
+
+	tests := []struct {
+		expr string
+		want []rules.Sample
+	}{
+		{
+			expr: "disk.used_percent > 90",
+			want: []rules.Sample{
+				{Labels: metrics.Labels{"mount": "/"}, Time: now.Add(-10 * time.Second), Value: 89},
+				{Labels: metrics.Labels{"mount": "/boot"}, Time: now.Add(-10 * time.Second), Value: 49},
+				{Labels: metrics.Labels{"mount": "/var/log"}, Time: now.Add(-10 * time.Second), Value: 79},
+			},
+		},
+		{
+			expr: `disk.used_percent{mount=~"/var.*"} > 90`,
+			want: []rules.Sample{
+				{Labels: metrics.Labels{"mount": "/var/log"}, Time: now.Add(-10 * time.Second), Value: 79},
+			},
+		},
+		{
+			expr: `disk.used_percent{mount!="/boot", mount!~"/var.*"} > 90`,
+			want: []rules.Sample{
+				{Labels: metrics.Labels{"mount": "/"}, Time: now.Add(-10 * time.Second), Value: 89},
+			},
+		},
+		{
+			expr: `disk.used_percent{mount="/mnt"} > 90`,
+			want: []rules.Sample{},
+		},
+		{
+			expr: "memory.used_percent > 90",
+			want: []rules.Sample{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := rules.Parse(tt.expr)
+			assert.NoError(t, err)
+			samples, err := engine.latest(expr, now)
+			assert.NoError(t, err)
+			sort.Slice(samples, func(i, j int) bool { return samples[i].Labels["mount"] < samples[j].Labels["mount"] })
+			assert.Equal(t, tt.want, samples)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+// DNSResult is the answer of the resolver
+type DNSResult struct {
+	Resolver   string   `json:"resolver"`
+	RecordType string   `json:"record_type"`
+	Answers    []string `json:"answers"`
+}
+
+// probeDNS resolves the target with the resolver of the probe and checks the
+// expected values are all in the answer.
+func probeDNS(ctx context.Context, spec config.ProbeSpec) (*DNSResult, error) {
+	result := &DNSResult{Resolver: "system", RecordType: strings.ToUpper(spec.RecordType)}
+	if result.RecordType == "" {
+		result.RecordType = "A"
+	}
+
+	resolver := net.DefaultResolver
+	if spec.Resolver != "" {
+		result.Resolver = spec.Resolver
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				dialer := net.Dialer{}
+				return dialer.DialContext(ctx, network, spec.Resolver)
+			},
+		}
+	}
+
+	var err error
+	switch result.RecordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if result.RecordType == "AAAA" {
+			network = "ip6"
+		}
+		var ips []net.IP
+		if ips, err = resolver.LookupIP(ctx, network, spec.Target); err == nil {
+			for _, ip := range ips {
+				result.Answers = append(result.Answers, ip.String())
+			}
+		}
+	case "CNAME":
+		var cname string
+		if cname, err = resolver.LookupCNAME(ctx, spec.Target); err == nil {
+			result.Answers = []string{cname}
+		}
+	case "MX":
+		var records []*net.MX
+		if records, err = resolver.LookupMX(ctx, spec.Target); err == nil {
+			for _, record := range records {
+				result.Answers = append(result.Answers, record.Host)
+			}
+		}
+	case "TXT":
+		result.Answers, err = resolver.LookupTXT(ctx, spec.Target)
+	case "NS":
+		var records []*net.NS
+		if records, err = resolver.LookupNS(ctx, spec.Target); err == nil {
+			for _, record := range records {
+				result.Answers = append(result.Answers, record.Host)
+			}
+		}
+	}
+	if err != nil {
+		return result, err
+	}
+	sort.Strings(result.Answers)
+
+	for _, expected := range spec.Expect {
+		if !containsAnswer(result.Answers, expected) {
+			return result, fmt.Errorf("answer %v does not contain %q", result.Answers, expected)
+		}
+	}
+	return result, nil
+}
+
+// containsAnswer compares the names without case and trailing dot
+func containsAnswer(answers []string, expected string) bool {
+	expected = strings.TrimSuffix(strings.ToLower(expected), ".")
+	for _, answer := range answers {
+		if strings.TrimSuffix(strings.ToLower(answer), ".") == expected {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+const (
+	// maximal size of the body read for the assertions
+	maxBodySize = 1 << 20
+	// maximal size of the body excerpt of the failed assertions
+	maxBodyExcerpt = 256
+)
+
+// HTTPResult describes the response and the phases of the request
+type HTTPResult struct {
+	Status      int    `json:"status"`
+	Proto       string `json:"proto"`
+	ContentType string `json:"content_type,omitempty"`
+	BodyBytes   int    `json:"body_bytes"`
+	// BodyExcerpt is the beginning of the body when an assertion failed
+	BodyExcerpt string `json:"body_excerpt,omitempty"`
+	// the phases of the last request in milliseconds, a reused connection has no dns, connect or tls phase
+	DNSMs       float64 `json:"dns_ms,omitempty"`
+	ConnectMs   float64 `json:"connect_ms,omitempty"`
+	TLSMs       float64 `json:"tls_ms,omitempty"`
+	FirstByteMs float64 `json:"first_byte_ms"`
+	Redirects   int     `json:"redirects,omitempty"`
+}
+
+// probeHTTP sends a GET request to the target and checks the status and the body
+func probeHTTP(ctx context.Context, spec config.ProbeSpec) (*HTTPResult, *TLSResult, error) {
+	result := &HTTPResult{}
+	var dnsStart, connectStart, tlsStart, start time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { result.DNSMs = milliseconds(time.Since(dnsStart)) },
+		ConnectStart:      func(string, string) { connectStart = time.Now() },
+		ConnectDone:       func(string, string, error) { result.ConnectMs = milliseconds(time.Since(connectStart)) },
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { result.TLSMs = milliseconds(time.Since(tlsStart)) },
+		GotFirstResponseByte: func() {
+			result.FirstByteMs = milliseconds(time.Since(start))
+		},
+	}
+
+	request, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, spec.Target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, value := range spec.Headers {
+		request.Header.Set(name, value)
+	}
+	if host, ok := spec.Headers["Host"]; ok {
+		request.Host = host
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{ServerName: spec.ServerName, InsecureSkipVerify: spec.InsecureSkipVerify}
+	transport.DisableKeepAlives = true
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			result.Redirects = len(via)
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+
+	start = time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return result, nil, err
+	}
+	defer response.Body.Close()
+
+	result.Status = response.StatusCode
+	result.Proto = response.Proto
+	result.ContentType = response.Header.Get("Content-Type")
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodySize))
+	if err != nil {
+		return result, nil, fmt.Errorf("failed to read the body: %w", err)
+	}
+	result.BodyBytes = len(body)
+
+	var tlsResult *TLSResult
+	if response.TLS != nil {
+		serverName := spec.ServerName
+		if serverName == "" {
+			serverName = response.Request.URL.Hostname()
+		}
+		tlsResult = describeTLS(*response.TLS, serverName)
+	}
+
+	if err := checkResponse(spec, response.StatusCode, body); err != nil {
+		result.BodyExcerpt = excerpt(body)
+		return result, tlsResult, err
+	}
+	return result, tlsResult, nil
+}
+
+// checkResponse runs the assertions of the probe on the response
+func checkResponse(spec config.ProbeSpec, status int, body []byte) error {
+	if len(spec.ExpectStatus) > 0 {
+		if !slices.Contains(spec.ExpectStatus, status) {
+			return fmt.Errorf("unexpected status %d, expected %v", status, spec.ExpectStatus)
+		}
+	} else if status < 200 || status >= 400 {
+		return fmt.Errorf("unexpected status %d", status)
+	}
+	if spec.BodyContains != "" && !strings.Contains(string(body), spec.BodyContains) {
+		return fmt.Errorf("body does not contain %q", spec.BodyContains)
+	}
+	if spec.BodyMatches != "" {
+		// The expression was compiled when the spec was validated.
+		if !regexp.MustCompile(spec.BodyMatches).Match(body) {
+			return fmt.Errorf("body does not match %q", spec.BodyMatches)
+		}
+	}
+	return nil
+}
+
+func excerpt(body []byte) string {
+	text := strings.ToValidUTF8(string(body), "?")
+	if len(text) > maxBodyExcerpt {
+		text = strings.ToValidUTF8(text[:maxBodyExcerpt], "") + "..."
+	}
+	return text
+}
@@ -0,0 +1,73 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+// Result is the outcome of a probe, Error explains why the probe failed
+type Result struct {
+	Type      string    `json:"type"`
+	Target    string    `json:"target"`
+	Time      time.Time `json:"time"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs float64   `json:"latency_ms"`
+	// Address is the address the tcp and tls probes connected to
+	Address string      `json:"address,omitempty"`
+	HTTP    *HTTPResult `json:"http,omitempty"`
+	TLS     *TLSResult  `json:"tls,omitempty"`
+	DNS     *DNSResult  `json:"dns,omitempty"`
+}
+
+// Run runs the probe within its timeout, a probe never returns an error, the
+// failures are reported by the result.
+func Run(ctx context.Context, spec config.ProbeSpec) Result {
+	result := Result{Type: spec.Type, Target: spec.Target, Time: time.Now()}
+	if err := spec.Validate(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	timeout, _ := spec.GetTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var err error
+	start := time.Now()
+	switch spec.Type {
+	case config.ProbeHTTP:
+		result.HTTP, result.TLS, err = probeHTTP(ctx, spec)
+	case config.ProbeTCP:
+		result.Address, err = probeTCP(ctx, spec)
+	case config.ProbeTLS:
+		result.Address, result.TLS, err = probeTLS(ctx, spec)
+	case config.ProbeDNS:
+		result.DNS, err = probeDNS(ctx, spec)
+	}
+	result.LatencyMs = milliseconds(time.Since(start))
+	if err == nil && result.TLS != nil && spec.MinValidDays > 0 && result.TLS.DaysLeft < spec.MinValidDays {
+		err = fmt.Errorf("certificate of %s expires in %d days, less than %d days", result.TLS.Subject, result.TLS.DaysLeft, spec.MinValidDays)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+	}
+	return result
+}
+
+// Summary describes the result in one line, like the subject of an alert
+func (r Result) Summary() string {
+	if r.Success {
+		return fmt.Sprintf("%s probe of %s succeeded in %.1fms", r.Type, r.Target, r.LatencyMs)
+	}
+	return fmt.Sprintf("%s probe of %s failed: %s", r.Type, r.Target, r.Error)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d.Microseconds())/10) / 100
+}
@@ -0,0 +1,314 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+func TestHTTPProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status": "ok", "version": "1.4.2"}`))
+		case "/auth":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("welcome"))
+		case "/old":
+			http.Redirect(w, r, "/health", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("upstream is down"))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		spec    config.ProbeSpec
+		success bool
+		status  int
+		err     string
+	}{
+		{
+			name:    "healthy",
+			spec:    config.ProbeSpec{Target: server.URL + "/health", BodyContains: `"ok"`, BodyMatches: `version": "1\.\d+`},
+			success: true,
+			status:  200,
+		},
+		{
+			name:   "server error",
+			spec:   config.ProbeSpec{Target: server.URL + "/down"},
+			status: 503,
+			err:    "unexpected status 503",
+		},
+		{
+			name:    "expected status",
+			spec:    config.ProbeSpec{Target: server.URL + "/down", ExpectStatus: []int{503}},
+			success: true,
+			status:  503,
+		},
+		{
+			name:   "body does not contain",
+			spec:   config.ProbeSpec{Target: server.URL + "/health", BodyContains: "degraded"},
+			status: 200,
+			err:    `body does not contain "degraded"`,
+		},
+		{
+			name:   "body does not match",
+			spec:   config.ProbeSpec{Target: server.URL + "/health", BodyMatches: `version": "2\.`},
+			status: 200,
+			err:    "body does not match",
+		},
+		{
+			name:    "headers",
+			spec:    config.ProbeSpec{Target: server.URL + "/auth", Headers: map[string]string{"Authorization": "Bearer token"}},
+			success: true,
+			status:  200,
+		},
+		{
+			name:   "missing header",
+			spec:   config.ProbeSpec{Target: server.URL + "/auth"},
+			status: 401,
+			err:    "unexpected status 401",
+		},
+		{
+			name:    "redirect",
+			spec:    config.ProbeSpec{Target: server.URL + "/old"},
+			success: true,
+			status:  200,
+		},
+		{
+			name: "invalid target",
+			spec: config.ProbeSpec{Target: "ftp://example.com"},
+			err:  "is not a http or https URL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Type = config.ProbeHTTP
+			result := Run(context.Background(), tt.spec)
+			assert.Equal(t, tt.success, result.Success, result.Error)
+			if tt.err != "" {
+				assert.Contains(t, result.Error, tt.err)
+			}
+			if tt.status == 0 {
+				assert.Nil(t, result.HTTP)
+				return
+			}
+			if assert.NotNil(t, result.HTTP) {
+				assert.Equal(t, tt.status, result.HTTP.Status)
+				// the body is only reported to explain a failed assertion
+				if tt.success || result.HTTP.BodyBytes == 0 {
+					assert.Empty(t, result.HTTP.BodyExcerpt)
+				} else {
+					assert.NotEmpty(t, result.HTTP.BodyExcerpt)
+				}
+			}
+		})
+	}
+
+	result := Run(context.Background(), config.ProbeSpec{Type: config.ProbeHTTP, Target: server.URL + "/old"})
+	if assert.NotNil(t, result.HTTP) {
+		assert.Equal(t, 1, result.HTTP.Redirects)
+		assert.Equal(t, "application/json", result.HTTP.ContentType)
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := listener.Addr().String()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	result := Run(context.Background(), config.ProbeSpec{Type: config.ProbeTCP, Target: address})
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, address, result.Address)
+	assert.Contains(t, result.Summary(), "succeeded")
+
+	// nothing listens on the port once the listener is closed
+	listener.Close()
+	result = Run(context.Background(), config.ProbeSpec{Type: config.ProbeTCP, Target: address, Timeout: "1s"})
+	assert.False(t, result.Success)
+	assert.NotEmpty(t, result.Error)
+	assert.Contains(t, result.Summary(), "failed")
+
+	result = Run(context.Background(), config.ProbeSpec{Type: config.ProbeTCP, Target: "localhost"})
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "is not host:port")
+}
+
+func TestTLSProbe(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	// the certificate of the test server is not signed by a trusted authority
+	result := Run(context.Background(), config.ProbeSpec{Type: config.ProbeTLS, Target: address})
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "certificate is not trusted")
+	if assert.NotNil(t, result.TLS) {
+		assert.False(t, result.TLS.Verified)
+		assert.NotEmpty(t, result.TLS.VerifyError)
+		assert.NotEmpty(t, result.TLS.Chain, "the chain of an untrusted certificate is reported")
+		assert.Equal(t, "127.0.0.1", result.TLS.ServerName)
+	}
+
+	result = Run(context.Background(), config.ProbeSpec{Type: config.ProbeTLS, Target: address, InsecureSkipVerify: true})
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, address, result.Address)
+	if assert.NotNil(t, result.TLS) {
+		assert.NotEmpty(t, result.TLS.Version)
+		assert.NotEmpty(t, result.TLS.CipherSuite)
+		assert.Greater(t, result.TLS.DaysLeft, 0)
+	}
+
+	// the certificate expires sooner than required
+	result = Run(context.Background(), config.ProbeSpec{Type: config.ProbeTLS, Target: address, InsecureSkipVerify: true, MinValidDays: 1000000})
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "less than 1000000 days")
+
+	// https probes report the certificate of the server as well
+	result = Run(context.Background(), config.ProbeSpec{Type: config.ProbeHTTP, Target: server.URL, InsecureSkipVerify: true})
+	assert.True(t, result.Success, result.Error)
+	if assert.NotNil(t, result.TLS) {
+		assert.False(t, result.TLS.Verified)
+	}
+	result = Run(context.Background(), config.ProbeSpec{Type: config.ProbeHTTP, Target: server.URL})
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "certificate")
+}
+
+// serveDNS answers the A queries of the names of records with their address,
+// the other names do not exist.
+func serveDNS(t *testing.T, records map[string]net.IP) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			query := buffer[:n]
+			if len(query) < 12 {
+				continue
+			}
+			// the question starts after the header, its name is a list of labels
+			var labels []string
+			offset := 12
+			for offset < len(query) && query[offset] != 0 {
+				size := int(query[offset])
+				labels = append(labels, string(query[offset+1:offset+1+size]))
+				offset += 1 + size
+			}
+			offset += 5 // the zero length label, the type and the class
+			if offset > len(query) {
+				continue
+			}
+			ip, ok := records[strings.ToLower(strings.Join(labels, "."))]
+			qtype := binary.BigEndian.Uint16(query[offset-4:])
+
+			response := make([]byte, 12, 512)
+			copy(response, query[:2])
+			flags := uint16(0x8180)
+			if !ok {
+				flags |= 3 // NXDOMAIN
+			}
+			binary.BigEndian.PutUint16(response[2:], flags)
+			binary.BigEndian.PutUint16(response[4:], 1)
+			response = append(response, query[12:offset]...)
+			if ok && qtype == 1 {
+				binary.BigEndian.PutUint16(response[6:], 1)
+				// the name of the answer points to the question
+				response = append(response, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+				response = append(response, ip.To4()...)
+			}
+			conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSProbe(t *testing.T) {
+	resolver := serveDNS(t, map[string]net.IP{"app.probe.test": net.ParseIP("10.1.2.3")})
+
+	tests := []struct {
+		name    string
+		spec    config.ProbeSpec
+		success bool
+		answers []string
+		err     string
+	}{
+		{
+			name:    "resolved",
+			spec:    config.ProbeSpec{Target: "app.probe.test", Expect: []string{"10.1.2.3"}},
+			success: true,
+			answers: []string{"10.1.2.3"},
+		},
+		{
+			name:    "expected address missing",
+			spec:    config.ProbeSpec{Target: "app.probe.test", RecordType: "a", Expect: []string{"10.9.9.9"}},
+			answers: []string{"10.1.2.3"},
+			err:     `does not contain "10.9.9.9"`,
+		},
+		{
+			name: "unknown name",
+			spec: config.ProbeSpec{Target: "missing.probe.test"},
+			err:  "no such host",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Type = config.ProbeDNS
+			tt.spec.Resolver = resolver
+			tt.spec.Timeout = "2s"
+			result := Run(context.Background(), tt.spec)
+			assert.Equal(t, tt.success, result.Success, result.Error)
+			if tt.err != "" {
+				assert.Contains(t, result.Error, tt.err)
+			}
+			if assert.NotNil(t, result.DNS) {
+				assert.Equal(t, resolver, result.DNS.Resolver)
+				assert.Equal(t, "A", result.DNS.RecordType)
+				assert.Equal(t, tt.answers, result.DNS.Answers)
+			}
+		})
+	}
+
+	result := Run(context.Background(), config.ProbeSpec{Type: config.ProbeDNS, Target: "app.probe.test", RecordType: "SRV"})
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "unsupported dns record type")
+}
+
+func TestContainsAnswer(t *testing.T) {
+	answers := []string{"mail.example.com.", "10.0.0.1"}
+	assert.True(t, containsAnswer(answers, "MAIL.example.com"))
+	assert.True(t, containsAnswer(answers, "10.0.0.1"))
+	assert.False(t, containsAnswer(answers, "example.com"))
+}
@@ -0,0 +1,19 @@
+package probe
+
+import (
+	"context"
+	"net"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+// probeTCP connects to the target and returns the address it connected to
+func probeTCP(ctx context.Context, spec config.ProbeSpec) (string, error) {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", spec.Target)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.RemoteAddr().String(), nil
+}
@@ -0,0 +1,103 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/config"
+)
+
+// TLSResult describes the handshake and the certificate chain presented by the server
+type TLSResult struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	ServerName  string `json:"server_name"`
+	// Subject, NotAfter and DaysLeft describe the leaf certificate
+	Subject  string        `json:"subject"`
+	DNSNames []string      `json:"dns_names,omitempty"`
+	NotAfter time.Time     `json:"not_after"`
+	DaysLeft int           `json:"days_left"`
+	Verified bool          `json:"verified"`
+	Chain    []Certificate `json:"chain"`
+	// VerifyError explains why the chain is not trusted
+	VerifyError string `json:"verify_error,omitempty"`
+}
+
+// Certificate is a certificate of the chain presented by the server
+type Certificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+	Serial    string    `json:"serial"`
+}
+
+// probeTLS runs a handshake with the target, the chain is verified after the
+// handshake so that the chain of an invalid certificate is reported as well.
+func probeTLS(ctx context.Context, spec config.ProbeSpec) (string, *TLSResult, error) {
+	host, _, err := net.SplitHostPort(spec.Target)
+	if err != nil {
+		return "", nil, err
+	}
+	serverName := spec.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+
+	dialer := tls.Dialer{Config: &tls.Config{ServerName: serverName, InsecureSkipVerify: true}}
+	conn, err := dialer.DialContext(ctx, "tcp", spec.Target)
+	if err != nil {
+		return "", nil, err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	result := describeTLS(state, serverName)
+	if result.VerifyError != "" && !spec.InsecureSkipVerify {
+		return conn.RemoteAddr().String(), result, fmt.Errorf("certificate is not trusted: %s", result.VerifyError)
+	}
+	return conn.RemoteAddr().String(), result, nil
+}
+
+// describeTLS describes the connection state and verifies its chain for serverName
+func describeTLS(state tls.ConnectionState, serverName string) *TLSResult {
+	result := &TLSResult{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  serverName,
+	}
+	if len(state.PeerCertificates) == 0 {
+		result.VerifyError = "no certificate presented"
+		return result
+	}
+
+	leaf := state.PeerCertificates[0]
+	result.Subject = leaf.Subject.String()
+	result.DNSNames = leaf.DNSNames
+	result.NotAfter = leaf.NotAfter
+	result.DaysLeft = int(time.Until(leaf.NotAfter).Hours() / 24)
+	for _, cert := range state.PeerCertificates {
+		result.Chain = append(result.Chain, Certificate{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+			Serial:    cert.SerialNumber.String(),
+		})
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: serverName, Intermediates: intermediates}); err != nil {
+		result.VerifyError = err.Error()
+	} else {
+		result.Verified = true
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+)
+
+// DiskStat is a line of /proc/diskstats, the times are in milliseconds
+type DiskStat struct {
+	Major          uint64 `json:"major"`
+	Minor          uint64 `json:"minor"`
+	Device         string `json:"device"`
+	Reads          uint64 `json:"reads"`
+	ReadsMerged    uint64 `json:"reads_merged"`
+	SectorsRead    uint64 `json:"sectors_read"`
+	ReadTime       uint64 `json:"read_ms"`
+	Writes         uint64 `json:"writes"`
+	WritesMerged   uint64 `json:"writes_merged"`
+	SectorsWritten uint64 `json:"sectors_written"`
+	WriteTime      uint64 `json:"write_ms"`
+	InFlight       uint64 `json:"in_flight"`
+	IOTime         uint64 `json:"io_ms"`
+	WeightedIOTime uint64 `json:"weighted_io_ms"`
+	// Rotational is read from /sys/block, nil when unknown
+	Rotational *bool `json:"rotational,omitempty"`
+}
+
+// DiskStats returns the statistics of the block devices, the devices without
+// any IO like unused loop devices are skipped.
+func (fs FS) DiskStats() ([]DiskStat, error) {
+	data, err := os.ReadFile(fs.ProcPath("diskstats"))
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []DiskStat
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			continue
+		}
+		counters, err := parseUints(append(fields[:2:2], fields[3:14]...))
+		if err != nil {
+			continue
+		}
+		stat := DiskStat{
+			Major: counters[0], Minor: counters[1], Device: fields[2],
+			Reads: counters[2], ReadsMerged: counters[3], SectorsRead: counters[4], ReadTime: counters[5],
+			Writes: counters[6], WritesMerged: counters[7], SectorsWritten: counters[8], WriteTime: counters[9],
+			InFlight: counters[10], IOTime: counters[11], WeightedIOTime: counters[12],
+		}
+		if stat.Reads == 0 && stat.Writes == 0 {
+			continue
+		}
+		if rotational, err := readUint(fs.SysPath("block", stat.Device, "queue", "rotational")); err == nil {
+			isRotational := rotational == 1
+			stat.Rotational = &isRotational
+		}
+		stats = append(stats, stat)
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,260 @@
+package procfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/mathutil"
+)
+
+const (
+	SortByCPU = "cpu"
+	SortByRSS = "rss"
+	SortByFDs = "fds"
+	SortByIO  = "io"
+
+	// maximal depth of the parent chain of a described process
+	maxParentChain = 32
+)
+
+// ProcessSummary is one entry of the top processes
+type ProcessSummary struct {
+	PID        int     `json:"pid"`
+	PPID       int     `json:"ppid"`
+	Comm       string  `json:"comm"`
+	State      string  `json:"state"`
+	CPUPercent float64 `json:"cpu_percent"`
+	RSSBytes   uint64  `json:"rss_bytes"`
+	FDs        int     `json:"fds"`
+	// IOBytesPerSec is the disk read and write rate, -1 when /proc/[pid]/io is not readable
+	IOBytesPerSec float64 `json:"io_bytes_per_sec"`
+	Threads       int64   `json:"threads"`
+	Cmdline       string  `json:"cmdline,omitempty"`
+}
+
+// ProcessDetail is the description of one process
+type ProcessDetail struct {
+	ProcessSummary
+	UID         string               `json:"uid,omitempty"`
+	Exe         string               `json:"exe,omitempty"`
+	Cwd         string               `json:"cwd,omitempty"`
+	Cgroups     []string             `json:"cgroups,omitempty"`
+	StartedAt   time.Time            `json:"started_at"`
+	IO          *ProcIO              `json:"io,omitempty"`
+	Limits      map[string]ProcLimit `json:"limits,omitempty"`
+	ParentChain []ParentProcess      `json:"parent_chain"`
+	Status      map[string]string    `json:"status,omitempty"`
+}
+
+// ParentProcess is one ancestor of a described process
+type ParentProcess struct {
+	PID     int    `json:"pid"`
+	Comm    string `json:"comm"`
+	Cmdline string `json:"cmdline,omitempty"`
+}
+
+type processSample struct {
+	stat ProcStat
+	io   *ProcIO
+}
+
+// TopProcesses returns the top n processes sorted by cpu, rss, fds or io. The cpu and io
+// rates are measured between two samples taken interval apart.
+func (fs FS) TopProcesses(ctx context.Context, sortBy string, n int, interval time.Duration) ([]ProcessSummary, error) {
+	switch sortBy {
+	case SortByCPU, SortByRSS, SortByFDs, SortByIO:
+	default:
+		return nil, fmt.Errorf("unknown sort key %q, use one of cpu, rss, fds or io", sortBy)
+	}
+
+	first := fs.sampleProcesses()
+	start := time.Now()
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+	}
+	second := fs.sampleProcesses()
+	return fs.rankProcesses(first, second, time.Since(start).Seconds(), sortBy, n)
+}
+
+// rankProcesses returns the top n processes of the second sample, with their
+// rates since the first sample taken elapsed seconds before
+func (fs FS) rankProcesses(first, second map[int]processSample, elapsed float64, sortBy string, n int) ([]ProcessSummary, error) {
+	if len(second) == 0 {
+		return nil, fmt.Errorf("no process found in %s", fs.Proc)
+	}
+
+	pageSize := uint64(os.Getpagesize())
+	summaries := make([]ProcessSummary, 0, len(second))
+	for pid, sample := range second {
+		summary := ProcessSummary{
+			PID:           pid,
+			PPID:          sample.stat.PPID,
+			Comm:          sample.stat.Comm,
+			State:         sample.stat.State,
+			RSSBytes:      uint64(max(sample.stat.RSSPages, 0)) * pageSize,
+			IOBytesPerSec: -1,
+			Threads:       sample.stat.NumThreads,
+		}
+		// a pid reused between the samples is another process, it has no rate yet
+		if previous, ok := first[pid]; ok && previous.stat.StartTime == sample.stat.StartTime && elapsed > 0 {
+			ticks := float64(counterDelta(sample.stat.UTime+sample.stat.STime, previous.stat.UTime+previous.stat.STime))
+			summary.CPUPercent = mathutil.Round2(ticks / UserHZ / elapsed * 100)
+			if sample.io != nil && previous.io != nil {
+				bytes := float64(counterDelta(sample.io.ReadBytes+sample.io.WriteBytes, previous.io.ReadBytes+previous.io.WriteBytes))
+				summary.IOBytesPerSec = mathutil.Round2(bytes / elapsed)
+			}
+		}
+		if sortBy == SortByFDs {
+			summary.FDs, _ = fs.ProcFDCount(pid)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		switch sortBy {
+		case SortByCPU:
+			return a.CPUPercent > b.CPUPercent
+		case SortByFDs:
+			return a.FDs > b.FDs
+		case SortByIO:
+			return a.IOBytesPerSec > b.IOBytesPerSec
+		default:
+			return a.RSSBytes > b.RSSBytes
+		}
+	})
+	if n > 0 && len(summaries) > n {
+		summaries = summaries[:n]
+	}
+
+	for i := range summaries {
+		if sortBy != SortByFDs {
+			summaries[i].FDs, _ = fs.ProcFDCount(summaries[i].PID)
+		}
+		summaries[i].Cmdline, _ = fs.ProcCmdline(summaries[i].PID)
+		summaries[i].Cmdline = truncate(summaries[i].Cmdline, 200)
+	}
+	return summaries, nil
+}
+
+// DescribeProcess returns the details of the process with its parent chain
+func (fs FS) DescribeProcess(pid int) (ProcessDetail, error) {
+	stat, err := fs.ProcStat(pid)
+	if err != nil {
+		return ProcessDetail{}, fmt.Errorf("process %d not found: %w", pid, err)
+	}
+
+	detail := ProcessDetail{
+		ProcessSummary: ProcessSummary{
+			PID:           pid,
+			PPID:          stat.PPID,
+			Comm:          stat.Comm,
+			State:         stat.State,
+			RSSBytes:      uint64(max(stat.RSSPages, 0)) * uint64(os.Getpagesize()),
+			IOBytesPerSec: -1,
+			Threads:       stat.NumThreads,
+		},
+	}
+	detail.Cmdline, _ = fs.ProcCmdline(pid)
+	detail.FDs, _ = fs.ProcFDCount(pid)
+	detail.Exe, _ = fs.ProcLink(pid, "exe")
+	detail.Cwd, _ = fs.ProcLink(pid, "cwd")
+	detail.Cgroups, _ = fs.ProcCgroups(pid)
+	detail.Limits, _ = fs.ProcLimits(pid)
+	if io, err := fs.ProcIO(pid); err == nil {
+		detail.IO = &io
+	}
+	if status, err := fs.ProcStatus(pid); err == nil {
+		detail.UID = status["Uid"]
+		detail.Status = selectStatus(status)
+	}
+	if systemStat, err := fs.Stat(); err == nil {
+		detail.StartedAt = time.Unix(int64(systemStat.BootTime)+int64(stat.StartTime/UserHZ), 0)
+	}
+	if cpuPercent, err := fs.averageCPUPercent(stat); err == nil {
+		detail.CPUPercent = cpuPercent
+	}
+
+	detail.ParentChain = []ParentProcess{}
+	for ppid := stat.PPID; ppid > 0 && len(detail.ParentChain) < maxParentChain; {
+		parent, err := fs.ProcStat(ppid)
+		if err != nil {
+			break
+		}
+		cmdline, _ := fs.ProcCmdline(ppid)
+		detail.ParentChain = append(detail.ParentChain, ParentProcess{PID: ppid, Comm: parent.Comm, Cmdline: truncate(cmdline, 200)})
+		ppid = parent.PPID
+	}
+	return detail, nil
+}
+
+// averageCPUPercent returns the average cpu usage of the process since it started
+func (fs FS) averageCPUPercent(stat ProcStat) (float64, error) {
+	uptime, err := readString(fs.ProcPath("uptime"))
+	if err != nil {
+		return 0, err
+	}
+	var seconds float64
+	if _, err := fmt.Sscanf(uptime, "%f", &seconds); err != nil {
+		return 0, err
+	}
+	running := seconds - float64(stat.StartTime)/UserHZ
+	if running <= 0 {
+		return 0, nil
+	}
+	return mathutil.Round2(float64(stat.UTime+stat.STime) / UserHZ / running * 100), nil
+}
+
+func (fs FS) sampleProcesses() map[int]processSample {
+	samples := make(map[int]processSample)
+	pids, err := fs.AllPIDs()
+	if err != nil {
+		return samples
+	}
+	for _, pid := range pids {
+		stat, err := fs.ProcStat(pid)
+		if err != nil {
+			continue
+		}
+		sample := processSample{stat: stat}
+		if io, err := fs.ProcIO(pid); err == nil {
+			sample.io = &io
+		}
+		samples[pid] = sample
+	}
+	return samples
+}
+
+// selectStatus keeps the fields of /proc/[pid]/status useful for an investigation
+func selectStatus(status map[string]string) map[string]string {
+	selected := make(map[string]string)
+	for _, key := range []string{"VmPeak", "VmRSS", "VmSwap", "RssAnon", "RssFile", "voluntary_ctxt_switches", "nonvoluntary_ctxt_switches", "Cpus_allowed_list", "NSpid"} {
+		if value, ok := status[key]; ok {
+			selected[key] = value
+		}
+	}
+	return selected
+}
+
+// counterDelta returns the growth of a counter, 0 when it went backwards
+func counterDelta(current, previous uint64) uint64 {
+	if current < previous {
+		return 0
+	}
+	return current - previous
+}
+
+func truncate(value string, size int) string {
+	if len(value) <= size {
+		return value
+	}
+	return strings.ToValidUTF8(value[:size], "") + "..."
+}
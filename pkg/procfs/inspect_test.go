@@ -0,0 +1,155 @@
+package procfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProcess is a process of a fake proc tree
+type fakeProcess struct {
+	pid, ppid    int
+	comm         string
+	utime, stime uint64
+	startTime    uint64
+	rssPages     int64
+	// readBytes and writeBytes are written to the io file when one is set
+	readBytes, writeBytes uint64
+}
+
+// writeFakeProcesses replaces the processes of the fake proc tree
+func writeFakeProcesses(t *testing.T, root string, processes ...fakeProcess) {
+	entries, _ := os.ReadDir(root)
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err == nil {
+			assert.NoError(t, os.RemoveAll(filepath.Join(root, entry.Name())))
+		}
+	}
+	for _, p := range processes {
+		dir := filepath.Join(root, strconv.Itoa(p.pid))
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "fd"), 0755))
+		stat := fmt.Sprintf("%d (%s) S %d 1 1 0 -1 4194560 100 0 0 0 %d %d 0 0 20 0 1 0 %d 1000000 %d 18446744073709551615\n",
+			p.pid, p.comm, p.ppid, p.utime, p.stime, p.startTime, p.rssPages)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(p.comm+"\x00--flag\x00"), 0644))
+		if p.readBytes > 0 || p.writeBytes > 0 {
+			io := fmt.Sprintf("rchar: 0\nwchar: 0\nread_bytes: %d\nwrite_bytes: %d\n", p.readBytes, p.writeBytes)
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "io"), []byte(io), 0644))
+		}
+	}
+}
+
+func findSummary(summaries []ProcessSummary, pid int) (ProcessSummary, bool) {
+	for _, summary := range summaries {
+		if summary.PID == pid {
+			return summary, true
+		}
+	}
+	return ProcessSummary{}, false
+}
+
+func TestRankProcesses(t *testing.T) {
+	root := t.TempDir()
+	fs := NewFS(root, root)
+
+	writeFakeProcesses(t, root,
+		fakeProcess{pid: 10, ppid: 1, comm: "busy", utime: 100, stime: 50, startTime: 1000, rssPages: 10, readBytes: 1000, writeBytes: 1000},
+		fakeProcess{pid: 20, ppid: 1, comm: "old worker", utime: 9000, stime: 1000, startTime: 2000, rssPages: 20},
+		fakeProcess{pid: 30, ppid: 1, comm: "idle", utime: 5, stime: 5, startTime: 3000, rssPages: 300},
+	)
+	first := fs.sampleProcesses()
+	// pid 20 exited and its pid was reused by a new process with lower counters
+	writeFakeProcesses(t, root,
+		fakeProcess{pid: 10, ppid: 1, comm: "busy", utime: 140, stime: 60, startTime: 1000, rssPages: 10, readBytes: 3000, writeBytes: 5000},
+		fakeProcess{pid: 20, ppid: 10, comm: "new worker", utime: 2, stime: 1, startTime: 9000, rssPages: 5},
+		fakeProcess{pid: 30, ppid: 1, comm: "idle", utime: 5, stime: 5, startTime: 3000, rssPages: 300},
+		fakeProcess{pid: 40, ppid: 1, comm: "started", utime: 1, stime: 0, startTime: 9500, rssPages: 1},
+	)
+	second := fs.sampleProcesses()
+
+	summaries, err := fs.rankProcesses(first, second, 2, SortByCPU, 0)
+	assert.NoError(t, err)
+	assert.Len(t, summaries, 4)
+
+	busy, _ := findSummary(summaries, 10)
+	assert.Equal(t, 10, summaries[0].PID, "the busy process is the top cpu consumer")
+	assert.Equal(t, 25.0, busy.CPUPercent)
+	assert.Equal(t, 3000.0, busy.IOBytesPerSec)
+	assert.Equal(t, "busy --flag", busy.Cmdline)
+
+	reused, _ := findSummary(summaries, 20)
+	assert.Equal(t, "new worker", reused.Comm)
+	assert.Equal(t, 0.0, reused.CPUPercent, "a reused pid must not be compared with the previous process")
+	assert.Equal(t, -1.0, reused.IOBytesPerSec)
+
+	started, _ := findSummary(summaries, 40)
+	assert.Equal(t, 0.0, started.CPUPercent)
+	idle, _ := findSummary(summaries, 30)
+	assert.Equal(t, 0.0, idle.CPUPercent)
+}
+
+func TestRankProcessesCounterReset(t *testing.T) {
+	root := t.TempDir()
+	fs := NewFS(root, root)
+
+	writeFakeProcesses(t, root, fakeProcess{pid: 10, comm: "app", utime: 500, stime: 500, startTime: 100, readBytes: 5000, writeBytes: 1})
+	first := fs.sampleProcesses()
+	writeFakeProcesses(t, root, fakeProcess{pid: 10, comm: "app", utime: 400, stime: 400, startTime: 100, readBytes: 10, writeBytes: 1})
+	second := fs.sampleProcesses()
+
+	summaries, err := fs.rankProcesses(first, second, 1, SortByCPU, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, summaries, 1) {
+		assert.Equal(t, 0.0, summaries[0].CPUPercent)
+		assert.Equal(t, 0.0, summaries[0].IOBytesPerSec)
+	}
+}
+
+func TestRankProcessesSortAndLimit(t *testing.T) {
+	root := t.TempDir()
+	fs := NewFS(root, root)
+	writeFakeProcesses(t, root,
+		fakeProcess{pid: 1, comm: "small", rssPages: 1},
+		fakeProcess{pid: 2, comm: "large", rssPages: 1000},
+		fakeProcess{pid: 3, comm: "medium", rssPages: 100},
+	)
+	sample := fs.sampleProcesses()
+
+	summaries, err := fs.rankProcesses(sample, sample, 1, SortByRSS, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, summaries, 2) {
+		assert.Equal(t, "large", summaries[0].Comm)
+		assert.Equal(t, "medium", summaries[1].Comm)
+		assert.Equal(t, uint64(1000*os.Getpagesize()), summaries[0].RSSBytes)
+	}
+
+	_, err = fs.rankProcesses(sample, map[int]processSample{}, 1, SortByRSS, 2)
+	assert.Error(t, err)
+}
+
+func TestTopProcesses(t *testing.T) {
+	root := t.TempDir()
+	fs := NewFS(root, root)
+	writeFakeProcesses(t, root, fakeProcess{pid: 10, comm: "app", rssPages: 1})
+
+	_, err := fs.TopProcesses(context.Background(), "memory", 5, time.Millisecond)
+	assert.Error(t, err)
+
+	summaries, err := fs.TopProcesses(context.Background(), SortByCPU, 5, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Len(t, summaries, 1)
+
+	// the sampling interval stops with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = fs.TopProcesses(ctx, SortByCPU, 5, time.Hour)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Minute)
+}
@@ -0,0 +1,127 @@
+package procfs
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mount is a line of /proc/mounts
+type Mount struct {
+	Device     string   `json:"device"`
+	MountPoint string   `json:"mount_point"`
+	FSType     string   `json:"fs_type"`
+	Options    []string `json:"options"`
+	ReadOnly   bool     `json:"read_only"`
+}
+
+// DeletedFile is a deleted file still held open by a process, its space is
+// only freed when the file descriptor is closed.
+type DeletedFile struct {
+	PID  int    `json:"pid"`
+	Comm string `json:"comm"`
+	FD   int    `json:"fd"`
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Mounts returns the content of /proc/mounts
+func (fs FS) Mounts() ([]Mount, error) {
+	file, err := os.Open(fs.ProcPath("mounts"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []Mount
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		mount := Mount{
+			Device:     unescapeMountField(fields[0]),
+			MountPoint: unescapeMountField(fields[1]),
+			FSType:     fields[2],
+			Options:    strings.Split(fields[3], ","),
+		}
+		for _, option := range mount.Options {
+			if option == "ro" {
+				mount.ReadOnly = true
+			}
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountField decodes the octal escapes of the spaces, tabs and
+// backslashes of the mount fields, like \040 for a space.
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var builder strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if value, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				builder.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(field[i])
+	}
+	return builder.String()
+}
+
+// DeletedFiles returns the deleted files held open by the processes, the
+// largest first, the processes of other users are only readable as root.
+func (fs FS) DeletedFiles() ([]DeletedFile, error) {
+	pids, err := fs.AllPIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []DeletedFile
+	for _, pid := range pids {
+		fdDir := fs.ProcPath(strconv.Itoa(pid), "fd")
+		entries, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		comm := ""
+		for _, entry := range entries {
+			target, err := os.Readlink(fdDir + "/" + entry.Name())
+			if err != nil || !strings.HasPrefix(target, "/") || !strings.HasSuffix(target, " (deleted)") {
+				continue
+			}
+			// The fd link still opens the deleted file, its size is the space held.
+			info, err := os.Stat(fdDir + "/" + entry.Name())
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if comm == "" {
+				if stat, err := fs.ProcStat(pid); err == nil {
+					comm = stat.Comm
+				}
+			}
+			fd, _ := strconv.Atoi(entry.Name())
+			files = append(files, DeletedFile{
+				PID:  pid,
+				Comm: comm,
+				FD:   fd,
+				Path: strings.TrimSuffix(target, " (deleted)"),
+				Size: info.Size(),
+			})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Size > files[j].Size
+	})
+	return files, nil
+}
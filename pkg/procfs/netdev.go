@@ -0,0 +1,65 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+)
+
+// NetDevStat is a line of /proc/net/dev completed with the state of the interface from /sys/class/net
+type NetDevStat struct {
+	Interface    string `json:"interface"`
+	RxBytes      uint64 `json:"rx_bytes"`
+	RxPackets    uint64 `json:"rx_packets"`
+	RxErrors     uint64 `json:"rx_errors"`
+	RxDropped    uint64 `json:"rx_dropped"`
+	RxFIFO       uint64 `json:"rx_fifo"`
+	RxFrame      uint64 `json:"rx_frame"`
+	TxBytes      uint64 `json:"tx_bytes"`
+	TxPackets    uint64 `json:"tx_packets"`
+	TxErrors     uint64 `json:"tx_errors"`
+	TxDropped    uint64 `json:"tx_dropped"`
+	TxFIFO       uint64 `json:"tx_fifo"`
+	TxCollisions uint64 `json:"tx_collisions"`
+	TxCarrier    uint64 `json:"tx_carrier"`
+	OperState    string `json:"operstate,omitempty"`
+	// SpeedMbps is the link speed, 0 when unknown
+	SpeedMbps uint64 `json:"speed_mbps,omitempty"`
+}
+
+// NetDev returns the statistics of the network interfaces
+func (fs FS) NetDev() ([]NetDevStat, error) {
+	data, err := os.ReadFile(fs.ProcPath("net", "dev"))
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []NetDevStat
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 16 {
+			continue
+		}
+		counters, err := parseUints(fields[:16])
+		if err != nil {
+			continue
+		}
+		stat := NetDevStat{
+			Interface: strings.TrimSpace(name),
+			RxBytes:   counters[0], RxPackets: counters[1], RxErrors: counters[2], RxDropped: counters[3],
+			RxFIFO: counters[4], RxFrame: counters[5],
+			TxBytes: counters[8], TxPackets: counters[9], TxErrors: counters[10], TxDropped: counters[11],
+			TxFIFO: counters[12], TxCollisions: counters[13], TxCarrier: counters[14],
+		}
+		stat.OperState, _ = readString(fs.SysPath("class", "net", stat.Interface, "operstate"))
+		stat.SpeedMbps, _ = readUint(fs.SysPath("class", "net", stat.Interface, "speed"))
+		stats = append(stats, stat)
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,280 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	ProtocolTCP  = "tcp"
+	ProtocolTCP6 = "tcp6"
+	ProtocolUDP  = "udp"
+	ProtocolUDP6 = "udp6"
+)
+
+// tcpStates are the names of the TCP states indexed by their value in /proc/net/tcp
+var tcpStates = []string{
+	"", "ESTABLISHED", "SYN_SENT", "SYN_RECV", "FIN_WAIT1", "FIN_WAIT2", "TIME_WAIT",
+	"CLOSE", "CLOSE_WAIT", "LAST_ACK", "LISTEN", "CLOSING", "NEW_SYN_RECV",
+}
+
+// TCPStateListen is the state of the listening TCP sockets and of the bound UDP sockets
+const TCPStateListen = "LISTEN"
+
+// Socket is a line of /proc/net/tcp, tcp6, udp or udp6
+type Socket struct {
+	Protocol   string `json:"protocol"`
+	LocalAddr  string `json:"local_addr"`
+	LocalPort  int    `json:"local_port"`
+	RemoteAddr string `json:"remote_addr"`
+	RemotePort int    `json:"remote_port"`
+	State      string `json:"state"`
+	TxQueue    uint64 `json:"tx_queue"`
+	// RxQueue is the accept queue of the listening sockets
+	RxQueue     uint64 `json:"rx_queue"`
+	Retransmits uint64 `json:"retransmits"`
+	UID         int    `json:"uid"`
+	Inode       uint64 `json:"inode"`
+}
+
+// UnixSocket is a line of /proc/net/unix
+type UnixSocket struct {
+	Path      string `json:"path,omitempty"`
+	Type      string `json:"type"`
+	State     string `json:"state"`
+	Listening bool   `json:"listening"`
+	Inode     uint64 `json:"inode"`
+}
+
+// SocketOwner is a process holding a socket
+type SocketOwner struct {
+	PID  int    `json:"pid"`
+	Comm string `json:"comm"`
+}
+
+// NetSockets returns the sockets of the protocol, one of the Protocol constants
+func (fs FS) NetSockets(protocol string) ([]Socket, error) {
+	data, err := os.ReadFile(fs.ProcPath("net", protocol))
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets []Socket
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		socket := Socket{Protocol: protocol}
+		if socket.LocalAddr, socket.LocalPort, err = parseSocketAddr(fields[1]); err != nil {
+			return nil, err
+		}
+		if socket.RemoteAddr, socket.RemotePort, err = parseSocketAddr(fields[2]); err != nil {
+			return nil, err
+		}
+		state, _ := strconv.ParseUint(fields[3], 16, 8)
+		if int(state) < len(tcpStates) {
+			socket.State = tcpStates[state]
+		}
+		// UDP sockets have no state, the unconnected ones are reported like listening sockets.
+		if strings.HasPrefix(protocol, ProtocolUDP) {
+			socket.State = "ESTABLISHED"
+			if socket.RemotePort == 0 {
+				socket.State = TCPStateListen
+			}
+		}
+		if tx, rx, ok := strings.Cut(fields[4], ":"); ok {
+			socket.TxQueue, _ = strconv.ParseUint(tx, 16, 64)
+			socket.RxQueue, _ = strconv.ParseUint(rx, 16, 64)
+		}
+		socket.Retransmits, _ = strconv.ParseUint(fields[6], 16, 64)
+		socket.UID, _ = strconv.Atoi(fields[7])
+		socket.Inode, _ = strconv.ParseUint(fields[9], 10, 64)
+		sockets = append(sockets, socket)
+	}
+	return sockets, scanner.Err()
+}
+
+// parseSocketAddr parses the hexadecimal address:port of /proc/net/tcp, the
+// address is made of 32 bit words in host byte order.
+func parseSocketAddr(value string) (string, int, error) {
+	addr, port, ok := strings.Cut(value, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid socket address %q", value)
+	}
+	raw, err := hex.DecodeString(addr)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("invalid socket address %q", value)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.LittleEndian.Uint32(raw[i:]))
+	}
+	portNumber, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid socket port %q", value)
+	}
+	return ip.String(), int(portNumber), nil
+}
+
+// NetUnix returns the unix sockets of /proc/net/unix
+func (fs FS) NetUnix() ([]UnixSocket, error) {
+	data, err := os.ReadFile(fs.ProcPath("net", "unix"))
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets []UnixSocket
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			continue
+		}
+		socket := UnixSocket{}
+		flags, _ := strconv.ParseUint(fields[3], 16, 32)
+		// __SO_ACCEPTCON marks the listening sockets
+		socket.Listening = flags&0x10000 != 0
+		switch fields[4] {
+		case "0001":
+			socket.Type = "stream"
+		case "0002":
+			socket.Type = "dgram"
+		case "0005":
+			socket.Type = "seqpacket"
+		default:
+			socket.Type = fields[4]
+		}
+		switch fields[5] {
+		case "01":
+			socket.State = "UNCONNECTED"
+		case "02":
+			socket.State = "CONNECTING"
+		case "03":
+			socket.State = "CONNECTED"
+		case "04":
+			socket.State = "DISCONNECTING"
+		default:
+			socket.State = fields[5]
+		}
+		socket.Inode, _ = strconv.ParseUint(fields[6], 10, 64)
+		if len(fields) > 7 {
+			socket.Path = strings.Join(fields[7:], " ")
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets, scanner.Err()
+}
+
+// SocketOwners maps the inodes of the sockets to the processes holding them,
+// the processes of other users are only readable as root.
+func (fs FS) SocketOwners() (map[uint64]SocketOwner, error) {
+	pids, err := fs.AllPIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[uint64]SocketOwner)
+	for _, pid := range pids {
+		fdDir := fs.ProcPath(strconv.Itoa(pid), "fd")
+		entries, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		var owner *SocketOwner
+		for _, entry := range entries {
+			target, err := os.Readlink(fdDir + "/" + entry.Name())
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if owner == nil {
+				owner = &SocketOwner{PID: pid}
+				if stat, err := fs.ProcStat(pid); err == nil {
+					owner.Comm = stat.Comm
+				}
+			}
+			if _, ok := owners[inode]; !ok {
+				owners[inode] = *owner
+			}
+		}
+	}
+	return owners, nil
+}
+
+// SockStat returns the counters of /proc/net/sockstat and sockstat6 by
+// protocol, like TCP inuse, orphan and tw.
+func (fs FS) SockStat() (map[string]map[string]uint64, error) {
+	stats := make(map[string]map[string]uint64)
+	for _, name := range []string{"sockstat", "sockstat6"} {
+		data, err := os.ReadFile(fs.ProcPath("net", name))
+		if err != nil {
+			if name == "sockstat6" {
+				continue
+			}
+			return nil, err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			protocol, rest, ok := strings.Cut(scanner.Text(), ":")
+			if !ok {
+				continue
+			}
+			fields := strings.Fields(rest)
+			counters := make(map[string]uint64, len(fields)/2)
+			for i := 0; i+1 < len(fields); i += 2 {
+				if value, err := strconv.ParseUint(fields[i+1], 10, 64); err == nil {
+					counters[fields[i]] = value
+				}
+			}
+			stats[protocol] = counters
+		}
+	}
+	return stats, nil
+}
+
+// NetSNMP returns the counters of /proc/net/snmp and /proc/net/netstat by
+// group, like Tcp RetransSegs or TcpExt ListenOverflows.
+func (fs FS) NetSNMP() (map[string]map[string]int64, error) {
+	counters := make(map[string]map[string]int64)
+	for _, name := range []string{"snmp", "netstat"} {
+		data, err := os.ReadFile(fs.ProcPath("net", name))
+		if err != nil {
+			if name == "netstat" {
+				continue
+			}
+			return nil, err
+		}
+		// Every group is a line of names followed by a line of values.
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		for i := 0; i+1 < len(lines); i += 2 {
+			group, names, ok := strings.Cut(lines[i], ":")
+			valueGroup, values, ok2 := strings.Cut(lines[i+1], ":")
+			if !ok || !ok2 || group != valueGroup {
+				continue
+			}
+			nameFields, valueFields := strings.Fields(names), strings.Fields(values)
+			groupCounters := make(map[string]int64, len(nameFields))
+			for j := 0; j < len(nameFields) && j < len(valueFields); j++ {
+				if value, err := strconv.ParseInt(valueFields[j], 10, 64); err == nil {
+					groupCounters[nameFields[j]] = value
+				}
+			}
+			counters[group] = groupCounters
+		}
+	}
+	return counters, nil
+}
@@ -0,0 +1,165 @@
+package procfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSocketAddr(t *testing.T) {
+	tests := []struct {
+		value string
+		addr  string
+		port  int
+		err   string
+	}{
+		{value: "00000000:0016", addr: "0.0.0.0", port: 22},
+		{value: "0100007F:1538", addr: "127.0.0.1", port: 5432},
+		{value: "22D8B85D:01BB", addr: "93.184.216.34", port: 443},
+		{value: "00000000000000000000000000000000:0050", addr: "::", port: 80},
+		{value: "00000000000000000000000001000000:0277", addr: "::1", port: 631},
+		{value: "B80D0120000000000000000001000000:A2F0", addr: "2001:db8::1", port: 41712},
+		// the IPv4 addresses of the IPv6 sockets are mapped
+		{value: "0000000000000000FFFF00000F02000A:0050", addr: "10.0.2.15", port: 80},
+		{value: "0100007F", err: "invalid socket address"},
+		{value: "0100007:0016", err: "invalid socket address"},
+		{value: "0100007G:0016", err: "invalid socket address"},
+		{value: "0100007F00:0016", err: "invalid socket address"},
+		{value: "0100007F:10000", err: "invalid socket port"},
+		{value: "0100007F:", err: "invalid socket port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			addr, port, err := parseSocketAddr(tt.value)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.addr, addr)
+			assert.Equal(t, tt.port, port)
+		})
+	}
+}
+
+func TestNetSockets(t *testing.T) {
+	tests := []struct {
+		protocol string
+		want     []Socket
+	}{
+		{
+			protocol: ProtocolTCP,
+			want: []Socket{
+				{Protocol: "tcp", LocalAddr: "0.0.0.0", LocalPort: 22, RemoteAddr: "0.0.0.0", State: "LISTEN", Inode: 21345},
+				{Protocol: "tcp", LocalAddr: "127.0.0.1", LocalPort: 5432, RemoteAddr: "0.0.0.0", State: "LISTEN", RxQueue: 3, UID: 999, Inode: 22001},
+				{Protocol: "tcp", LocalAddr: "10.0.2.15", LocalPort: 22, RemoteAddr: "10.0.2.2", RemotePort: 54514, State: "ESTABLISHED", Inode: 30112},
+				{Protocol: "tcp", LocalAddr: "10.0.2.15", LocalPort: 35388, RemoteAddr: "93.184.216.34", RemotePort: 443, State: "CLOSE_WAIT", TxQueue: 1, Retransmits: 2, UID: 1000, Inode: 30555},
+				{Protocol: "tcp", LocalAddr: "10.0.2.15", LocalPort: 35390, RemoteAddr: "93.184.216.34", RemotePort: 443, State: "TIME_WAIT"},
+			},
+		},
+		{
+			protocol: ProtocolTCP6,
+			want: []Socket{
+				{Protocol: "tcp6", LocalAddr: "::", LocalPort: 80, RemoteAddr: "::", State: "LISTEN", UID: 33, Inode: 24410},
+				{Protocol: "tcp6", LocalAddr: "::1", LocalPort: 631, RemoteAddr: "::", State: "LISTEN", Inode: 24420},
+				{Protocol: "tcp6", LocalAddr: "10.0.2.15", LocalPort: 80, RemoteAddr: "10.0.2.2", RemotePort: 57782, State: "ESTABLISHED", UID: 33, Inode: 24501},
+				{Protocol: "tcp6", LocalAddr: "2001:db8::1", LocalPort: 41712, RemoteAddr: "2001:db8::2", RemotePort: 443, State: "SYN_SENT", TxQueue: 1, Retransmits: 3, UID: 1000, Inode: 24600},
+			},
+		},
+		{
+			// the unconnected UDP sockets are listening whatever their state
+			protocol: ProtocolUDP,
+			want: []Socket{
+				{Protocol: "udp", LocalAddr: "0.0.0.0", LocalPort: 68, RemoteAddr: "0.0.0.0", State: "LISTEN", Inode: 19876},
+				{Protocol: "udp", LocalAddr: "127.0.0.53", LocalPort: 53, RemoteAddr: "0.0.0.0", State: "LISTEN", UID: 101, Inode: 18123},
+				{Protocol: "udp", LocalAddr: "10.0.2.15", LocalPort: 40001, RemoteAddr: "8.8.8.8", RemotePort: 53, State: "ESTABLISHED", UID: 1000, Inode: 31001},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			sockets, err := fixtureFS().NetSockets(tt.protocol)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sockets)
+		})
+	}
+
+	_, err := fixtureFS().NetSockets(ProtocolUDP6)
+	assert.Error(t, err)
+}
+
+func TestNetUnix(t *testing.T) {
+	sockets, err := fixtureFS().NetUnix()
+	assert.NoError(t, err)
+	assert.Equal(t, []UnixSocket{
+		{Path: "/run/systemd/private", Type: "stream", State: "UNCONNECTED", Listening: true, Inode: 20001},
+		{Path: "@/tmp/.X11-unix/X0", Type: "stream", State: "UNCONNECTED", Listening: true, Inode: 20002},
+		{Path: "/run/dbus/system_bus_socket", Type: "stream", State: "CONNECTED", Inode: 20003},
+		{Type: "dgram", State: "UNCONNECTED", Inode: 20004},
+		{Path: "/run/udev/control", Type: "seqpacket", State: "UNCONNECTED", Listening: true, Inode: 20005},
+	}, sockets)
+}
+
+func TestSocketOwners(t *testing.T) {
+	owners, err := fixtureFS().SocketOwners()
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint64]SocketOwner{
+		20001: {PID: 1, Comm: "systemd"},
+		21345: {PID: 812, Comm: "sshd"},
+		30112: {PID: 812, Comm: "sshd"},
+		22001: {PID: 2001, Comm: "postgres"},
+		30555: {PID: 4242, Comm: "curl"},
+	}, owners)
+}
+
+func TestListeners(t *testing.T) {
+	listeners, err := fixtureFS().Listeners()
+	assert.NoError(t, err)
+	assert.Equal(t, []Listener{
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 22, PID: 812, Comm: "sshd"},
+		{Protocol: "tcp", Address: "127.0.0.1", Port: 5432, AcceptQueue: 3, PID: 2001, Comm: "postgres"},
+		{Protocol: "tcp6", Address: "::", Port: 80},
+		{Protocol: "tcp6", Address: "::1", Port: 631},
+		{Protocol: "udp", Address: "127.0.0.53", Port: 53},
+		{Protocol: "udp", Address: "0.0.0.0", Port: 68},
+		{Protocol: "unix", Address: "stream", Path: "/run/systemd/private", PID: 1, Comm: "systemd"},
+		{Protocol: "unix", Address: "stream", Path: "@/tmp/.X11-unix/X0"},
+		{Protocol: "unix", Address: "seqpacket", Path: "/run/udev/control"},
+	}, listeners)
+}
+
+func TestConnections(t *testing.T) {
+	tests := []struct {
+		name  string
+		state string
+		port  int
+		// want are the local ports of the connections
+		want []int
+	}{
+		{name: "all", want: []int{22, 35388, 35390, 80, 41712, 40001}},
+		{name: "established", state: "ESTABLISHED", want: []int{22, 80, 40001}},
+		{name: "remote port", port: 443, want: []int{35388, 35390, 41712}},
+		{name: "local port and state", state: "ESTABLISHED", port: 22, want: []int{22}},
+		{name: "listening port", port: 5432, want: []int{}},
+		{name: "no such state", state: "LAST_ACK", want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connections, err := fixtureFS().Connections(tt.state, tt.port)
+			assert.NoError(t, err)
+			ports := make([]int, 0, len(connections))
+			for _, connection := range connections {
+				ports = append(ports, connection.LocalPort)
+			}
+			assert.Equal(t, tt.want, ports)
+		})
+	}
+
+	connections, err := fixtureFS().Connections("CLOSE_WAIT", 0)
+	assert.NoError(t, err)
+	if assert.Len(t, connections, 1) {
+		assert.Equal(t, 4242, connections[0].PID)
+		assert.Equal(t, "curl", connections[0].Comm)
+		assert.Equal(t, "93.184.216.34", connections[0].RemoteAddr)
+	}
+}
@@ -0,0 +1,233 @@
+package procfs
+
+import (
+	"sort"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/mathutil"
+)
+
+// tcpCounters are the counters of /proc/net/snmp and netstat reported by the network summary
+var tcpCounters = map[string][]string{
+	"Tcp":    {"ActiveOpens", "PassiveOpens", "AttemptFails", "EstabResets", "CurrEstab", "InSegs", "OutSegs", "RetransSegs", "InErrs", "OutRsts"},
+	"TcpExt": {"ListenOverflows", "ListenDrops", "TCPTimeouts", "TCPLostRetransmit", "TCPSynRetrans", "TCPAbortOnTimeout", "TCPAbortOnMemory", "TCPBacklogDrop"},
+	"Udp":    {"InDatagrams", "OutDatagrams", "InErrors", "NoPorts", "RcvbufErrors", "SndbufErrors"},
+}
+
+// Listener is a listening socket and the process owning it
+type Listener struct {
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	Port     int    `json:"port,omitempty"`
+	Path     string `json:"path,omitempty"`
+	// AcceptQueue is the number of connections waiting to be accepted
+	AcceptQueue uint64 `json:"accept_queue,omitempty"`
+	PID         int    `json:"pid,omitempty"`
+	Comm        string `json:"comm,omitempty"`
+}
+
+// Connection is a TCP or UDP socket and the process owning it
+type Connection struct {
+	Socket
+	PID  int    `json:"pid,omitempty"`
+	Comm string `json:"comm,omitempty"`
+}
+
+// ProcessStates counts the sockets of a process in a state, like the
+// CLOSE_WAIT sockets a process forgets to close
+type ProcessStates struct {
+	PID   int    `json:"pid"`
+	Comm  string `json:"comm"`
+	State string `json:"state"`
+	Count int    `json:"count"`
+}
+
+// Retransmits are the TCP retransmissions measured over the sample interval
+type Retransmits struct {
+	RetransSegs uint64  `json:"retrans_segs"`
+	OutSegs     uint64  `json:"out_segs"`
+	Percent     float64 `json:"percent"`
+	Interval    string  `json:"interval"`
+}
+
+// InterfaceErrors are the error and drop counters of an interface
+type InterfaceErrors struct {
+	Interface  string `json:"interface"`
+	OperState  string `json:"operstate,omitempty"`
+	RxErrors   uint64 `json:"rx_errors"`
+	RxDropped  uint64 `json:"rx_dropped"`
+	RxFIFO     uint64 `json:"rx_fifo"`
+	RxFrame    uint64 `json:"rx_frame"`
+	TxErrors   uint64 `json:"tx_errors"`
+	TxDropped  uint64 `json:"tx_dropped"`
+	TxCarrier  uint64 `json:"tx_carrier"`
+	Collisions uint64 `json:"collisions"`
+}
+
+// NetworkSummary is the state of the network stack
+type NetworkSummary struct {
+	TCPStates map[string]int `json:"tcp_states"`
+	// StatesByProcess are the processes holding the most CLOSE_WAIT and TIME_WAIT sockets
+	StatesByProcess []ProcessStates              `json:"states_by_process,omitempty"`
+	SockStat        map[string]map[string]uint64 `json:"sockstat"`
+	Counters        map[string]map[string]int64  `json:"counters"`
+	Retransmits     *Retransmits                 `json:"retransmits,omitempty"`
+	Interfaces      []InterfaceErrors            `json:"interfaces"`
+}
+
+// Listeners returns the listening TCP sockets, the bound UDP sockets and the
+// listening unix sockets with their owners, sorted by protocol and port.
+func (fs FS) Listeners() ([]Listener, error) {
+	owners, err := fs.SocketOwners()
+	if err != nil {
+		return nil, err
+	}
+
+	var listeners []Listener
+	for _, protocol := range []string{ProtocolTCP, ProtocolTCP6, ProtocolUDP, ProtocolUDP6} {
+		sockets, err := fs.NetSockets(protocol)
+		if err != nil {
+			continue
+		}
+		for _, socket := range sockets {
+			if socket.State != TCPStateListen {
+				continue
+			}
+			owner := owners[socket.Inode]
+			listener := Listener{
+				Protocol: protocol,
+				Address:  socket.LocalAddr,
+				Port:     socket.LocalPort,
+				PID:      owner.PID,
+				Comm:     owner.Comm,
+			}
+			if protocol == ProtocolTCP || protocol == ProtocolTCP6 {
+				listener.AcceptQueue = socket.RxQueue
+			}
+			listeners = append(listeners, listener)
+		}
+	}
+	if sockets, err := fs.NetUnix(); err == nil {
+		for _, socket := range sockets {
+			if !socket.Listening {
+				continue
+			}
+			owner := owners[socket.Inode]
+			listeners = append(listeners, Listener{Protocol: "unix", Address: socket.Type, Path: socket.Path, PID: owner.PID, Comm: owner.Comm})
+		}
+	}
+
+	sort.SliceStable(listeners, func(i, j int) bool {
+		if listeners[i].Protocol != listeners[j].Protocol {
+			return listeners[i].Protocol < listeners[j].Protocol
+		}
+		return listeners[i].Port < listeners[j].Port
+	})
+	return listeners, nil
+}
+
+// Connections returns the TCP and UDP sockets which are not listening with
+// their owners, filtered by state and port when they are given.
+func (fs FS) Connections(state string, port int) ([]Connection, error) {
+	owners, err := fs.SocketOwners()
+	if err != nil {
+		return nil, err
+	}
+
+	var connections []Connection
+	for _, protocol := range []string{ProtocolTCP, ProtocolTCP6, ProtocolUDP, ProtocolUDP6} {
+		sockets, err := fs.NetSockets(protocol)
+		if err != nil {
+			continue
+		}
+		for _, socket := range sockets {
+			if socket.State == TCPStateListen || (state != "" && socket.State != state) {
+				continue
+			}
+			if port > 0 && socket.LocalPort != port && socket.RemotePort != port {
+				continue
+			}
+			owner := owners[socket.Inode]
+			connections = append(connections, Connection{Socket: socket, PID: owner.PID, Comm: owner.Comm})
+		}
+	}
+	return connections, nil
+}
+
+// NetworkSummary returns the histogram of the TCP states, the socket and
+// protocol counters, the TCP retransmissions measured over interval and the
+// error counters of the interfaces.
+func (fs FS) NetworkSummary(interval time.Duration) (NetworkSummary, error) {
+	summary := NetworkSummary{TCPStates: map[string]int{}}
+	before, err := fs.NetSNMP()
+	if err != nil {
+		return summary, err
+	}
+
+	owners, _ := fs.SocketOwners()
+	byProcess := make(map[ProcessStates]int)
+	for _, protocol := range []string{ProtocolTCP, ProtocolTCP6} {
+		sockets, err := fs.NetSockets(protocol)
+		if err != nil {
+			continue
+		}
+		for _, socket := range sockets {
+			summary.TCPStates[socket.State]++
+			// TIME_WAIT sockets belong to no process anymore, only CLOSE_WAIT ones are held.
+			if owner, ok := owners[socket.Inode]; ok && socket.State == "CLOSE_WAIT" {
+				byProcess[ProcessStates{PID: owner.PID, Comm: owner.Comm, State: socket.State}]++
+			}
+		}
+	}
+	for states, count := range byProcess {
+		states.Count = count
+		summary.StatesByProcess = append(summary.StatesByProcess, states)
+	}
+	sort.Slice(summary.StatesByProcess, func(i, j int) bool {
+		return summary.StatesByProcess[i].Count > summary.StatesByProcess[j].Count
+	})
+	if len(summary.StatesByProcess) > 10 {
+		summary.StatesByProcess = summary.StatesByProcess[:10]
+	}
+
+	if summary.SockStat, err = fs.SockStat(); err != nil {
+		return summary, err
+	}
+
+	time.Sleep(interval)
+	after, err := fs.NetSNMP()
+	if err != nil {
+		return summary, err
+	}
+	summary.Counters = make(map[string]map[string]int64, len(tcpCounters))
+	for group, names := range tcpCounters {
+		counters := make(map[string]int64, len(names))
+		for _, name := range names {
+			if value, ok := after[group][name]; ok {
+				counters[name] = value
+			}
+		}
+		summary.Counters[group] = counters
+	}
+	retrans := after["Tcp"]["RetransSegs"] - before["Tcp"]["RetransSegs"]
+	out := after["Tcp"]["OutSegs"] - before["Tcp"]["OutSegs"]
+	if retrans >= 0 && out >= 0 {
+		summary.Retransmits = &Retransmits{RetransSegs: uint64(retrans), OutSegs: uint64(out), Interval: interval.String()}
+		if out > 0 {
+			summary.Retransmits.Percent = mathutil.Round2(float64(retrans) * 100 / float64(out))
+		}
+	}
+
+	stats, err := fs.NetDev()
+	if err != nil {
+		return summary, err
+	}
+	for _, stat := range stats {
+		summary.Interfaces = append(summary.Interfaces, InterfaceErrors{
+			Interface: stat.Interface, OperState: stat.OperState,
+			RxErrors: stat.RxErrors, RxDropped: stat.RxDropped, RxFIFO: stat.RxFIFO, RxFrame: stat.RxFrame,
+			TxErrors: stat.TxErrors, TxDropped: stat.TxDropped, TxCarrier: stat.TxCarrier, Collisions: stat.TxCollisions,
+		})
+	}
+	return summary, nil
+}
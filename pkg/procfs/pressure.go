@@ -0,0 +1,87 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PressureResources are the resources reported by the pressure stall information
+var PressureResources = []string{"cpu", "memory", "io"}
+
+// PressureLine is a "some" or "full" line of a pressure file, the averages
+// are percentages and Total is the stall time in microseconds.
+type PressureLine struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	Total  uint64  `json:"total"`
+}
+
+// Pressure is the content of a pressure file like /proc/pressure/memory
+type Pressure struct {
+	Some PressureLine  `json:"some"`
+	Full *PressureLine `json:"full,omitempty"`
+}
+
+// Pressure returns the pressure stall information of the resource, cpu, memory or io
+func (fs FS) Pressure(resource string) (Pressure, error) {
+	return ParsePressureFile(fs.ProcPath("pressure", resource))
+}
+
+// ParsePressureFile parses a pressure file, the same format is used by /proc/pressure
+// and by the pressure files of the cgroups.
+func ParsePressureFile(path string) (Pressure, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Pressure{}, err
+	}
+
+	pressure := Pressure{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		line, err := parsePressureLine(fields[1:])
+		if err != nil {
+			return pressure, fmt.Errorf("%s: %w", path, err)
+		}
+		switch fields[0] {
+		case "some":
+			pressure.Some = line
+		case "full":
+			pressure.Full = &line
+		}
+	}
+	return pressure, scanner.Err()
+}
+
+func parsePressureLine(fields []string) (PressureLine, error) {
+	line := PressureLine{}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		var err error
+		switch key {
+		case "avg10":
+			line.Avg10, err = strconv.ParseFloat(value, 64)
+		case "avg60":
+			line.Avg60, err = strconv.ParseFloat(value, 64)
+		case "avg300":
+			line.Avg300, err = strconv.ParseFloat(value, 64)
+		case "total":
+			line.Total, err = strconv.ParseUint(value, 10, 64)
+		}
+		if err != nil {
+			return line, fmt.Errorf("invalid pressure field %q: %w", field, err)
+		}
+	}
+	return line, nil
+}
@@ -0,0 +1,195 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// UserHZ is the unit of the cpu times of /proc, fixed to 100 on linux
+	UserHZ = 100
+)
+
+// ProcStat is the content of /proc/[pid]/stat used by the inspection, cpu times are in USER_HZ
+type ProcStat struct {
+	PID        int    `json:"pid"`
+	Comm       string `json:"comm"`
+	State      string `json:"state"`
+	PPID       int    `json:"ppid"`
+	UTime      uint64 `json:"utime"`
+	STime      uint64 `json:"stime"`
+	NumThreads int64  `json:"num_threads"`
+	StartTime  uint64 `json:"starttime"`
+	VSize      uint64 `json:"vsize"`
+	RSSPages   int64  `json:"rss_pages"`
+}
+
+// ProcIO is the content of /proc/[pid]/io, only readable for the own processes or as root
+type ProcIO struct {
+	ReadBytes  uint64 `json:"read_bytes"`
+	WriteBytes uint64 `json:"write_bytes"`
+	RChar      uint64 `json:"rchar"`
+	WChar      uint64 `json:"wchar"`
+}
+
+// ProcLimit is a line of /proc/[pid]/limits
+type ProcLimit struct {
+	Soft  string `json:"soft"`
+	Hard  string `json:"hard"`
+	Units string `json:"units,omitempty"`
+}
+
+// AllPIDs returns the PIDs of all the processes
+func (fs FS) AllPIDs() ([]int, error) {
+	entries, err := os.ReadDir(fs.Proc)
+	if err != nil {
+		return nil, err
+	}
+
+	pids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	return pids, nil
+}
+
+// ProcStat returns the content of /proc/[pid]/stat
+func (fs FS) ProcStat(pid int) (ProcStat, error) {
+	data, err := os.ReadFile(fs.ProcPath(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return ProcStat{}, err
+	}
+
+	// The comm could contain spaces and parenthesis, it ends at the last ')'.
+	content := string(data)
+	start := strings.IndexByte(content, '(')
+	end := strings.LastIndexByte(content, ')')
+	if start < 0 || end < start {
+		return ProcStat{}, fmt.Errorf("unexpected stat content of pid %d", pid)
+	}
+	fields := strings.Fields(content[end+1:])
+	if len(fields) < 22 {
+		return ProcStat{}, fmt.Errorf("unexpected stat content of pid %d", pid)
+	}
+
+	stat := ProcStat{PID: pid, Comm: content[start+1 : end], State: fields[0]}
+	stat.PPID, _ = strconv.Atoi(fields[1])
+	stat.UTime, _ = strconv.ParseUint(fields[11], 10, 64)
+	stat.STime, _ = strconv.ParseUint(fields[12], 10, 64)
+	stat.NumThreads, _ = strconv.ParseInt(fields[17], 10, 64)
+	stat.StartTime, _ = strconv.ParseUint(fields[19], 10, 64)
+	stat.VSize, _ = strconv.ParseUint(fields[20], 10, 64)
+	stat.RSSPages, _ = strconv.ParseInt(fields[21], 10, 64)
+	return stat, nil
+}
+
+// ProcStatus returns the fields of /proc/[pid]/status
+func (fs FS) ProcStatus(pid int) (map[string]string, error) {
+	data, err := os.ReadFile(fs.ProcPath(strconv.Itoa(pid), "status"))
+	if err != nil {
+		return nil, err
+	}
+
+	status := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		status[key] = strings.Join(strings.Fields(value), " ")
+	}
+	return status, scanner.Err()
+}
+
+// ProcCmdline returns the command line of the process, empty for kernel threads
+func (fs FS) ProcCmdline(pid int) (string, error) {
+	data, err := os.ReadFile(fs.ProcPath(strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " ")), nil
+}
+
+// ProcCgroups returns the lines of /proc/[pid]/cgroup, with cgroup v2 a single "0::/path" line
+func (fs FS) ProcCgroups(pid int) ([]string, error) {
+	data, err := os.ReadFile(fs.ProcPath(strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// ProcFDCount returns the number of file descriptors opened by the process
+func (fs FS) ProcFDCount(pid int) (int, error) {
+	entries, err := os.ReadDir(fs.ProcPath(strconv.Itoa(pid), "fd"))
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// ProcIO returns the IO counters of the process
+func (fs FS) ProcIO(pid int) (ProcIO, error) {
+	values, err := fs.readKeyValues(fs.ProcPath(strconv.Itoa(pid), "io"), ":")
+	if err != nil {
+		return ProcIO{}, err
+	}
+	return ProcIO{
+		ReadBytes:  values["read_bytes"],
+		WriteBytes: values["write_bytes"],
+		RChar:      values["rchar"],
+		WChar:      values["wchar"],
+	}, nil
+}
+
+// ProcLimits returns the resource limits of the process
+func (fs FS) ProcLimits(pid int) (map[string]ProcLimit, error) {
+	data, err := os.ReadFile(fs.ProcPath(strconv.Itoa(pid), "limits"))
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[string]ProcLimit)
+	lines := strings.Split(string(data), "\n")
+	if len(lines) == 0 {
+		return limits, nil
+	}
+	// The columns are aligned on the header: Limit, Soft Limit, Hard Limit, Units.
+	header := lines[0]
+	softCol := strings.Index(header, "Soft Limit")
+	hardCol := strings.Index(header, "Hard Limit")
+	unitsCol := strings.Index(header, "Units")
+	if softCol < 0 || hardCol < 0 || unitsCol < 0 {
+		return nil, fmt.Errorf("unexpected limits content of pid %d", pid)
+	}
+	for _, line := range lines[1:] {
+		if len(line) <= hardCol {
+			continue
+		}
+		limit := ProcLimit{
+			Soft: strings.TrimSpace(line[softCol:hardCol]),
+			Hard: strings.TrimSpace(line[hardCol:min(unitsCol, len(line))]),
+		}
+		if len(line) > unitsCol {
+			limit.Units = strings.TrimSpace(line[unitsCol:])
+		}
+		limits[strings.TrimSpace(line[:softCol])] = limit
+	}
+	return limits, nil
+}
+
+// ProcLink returns the target of a link of the process like exe or cwd
+func (fs FS) ProcLink(pid int, name string) (string, error) {
+	return os.Readlink(fs.ProcPath(strconv.Itoa(pid), name))
+}
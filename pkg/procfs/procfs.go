@@ -0,0 +1,175 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultProcRoot = "/proc"
+	DefaultSysRoot  = "/sys"
+)
+
+// FS reads the proc and sys filesystems below the given roots, the roots
+// could point to fixture trees instead of the live filesystems.
+type FS struct {
+	Proc string
+	Sys  string
+}
+
+// NewFS creates a FS with the given roots, empty roots default to /proc and /sys
+func NewFS(procRoot, sysRoot string) FS {
+	if procRoot == "" {
+		procRoot = DefaultProcRoot
+	}
+	if sysRoot == "" {
+		sysRoot = DefaultSysRoot
+	}
+	return FS{Proc: procRoot, Sys: sysRoot}
+}
+
+// DefaultFS returns the FS of the live system
+func DefaultFS() FS {
+	return NewFS(DefaultProcRoot, DefaultSysRoot)
+}
+
+// ProcPath returns the path of the file below the proc root
+func (fs FS) ProcPath(elem ...string) string {
+	return filepath.Join(append([]string{fs.Proc}, elem...)...)
+}
+
+// SysPath returns the path of the file below the sys root
+func (fs FS) SysPath(elem ...string) string {
+	return filepath.Join(append([]string{fs.Sys}, elem...)...)
+}
+
+// LoadAvg is the content of /proc/loadavg
+type LoadAvg struct {
+	Load1   float64 `json:"load1"`
+	Load5   float64 `json:"load5"`
+	Load15  float64 `json:"load15"`
+	Running uint64  `json:"running"`
+	Total   uint64  `json:"total"`
+	LastPID uint64  `json:"last_pid"`
+}
+
+// MemInfo returns the fields of /proc/meminfo in kB, counters without
+// unit like HugePages_Total are returned as they are.
+func (fs FS) MemInfo() (map[string]uint64, error) {
+	return fs.readKeyValues(fs.ProcPath("meminfo"), ":")
+}
+
+// VMStat returns the counters of /proc/vmstat
+func (fs FS) VMStat() (map[string]uint64, error) {
+	return fs.readKeyValues(fs.ProcPath("vmstat"), " ")
+}
+
+// LoadAvg returns the content of /proc/loadavg
+func (fs FS) LoadAvg() (LoadAvg, error) {
+	data, err := os.ReadFile(fs.ProcPath("loadavg"))
+	if err != nil {
+		return LoadAvg{}, err
+	}
+
+	loadavg := LoadAvg{}
+	fields := strings.Fields(string(data))
+	if len(fields) < 5 {
+		return loadavg, fmt.Errorf("unexpected loadavg content %q", string(data))
+	}
+	for i, load := range []*float64{&loadavg.Load1, &loadavg.Load5, &loadavg.Load15} {
+		if *load, err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return loadavg, fmt.Errorf("invalid load average %q: %w", fields[i], err)
+		}
+	}
+	if running, total, ok := strings.Cut(fields[3], "/"); ok {
+		loadavg.Running, _ = strconv.ParseUint(running, 10, 64)
+		loadavg.Total, _ = strconv.ParseUint(total, 10, 64)
+	}
+	loadavg.LastPID, _ = strconv.ParseUint(fields[4], 10, 64)
+	return loadavg, nil
+}
+
+// FileNr is the content of /proc/sys/fs/file-nr, the file handles of the system
+type FileNr struct {
+	Allocated uint64 `json:"allocated"`
+	Max       uint64 `json:"max"`
+}
+
+// FileNr returns the number of allocated file handles and their maximum
+func (fs FS) FileNr() (FileNr, error) {
+	data, err := os.ReadFile(fs.ProcPath("sys", "fs", "file-nr"))
+	if err != nil {
+		return FileNr{}, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 3 {
+		return FileNr{}, fmt.Errorf("unexpected file-nr content %q", string(data))
+	}
+	fileNr := FileNr{}
+	fileNr.Allocated, _ = strconv.ParseUint(fields[0], 10, 64)
+	fileNr.Max, _ = strconv.ParseUint(fields[2], 10, 64)
+	return fileNr, nil
+}
+
+// readKeyValues parses files made of "key<sep> value [unit]" lines
+func (fs FS) readKeyValues(path, sep string) (map[string]uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), sep)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, scanner.Err()
+}
+
+// readUint reads a file containing a single unsigned integer
+func readUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readString reads a file containing a single line
+func readString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// parseUints parses all the fields as unsigned integers
+func parseUints(fields []string) ([]uint64, error) {
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid counter %q: %w", field, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
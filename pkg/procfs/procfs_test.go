@@ -0,0 +1,171 @@
+package procfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureFS() FS {
+	return NewFS("testdata/proc", "testdata/sys")
+}
+
+func TestMemInfo(t *testing.T) {
+	meminfo, err := fixtureFS().MemInfo()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		key  string
+		want uint64
+	}{
+		{"MemTotal", 8048980},
+		{"MemAvailable", 3862140},
+		{"SwapTotal", 2097148},
+		{"SwapFree", 1835004},
+		{"HugePages_Total", 0},
+		{"Hugepagesize", 2048},
+	}
+	for _, tt := range tests {
+		value, ok := meminfo[tt.key]
+		assert.True(t, ok, tt.key)
+		assert.Equal(t, tt.want, value, tt.key)
+	}
+}
+
+func TestStat(t *testing.T) {
+	stat, err := fixtureFS().Stat()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "cpu", stat.Total.CPU)
+	assert.Equal(t, uint64(10132153), stat.Total.User)
+	assert.Equal(t, uint64(46828483), stat.Total.Idle)
+	assert.Equal(t, uint64(175628), stat.Total.Guest)
+	assert.Equal(t, uint64(10132153+290696+3084719+46828483+16683+0+25195+0), stat.Total.Total())
+	assert.Equal(t, stat.Total.Total()-46828483-16683, stat.Total.Busy())
+	if assert.Len(t, stat.CPUs, 2) {
+		assert.Equal(t, "cpu0", stat.CPUs[0].CPU)
+		assert.Equal(t, "cpu1", stat.CPUs[1].CPU)
+		assert.Equal(t, uint64(1911), stat.CPUs[1].SoftIRQ)
+	}
+	assert.Equal(t, uint64(11554520), stat.ContextSwitches)
+	assert.Equal(t, uint64(1714040000), stat.BootTime)
+	assert.Equal(t, uint64(286513), stat.Processes)
+	assert.Equal(t, uint64(3), stat.ProcsRunning)
+	assert.Equal(t, uint64(1), stat.ProcsBlocked)
+	assert.Equal(t, uint64(1462898), stat.Interrupts)
+	assert.Equal(t, uint64(5057579), stat.SoftIRQs)
+}
+
+func TestLoadAvg(t *testing.T) {
+	loadavg, err := fixtureFS().LoadAvg()
+	assert.NoError(t, err)
+	assert.Equal(t, LoadAvg{Load1: 0.52, Load5: 0.41, Load15: 0.38, Running: 2, Total: 1187, LastPID: 286513}, loadavg)
+}
+
+func TestPressure(t *testing.T) {
+	tests := []struct {
+		resource string
+		some     PressureLine
+		full     *PressureLine
+	}{
+		{
+			resource: "cpu",
+			some:     PressureLine{Avg10: 1.5, Avg60: 0.8, Avg300: 0.25, Total: 123456789},
+			full:     &PressureLine{},
+		},
+		{
+			resource: "memory",
+			some:     PressureLine{Avg10: 12.34, Avg60: 5.67, Avg300: 1.23, Total: 987654},
+			full:     &PressureLine{Avg10: 8, Avg60: 3.5, Avg300: 0.75, Total: 456789},
+		},
+		{
+			// the older kernels have no full line for io
+			resource: "io",
+			some:     PressureLine{Total: 42},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			pressure, err := fixtureFS().Pressure(tt.resource)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.some, pressure.Some)
+			assert.Equal(t, tt.full, pressure.Full)
+		})
+	}
+
+	_, err := fixtureFS().Pressure("irq")
+	assert.Error(t, err)
+}
+
+func TestDiskStats(t *testing.T) {
+	stats, err := fixtureFS().DiskStats()
+	assert.NoError(t, err)
+
+	// loop0 has no IO and is skipped
+	devices := make(map[string]DiskStat)
+	for _, stat := range stats {
+		devices[stat.Device] = stat
+	}
+	assert.Len(t, devices, 3)
+	assert.NotContains(t, devices, "loop0")
+
+	sda := devices["sda"]
+	assert.Equal(t, uint64(8), sda.Major)
+	assert.Equal(t, uint64(96520), sda.Reads)
+	assert.Equal(t, uint64(5617898), sda.SectorsRead)
+	assert.Equal(t, uint64(321583), sda.Writes)
+	assert.Equal(t, uint64(12034074), sda.SectorsWritten)
+	assert.Equal(t, uint64(298768), sda.IOTime)
+	assert.Equal(t, uint64(485588), sda.WeightedIOTime)
+	if assert.NotNil(t, sda.Rotational) {
+		assert.True(t, *sda.Rotational)
+	}
+
+	// the partitions have no queue in /sys/block
+	assert.Nil(t, devices["sda1"].Rotational)
+
+	// the kernels before 4.18 have only 11 counters
+	nvme := devices["nvme0n1"]
+	assert.Equal(t, uint64(4321), nvme.Reads)
+	assert.Equal(t, uint64(2), nvme.InFlight)
+	assert.Equal(t, uint64(5555), nvme.WeightedIOTime)
+	if assert.NotNil(t, nvme.Rotational) {
+		assert.False(t, *nvme.Rotational)
+	}
+}
+
+func TestNetDev(t *testing.T) {
+	stats, err := fixtureFS().NetDev()
+	assert.NoError(t, err)
+	if !assert.Len(t, stats, 2) {
+		return
+	}
+
+	lo := stats[0]
+	assert.Equal(t, "lo", lo.Interface)
+	assert.Equal(t, uint64(1234567), lo.RxBytes)
+	assert.Equal(t, "unknown", lo.OperState)
+	assert.Equal(t, uint64(0), lo.SpeedMbps)
+
+	eth0 := stats[1]
+	assert.Equal(t, NetDevStat{
+		Interface: "eth0",
+		RxBytes:   987654321, RxPackets: 1234567, RxErrors: 3, RxDropped: 14, RxFrame: 2,
+		TxBytes: 123456789, TxPackets: 654321, TxErrors: 1, TxDropped: 7, TxCarrier: 4,
+		OperState: "up", SpeedMbps: 1000,
+	}, eth0)
+}
+
+func TestMissingFiles(t *testing.T) {
+	fs := NewFS("testdata/missing", "testdata/missing")
+	_, err := fs.MemInfo()
+	assert.Error(t, err)
+	_, err = fs.Stat()
+	assert.Error(t, err)
+	_, err = fs.LoadAvg()
+	assert.Error(t, err)
+	_, err = fs.DiskStats()
+	assert.Error(t, err)
+	_, err = fs.NetDev()
+	assert.Error(t, err)
+}
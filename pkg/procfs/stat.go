@@ -0,0 +1,102 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CPUStat is a cpu line of /proc/stat, in USER_HZ
+type CPUStat struct {
+	CPU       string `json:"cpu"`
+	User      uint64 `json:"user"`
+	Nice      uint64 `json:"nice"`
+	System    uint64 `json:"system"`
+	Idle      uint64 `json:"idle"`
+	IOWait    uint64 `json:"iowait"`
+	IRQ       uint64 `json:"irq"`
+	SoftIRQ   uint64 `json:"softirq"`
+	Steal     uint64 `json:"steal"`
+	Guest     uint64 `json:"guest"`
+	GuestNice uint64 `json:"guest_nice"`
+}
+
+// Total returns the total time of the cpu, the guest times are already part of user and nice
+func (c CPUStat) Total() uint64 {
+	return c.User + c.Nice + c.System + c.Idle + c.IOWait + c.IRQ + c.SoftIRQ + c.Steal
+}
+
+// Busy returns the time the cpu was not idle
+func (c CPUStat) Busy() uint64 {
+	return c.Total() - c.Idle - c.IOWait
+}
+
+// Stat is the content of /proc/stat
+type Stat struct {
+	Total           CPUStat   `json:"total"`
+	CPUs            []CPUStat `json:"cpus"`
+	ContextSwitches uint64    `json:"context_switches"`
+	BootTime        uint64    `json:"boot_time"`
+	Processes       uint64    `json:"processes"`
+	ProcsRunning    uint64    `json:"procs_running"`
+	ProcsBlocked    uint64    `json:"procs_blocked"`
+	Interrupts      uint64    `json:"interrupts"`
+	SoftIRQs        uint64    `json:"softirqs"`
+}
+
+// Stat returns the content of /proc/stat
+func (fs FS) Stat() (Stat, error) {
+	data, err := os.ReadFile(fs.ProcPath("stat"))
+	if err != nil {
+		return Stat{}, err
+	}
+
+	stat := Stat{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, _ := strconv.ParseUint(fields[1], 10, 64)
+		switch {
+		case fields[0] == "cpu":
+			stat.Total = parseCPUStat(fields)
+		case strings.HasPrefix(fields[0], "cpu"):
+			stat.CPUs = append(stat.CPUs, parseCPUStat(fields))
+		case fields[0] == "ctxt":
+			stat.ContextSwitches = value
+		case fields[0] == "btime":
+			stat.BootTime = value
+		case fields[0] == "processes":
+			stat.Processes = value
+		case fields[0] == "procs_running":
+			stat.ProcsRunning = value
+		case fields[0] == "procs_blocked":
+			stat.ProcsBlocked = value
+		case fields[0] == "intr":
+			stat.Interrupts = value
+		case fields[0] == "softirq":
+			stat.SoftIRQs = value
+		}
+	}
+	return stat, scanner.Err()
+}
+
+func parseCPUStat(fields []string) CPUStat {
+	cpu := CPUStat{CPU: fields[0]}
+	counters := []*uint64{
+		&cpu.User, &cpu.Nice, &cpu.System, &cpu.Idle, &cpu.IOWait,
+		&cpu.IRQ, &cpu.SoftIRQ, &cpu.Steal, &cpu.Guest, &cpu.GuestNice,
+	}
+	for i, counter := range counters {
+		if i+1 >= len(fields) {
+			break
+		}
+		*counter, _ = strconv.ParseUint(fields[i+1], 10, 64)
+	}
+	return cpu
+}
@@ -21,6 +21,7 @@ tools as below:
 
 {{.system_info}}
 
+{{.host_profile}}
 you can use such build in tools to help you complete the task:
 
 {{.tools}}
@@ -32,7 +33,7 @@ Thought: you should always think about what to do next one step at a time and us
 complete the task.
 
 Action: the Action should be one of the {{.tool_names}}.
-Action_input: the script content with the format:
+Action_input: the input of the tool as described above, for ScriptExecutor the script content with the format:
 
 {{.ShellScriptFormat}}
 
@@ -40,10 +41,16 @@ for example:
 
 {{.ShellExample}}
 
-Observation: the output of the script.
+Observation: the output of the tool.
 ... (this Thought/Action/Action Input/Observation can repeat N times)
+
 Thought: I now know the final answer
-Final Answer: the final answer to the original input question
+Final Answer: the final answer to the original input question, the first line of the final answer must be "Status: ok"
+if no issue was found or "Status: finding" otherwise.
+
+When a step needs several independent actions, for example to collect CPU, memory and disk data, give all the
+Action/Action_input pairs one after the other before stopping, they are executed concurrently and their observations
+are returned in the same order as the actions.
 
 Begin!
 
@@ -0,0 +1,119 @@
+package psi
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/cgroup"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+// Stall is a "some" or "full" line of a pressure file with the share of the
+// time tasks were stalled since the previous sample.
+type Stall struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	// TotalUsec is the cumulative stall time in microseconds
+	TotalUsec uint64 `json:"total_usec"`
+	// Percent is the stall time over the time since the previous sample, nil for the first sample
+	Percent *float64 `json:"percent,omitempty"`
+}
+
+// Sample is the pressure of a resource, for the whole system or for a cgroup
+type Sample struct {
+	Resource string `json:"resource"`
+	// Cgroup is the path of the cgroup, empty for the whole system
+	Cgroup string    `json:"cgroup,omitempty"`
+	Time   time.Time `json:"time"`
+	Some   Stall     `json:"some"`
+	// Full is the time all the tasks were stalled, the system cpu has no full line before linux 5.13
+	Full *Stall `json:"full,omitempty"`
+}
+
+// Collector samples the pressure files of the system and of cgroups, the
+// stall percentages are computed against the previous sample.
+type Collector struct {
+	fs        procfs.FS
+	hierarchy *cgroup.Hierarchy
+	mu        sync.Mutex
+	previous  map[string]Sample
+}
+
+// NewCollector creates a collector, the cgroups could only be sampled when
+// the hierarchy is not nil.
+func NewCollector(fs procfs.FS, hierarchy *cgroup.Hierarchy) *Collector {
+	return &Collector{fs: fs, hierarchy: hierarchy, previous: make(map[string]Sample)}
+}
+
+// Path returns the pressure file of the resource, of the system when the cgroup is empty
+func (c *Collector) Path(resource, cgroupPath string) string {
+	if cgroupPath == "" || c.hierarchy == nil {
+		return c.fs.ProcPath("pressure", resource)
+	}
+	return filepath.Join(c.hierarchy.Root, filepath.FromSlash(strings.Trim(cgroupPath, "/")), resource+".pressure")
+}
+
+// Collect samples the resources of the system and of the given cgroups, the
+// missing files, like the pressure of a removed cgroup, are skipped.
+func (c *Collector) Collect(cgroups []string) ([]Sample, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var samples []Sample
+	var firstErr error
+	for _, cgroupPath := range append([]string{""}, cgroups...) {
+		if cgroupPath != "" && c.hierarchy == nil {
+			continue
+		}
+		for _, resource := range procfs.PressureResources {
+			pressure, err := procfs.ParsePressureFile(c.Path(resource, cgroupPath))
+			if err != nil {
+				if cgroupPath == "" && firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			samples = append(samples, c.sample(resource, cgroupPath, pressure, time.Now()))
+		}
+	}
+	if len(samples) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return samples, nil
+}
+
+func (c *Collector) sample(resource, cgroupPath string, pressure procfs.Pressure, now time.Time) Sample {
+	sample := Sample{Resource: resource, Cgroup: cgroupPath, Time: now, Some: stall(pressure.Some)}
+	if pressure.Full != nil {
+		full := stall(*pressure.Full)
+		sample.Full = &full
+	}
+
+	key := cgroupPath + ":" + resource
+	if previous, ok := c.previous[key]; ok {
+		elapsed := now.Sub(previous.Time).Microseconds()
+		sample.Some.Percent = stallPercent(previous.Some.TotalUsec, sample.Some.TotalUsec, elapsed)
+		if sample.Full != nil && previous.Full != nil {
+			sample.Full.Percent = stallPercent(previous.Full.TotalUsec, sample.Full.TotalUsec, elapsed)
+		}
+	}
+	c.previous[key] = sample
+	return sample
+}
+
+func stall(line procfs.PressureLine) Stall {
+	return Stall{Avg10: line.Avg10, Avg60: line.Avg60, Avg300: line.Avg300, TotalUsec: line.Total}
+}
+
+// stallPercent returns the share of the elapsed time which was stalled, nil
+// when the counter was reset or no time elapsed
+func stallPercent(before, after uint64, elapsedUsec int64) *float64 {
+	if after < before || elapsedUsec <= 0 {
+		return nil
+	}
+	percent := float64(int64(float64(after-before)*10000/float64(elapsedUsec)+0.5)) / 100
+	return &percent
+}
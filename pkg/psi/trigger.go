@@ -0,0 +1,63 @@
+package psi
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+const (
+	KindSome = "some"
+	KindFull = "full"
+
+	// the window of a trigger must be between these bounds, the unprivileged
+	// triggers need a window multiple of 2s
+	minTriggerWindow = 500 * time.Millisecond
+	maxTriggerWindow = 10 * time.Second
+)
+
+// Trigger asks the kernel to notify when the tasks were stalled on a resource
+// for more than Stall within any Window, see Documentation/accounting/psi.rst.
+type Trigger struct {
+	// Name identifies the trigger in the notifications
+	Name     string
+	Resource string
+	// Cgroup is the path of the cgroup whose pressure file is watched, empty for the whole system
+	Cgroup string
+	Kind   string
+	Stall  time.Duration
+	Window time.Duration
+}
+
+// Validate checks the trigger is accepted by the kernel
+func (t Trigger) Validate() error {
+	if !slices.Contains(procfs.PressureResources, t.Resource) {
+		return fmt.Errorf("unknown pressure resource %q, use cpu, memory or io", t.Resource)
+	}
+	if t.Kind != KindSome && t.Kind != KindFull {
+		return fmt.Errorf("unknown pressure kind %q, use some or full", t.Kind)
+	}
+	if t.Window < minTriggerWindow || t.Window > maxTriggerWindow {
+		return fmt.Errorf("pressure window %s is not between %s and %s", t.Window, minTriggerWindow, maxTriggerWindow)
+	}
+	if t.Stall <= 0 || t.Stall > t.Window {
+		return fmt.Errorf("pressure stall %s is not within the window %s", t.Stall, t.Window)
+	}
+	return nil
+}
+
+// String describes the trigger like the line written to the pressure file
+func (t Trigger) String() string {
+	target := "system"
+	if t.Cgroup != "" {
+		target = "cgroup " + t.Cgroup
+	}
+	return fmt.Sprintf("%s %s pressure of %s stalled %s within %s", t.Kind, t.Resource, target, t.Stall, t.Window)
+}
+
+// spec is the line written to the pressure file to register the trigger
+func (t Trigger) spec() string {
+	return fmt.Sprintf("%s %d %d", t.Kind, t.Stall.Microseconds(), t.Window.Microseconds())
+}
@@ -0,0 +1,90 @@
+//go:build linux
+
+package psi
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// Watch registers the triggers and calls fire every time the kernel notifies
+// one of them until the context is done, the kernel notifies a trigger at most
+// once per window. fire is called from the goroutine of Watch. A trigger whose
+// cgroup is removed is dropped, Watch returns once all the triggers are gone.
+func (c *Collector) Watch(ctx context.Context, triggers []Trigger, fire func(Trigger)) error {
+	logger := pterm.DefaultLogger.WithLevel(pterm.LogLevelTrace)
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("failed to create epoll: %w", err)
+	}
+	defer syscall.Close(epfd)
+
+	byFd := make(map[int32]Trigger, len(triggers))
+	// The trigger lives as long as its file stays open.
+	files := make(map[int32]*os.File, len(triggers))
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for _, trigger := range triggers {
+		if err := trigger.Validate(); err != nil {
+			return err
+		}
+		path := c.Path(trigger.Resource, trigger.Cgroup)
+		file, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		fd := int32(file.Fd())
+		files[fd] = file
+		if _, err := file.Write(append([]byte(trigger.spec()), 0)); err != nil {
+			return fmt.Errorf("failed to register trigger %q on %s: %w", trigger.spec(), path, err)
+		}
+		event := syscall.EpollEvent{Events: syscall.EPOLLPRI, Fd: fd}
+		if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, int(fd), &event); err != nil {
+			return fmt.Errorf("failed to poll %s: %w", path, err)
+		}
+		byFd[fd] = trigger
+	}
+
+	events := make([]syscall.EpollEvent, len(triggers))
+	// The wait is bounded so the cancellation of the context is noticed.
+	timeout := int(time.Second.Milliseconds())
+	for ctx.Err() == nil {
+		n, err := syscall.EpollWait(epfd, events, timeout)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to wait for pressure triggers: %w", err)
+		}
+		for _, event := range events[:n] {
+			trigger, ok := byFd[event.Fd]
+			if !ok {
+				continue
+			}
+			if event.Events&syscall.EPOLLERR != 0 {
+				// The cgroup of the trigger was removed, the other triggers are still watched.
+				logger.Warn("ai-agentic-monitor: pressure trigger is gone,", logger.Args("trigger", trigger.Name, "pressure", trigger.String()))
+				syscall.EpollCtl(epfd, syscall.EPOLL_CTL_DEL, int(event.Fd), nil)
+				files[event.Fd].Close()
+				delete(files, event.Fd)
+				delete(byFd, event.Fd)
+				if len(byFd) == 0 {
+					return fmt.Errorf("all the pressure triggers are gone")
+				}
+				continue
+			}
+			if event.Events&syscall.EPOLLPRI != 0 {
+				fire(trigger)
+			}
+		}
+	}
+	return ctx.Err()
+}
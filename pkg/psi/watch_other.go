@@ -0,0 +1,13 @@
+//go:build !linux
+
+package psi
+
+import (
+	"context"
+	"fmt"
+)
+
+// Watch is only supported on linux, the pressure stall information is a linux interface
+func (c *Collector) Watch(ctx context.Context, triggers []Trigger, fire func(Trigger)) error {
+	return fmt.Errorf("pressure triggers are only supported on linux")
+}
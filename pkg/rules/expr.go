@@ -0,0 +1,164 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+const (
+	MatchEqual     = "="
+	MatchNotEqual  = "!="
+	MatchRegexp    = "=~"
+	MatchNotRegexp = "!~"
+)
+
+// Matcher selects the series by the value of a label
+type Matcher struct {
+	Name  string
+	Op    string
+	Value string
+	re    *regexp.Regexp
+}
+
+// Matches tells if the label of the series matches, a missing label is an empty value
+func (m Matcher) Matches(labels metrics.Labels) bool {
+	value := labels[m.Name]
+	switch m.Op {
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return value == m.Value
+}
+
+func (m Matcher) String() string {
+	return m.Name + m.Op + strconv.Quote(m.Value)
+}
+
+// Expr is a threshold on the series of a metric, like
+// disk.used_percent{mount="/"} > 90 for 10m
+type Expr struct {
+	Metric    string
+	Matchers  []Matcher
+	Op        string
+	Threshold float64
+	// For is how long the condition must hold before the rule fires
+	For time.Duration
+}
+
+var (
+	exprRegexp = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_.]*)\s*(\{(?:[^}"]|"(?:[^"\\]|\\.)*")*\})?\s*` +
+		`(>=|<=|==|!=|>|<)\s*(\S+?)\s*(?:\bfor\s+(\S+))?\s*$`)
+	matcherRegexp = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*("(?:[^"\\]|\\.)*")\s*(?:,|$)`)
+)
+
+// Parse parses an expression made of a metric, optional label matchers, a
+// comparison with a number and an optional for duration.
+func Parse(text string) (Expr, error) {
+	match := exprRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return Expr{}, fmt.Errorf("invalid expression %q, expected like disk.used_percent{mount=\"/\"} > 90 for 10m", text)
+	}
+	expr := Expr{Metric: match[1], Op: match[3]}
+	threshold, err := strconv.ParseFloat(match[4], 64)
+	if err != nil {
+		return Expr{}, fmt.Errorf("invalid threshold %q in expression %q", match[4], text)
+	}
+	expr.Threshold = threshold
+	if match[5] != "" {
+		if expr.For, err = time.ParseDuration(match[5]); err != nil || expr.For < 0 {
+			return Expr{}, fmt.Errorf("invalid for duration %q in expression %q", match[5], text)
+		}
+	}
+	if match[2] != "" {
+		if expr.Matchers, err = parseMatchers(strings.TrimSuffix(strings.TrimPrefix(match[2], "{"), "}")); err != nil {
+			return Expr{}, fmt.Errorf("invalid labels in expression %q: %w", text, err)
+		}
+	}
+	return expr, nil
+}
+
+func parseMatchers(text string) ([]Matcher, error) {
+	var matchers []Matcher
+	for strings.TrimSpace(text) != "" {
+		match := matcherRegexp.FindStringSubmatch(text)
+		if match == nil {
+			return nil, fmt.Errorf("invalid label matcher %q", strings.TrimSpace(text))
+		}
+		value, err := strconv.Unquote(match[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid label value %s: %w", match[3], err)
+		}
+		matcher := Matcher{Name: match[1], Op: match[2], Value: value}
+		if matcher.Op == MatchRegexp || matcher.Op == MatchNotRegexp {
+			// the regexp must match the whole value, like mount=~"/var/.*"
+			if matcher.re, err = regexp.Compile("^(?:" + value + ")$"); err != nil {
+				return nil, fmt.Errorf("invalid label regexp %q: %w", value, err)
+			}
+		}
+		matchers = append(matchers, matcher)
+		text = text[len(match[0]):]
+	}
+	return matchers, nil
+}
+
+// Matches tells if the series has the metric and the labels of the expression
+func (e Expr) Matches(name string, labels metrics.Labels) bool {
+	if name != e.Metric {
+		return false
+	}
+	for _, matcher := range e.Matchers {
+		if !matcher.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Holds tells if the value satisfies the comparison against the threshold
+func (e Expr) Holds(value float64) bool {
+	return compare(value, e.Op, e.Threshold)
+}
+
+func (e Expr) String() string {
+	var builder strings.Builder
+	builder.WriteString(e.Metric)
+	if len(e.Matchers) > 0 {
+		matchers := make([]string, len(e.Matchers))
+		for i, matcher := range e.Matchers {
+			matchers[i] = matcher.String()
+		}
+		builder.WriteString("{" + strings.Join(matchers, ",") + "}")
+	}
+	fmt.Fprintf(&builder, " %s %s", e.Op, strconv.FormatFloat(e.Threshold, 'f', -1, 64))
+	if e.For > 0 {
+		fmt.Fprintf(&builder, " for %s", e.For)
+	}
+	return builder.String()
+}
+
+func compare(value float64, op string, threshold float64) bool {
+	switch op {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		// want is the expression printed back, empty when the text is invalid
+		want string
+		err  string
+	}{
+		{text: "cpu.usage_percent > 90", want: "cpu.usage_percent > 90"},
+		{text: `disk.used_percent{mount="/"} >= 90.5 for 10m`, want: `disk.used_percent{mount="/"} >= 90.5 for 10m0s`},
+		{text: `  load.load1{}<1e-3  `, want: "load.load1 < 0.001"},
+		{text: `net.errors_per_sec{interface!="lo", interface=~"eth.*"} != 0 for 30s`, want: `net.errors_per_sec{interface!="lo",interface=~"eth.*"} != 0 for 30s`},
+		{text: `disk.used_percent{mount!~"/snap/.*"} == 100`, want: `disk.used_percent{mount!~"/snap/.*"} == 100`},
+		{text: `process.rss_bytes{comm="a \"quoted\" name"} > 1`, want: `process.rss_bytes{comm="a \"quoted\" name"} > 1`},
+		{text: "", err: "invalid expression"},
+		{text: "cpu.usage_percent", err: "invalid expression"},
+		{text: "cpu.usage_percent >", err: "invalid expression"},
+		{text: "cpu.usage_percent => 90", err: "invalid expression"},
+		{text: "cpu.usage_percent > high", err: "invalid threshold"},
+		{text: "cpu.usage_percent > 90 for ever", err: "invalid for duration"},
+		{text: "cpu.usage_percent > 90 for -1m", err: "invalid for duration"},
+		{text: `disk.used_percent{mount=/} > 90`, err: "invalid label matcher"},
+		{text: `disk.used_percent{mount="/" device="sda"} > 90`, err: "invalid label matcher"},
+		{text: `disk.used_percent{mount=~"["} > 90`, err: "invalid label regexp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			expr, err := Parse(tt.text)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, expr.String())
+			}
+		})
+	}
+}
+
+func TestExprMatches(t *testing.T) {
+	expr, err := Parse(`disk.used_percent{mount=~"/var.*", device!="loop0"} > 90 for 5m`)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, expr.For)
+
+	tests := []struct {
+		name    string
+		metric  string
+		labels  metrics.Labels
+		matches bool
+	}{
+		{"matching", "disk.used_percent", metrics.Labels{"mount": "/var", "device": "sda1"}, true},
+		{"prefix of the regexp", "disk.used_percent", metrics.Labels{"mount": "/var/lib/docker", "device": "sda1"}, true},
+		{"regexp anchored at the start", "disk.used_percent", metrics.Labels{"mount": "/srv/var", "device": "sda1"}, false},
+		{"excluded device", "disk.used_percent", metrics.Labels{"mount": "/var", "device": "loop0"}, false},
+		{"missing label is empty", "disk.used_percent", metrics.Labels{"mount": "/var"}, true},
+		{"other metric", "disk.avail_bytes", metrics.Labels{"mount": "/var", "device": "sda1"}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.matches, expr.Matches(tt.metric, tt.labels), tt.name)
+	}
+
+	// the regexp must match the whole value
+	anchored, err := Parse(`disk.used_percent{mount=~"/"} > 90`)
+	assert.NoError(t, err)
+	assert.True(t, anchored.Matches("disk.used_percent", metrics.Labels{"mount": "/"}))
+	assert.False(t, anchored.Matches("disk.used_percent", metrics.Labels{"mount": "/boot"}))
+	excluded, err := Parse(`disk.used_percent{mount!~"/boot|/snap/.*"} > 90`)
+	assert.NoError(t, err)
+	assert.True(t, excluded.Matches("disk.used_percent", metrics.Labels{"mount": "/boot/efi"}))
+	assert.False(t, excluded.Matches("disk.used_percent", metrics.Labels{"mount": "/snap/core/1"}))
+	equal, err := Parse(`disk.used_percent{mount="/"} > 90`)
+	assert.NoError(t, err)
+	assert.False(t, equal.Matches("disk.used_percent", metrics.Labels{"mount": "/var"}))
+}
+
+func TestNewRule(t *testing.T) {
+	clear := func(value float64) *float64 { return &value }
+	tests := []struct {
+		expr  string
+		clear *float64
+		err   string
+	}{
+		{expr: "cpu.usage_percent > 90"},
+		{expr: "cpu.usage_percent > 90", clear: clear(80)},
+		{expr: "cpu.usage_percent >= 90", clear: clear(90)},
+		{expr: "cpu.usage_percent > 90", clear: clear(95), err: "must not be above"},
+		{expr: "disk.avail_bytes < 1000", clear: clear(2000)},
+		{expr: "disk.avail_bytes <= 1000", clear: clear(500), err: "must not be below"},
+		{expr: "net.errors_per_sec != 0", clear: clear(0), err: "not supported"},
+		{expr: "cpu.usage_percent >", err: "invalid expression"},
+	}
+	for _, tt := range tests {
+		_, err := NewRule("rule", tt.expr, tt.clear)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.expr)
+		} else {
+			assert.ErrorContains(t, err, tt.err, tt.expr)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+// Rule is a threshold evaluated on every series matching its expression, a
+// firing series resolves once its value crosses Clear back.
+type Rule struct {
+	Name string
+	Expr Expr
+	// Clear is the hysteresis of the rule, the threshold of the expression when nil
+	Clear *float64
+}
+
+// NewRule parses the expression of the rule and checks that the clear value
+// is on the resolved side of the threshold.
+func NewRule(name, text string, clear *float64) (Rule, error) {
+	expr, err := Parse(text)
+	if err != nil {
+		return Rule{}, err
+	}
+	rule := Rule{Name: name, Expr: expr, Clear: clear}
+	if clear == nil {
+		return rule, nil
+	}
+	switch expr.Op {
+	case ">", ">=":
+		if *clear > expr.Threshold {
+			return Rule{}, fmt.Errorf("clear %g must not be above the threshold %g", *clear, expr.Threshold)
+		}
+	case "<", "<=":
+		if *clear < expr.Threshold {
+			return Rule{}, fmt.Errorf("clear %g must not be below the threshold %g", *clear, expr.Threshold)
+		}
+	default:
+		return Rule{}, fmt.Errorf("clear is not supported with the operator %s", expr.Op)
+	}
+	return rule, nil
+}
+
+// firing tells if a firing series is still firing with the value
+func (r Rule) firing(value float64) bool {
+	if r.Clear == nil {
+		return r.Expr.Holds(value)
+	}
+	return compare(value, r.Expr.Op, *r.Clear)
+}
+
+// Sample is the latest value of a series
+type Sample struct {
+	Labels metrics.Labels
+	Time   time.Time
+	Value  float64
+}
+
+// Transition is a series of a rule which started firing or resolved
+type Transition struct {
+	Rule   string         `json:"rule"`
+	Labels metrics.Labels `json:"labels,omitempty"`
+	Value  float64        `json:"value"`
+	Firing bool           `json:"firing"`
+	// Since is when the condition started to hold
+	Since time.Time `json:"since"`
+	// Stale is set when the series resolved because it has no sample anymore
+	Stale bool `json:"stale,omitempty"`
+}
+
+// seriesState is the state of a series of a rule
+type seriesState struct {
+	labels metrics.Labels
+	// pending is when the condition started to hold, zero when it does not
+	pending time.Time
+	firing  bool
+	value   float64
+}
+
+// Evaluator keeps the state of the series of a rule between two evaluations
+type Evaluator struct {
+	rule   Rule
+	series map[string]*seriesState
+}
+
+// NewEvaluator creates the evaluator of the rule
+func NewEvaluator(rule Rule) *Evaluator {
+	return &Evaluator{rule: rule, series: make(map[string]*seriesState)}
+}
+
+// Rule returns the rule of the evaluator
+func (e *Evaluator) Rule() Rule {
+	return e.rule
+}
+
+// Evaluate updates the states with the latest samples of the series matching
+// the rule and returns the series which started firing or resolved, the
+// series without sample are forgotten.
+func (e *Evaluator) Evaluate(samples []Sample) []Transition {
+	var transitions []Transition
+	seen := make(map[string]bool, len(samples))
+	for _, sample := range samples {
+		key := sample.Labels.String()
+		seen[key] = true
+		state, ok := e.series[key]
+		if !ok {
+			state = &seriesState{labels: sample.Labels}
+			e.series[key] = state
+		}
+		state.value = sample.Value
+
+		if state.firing {
+			if !e.rule.firing(sample.Value) {
+				state.firing = false
+				transitions = append(transitions, e.transition(state, false))
+				state.pending = time.Time{}
+			}
+			continue
+		}
+		if !e.rule.Expr.Holds(sample.Value) {
+			state.pending = time.Time{}
+			continue
+		}
+		if state.pending.IsZero() {
+			state.pending = sample.Time
+		}
+		if sample.Time.Sub(state.pending) >= e.rule.Expr.For {
+			state.firing = true
+			transitions = append(transitions, e.transition(state, true))
+		}
+	}
+
+	for key, state := range e.series {
+		if seen[key] {
+			continue
+		}
+		if state.firing {
+			transition := e.transition(state, false)
+			transition.Stale = true
+			transitions = append(transitions, transition)
+		}
+		delete(e.series, key)
+	}
+	return transitions
+}
+
+// Firing returns the number of series firing
+func (e *Evaluator) Firing() int {
+	firing := 0
+	for _, state := range e.series {
+		if state.firing {
+			firing++
+		}
+	}
+	return firing
+}
+
+func (e *Evaluator) transition(state *seriesState, firing bool) Transition {
+	return Transition{
+		Rule:   e.rule.Name,
+		Labels: state.labels,
+		Value:  state.value,
+		Firing: firing,
+		Since:  state.pending,
+	}
+}
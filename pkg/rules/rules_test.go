@@ -0,0 +1,164 @@
+package rules
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/metrics"
+)
+
+// step is an evaluation of the samples of the mounts at a minute
+type step struct {
+	minute int
+	values map[string]float64
+	// want are the transitions described by describeTransition
+	want []string
+}
+
+func describeTransition(transition Transition) string {
+	kind := "resolved"
+	switch {
+	case transition.Firing:
+		kind = "firing"
+	case transition.Stale:
+		kind = "stale"
+	}
+	return fmt.Sprintf("%s %s %g", kind, transition.Labels["mount"], transition.Value)
+}
+
+func TestEvaluate(t *testing.T) {
+	clear := func(value float64) *float64 { return &value }
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		expr  string
+		clear *float64
+		steps []step
+	}{
+		{
+			name: "fires at once without for",
+			expr: "disk.used_percent > 90",
+			steps: []step{
+				{minute: 0, values: map[string]float64{"/": 50}},
+				{minute: 1, values: map[string]float64{"/": 95}, want: []string{"firing / 95"}},
+				{minute: 2, values: map[string]float64{"/": 96}},
+				{minute: 3, values: map[string]float64{"/": 80}, want: []string{"resolved / 80"}},
+				{minute: 4, values: map[string]float64{"/": 80}},
+			},
+		},
+		{
+			name: "fires once the condition held for the duration",
+			expr: "disk.used_percent > 90 for 2m",
+			steps: []step{
+				{minute: 0, values: map[string]float64{"/": 95}},
+				{minute: 1, values: map[string]float64{"/": 95}},
+				{minute: 2, values: map[string]float64{"/": 97}, want: []string{"firing / 97"}},
+				{minute: 3, values: map[string]float64{"/": 97}},
+			},
+		},
+		{
+			name: "pending reset when the condition stops holding",
+			expr: "disk.used_percent > 90 for 2m",
+			steps: []step{
+				{minute: 0, values: map[string]float64{"/": 95}},
+				{minute: 1, values: map[string]float64{"/": 85}},
+				{minute: 2, values: map[string]float64{"/": 95}},
+				{minute: 3, values: map[string]float64{"/": 95}},
+				{minute: 4, values: map[string]float64{"/": 95}, want: []string{"firing / 95"}},
+			},
+		},
+		{
+			name:  "clear hysteresis",
+			expr:  "disk.used_percent > 90",
+			clear: clear(80),
+			steps: []step{
+				{minute: 0, values: map[string]float64{"/": 95}, want: []string{"firing / 95"}},
+				{minute: 1, values: map[string]float64{"/": 85}},
+				{minute: 2, values: map[string]float64{"/": 81}},
+				{minute: 3, values: map[string]float64{"/": 80}, want: []string{"resolved / 80"}},
+				{minute: 4, values: map[string]float64{"/": 85}},
+				{minute: 5, values: map[string]float64{"/": 91}, want: []string{"firing / 91"}},
+			},
+		},
+		{
+			name:  "clear of a lower bound",
+			expr:  "disk.used_percent < 10",
+			clear: clear(20),
+			steps: []step{
+				{minute: 0, values: map[string]float64{"/": 5}, want: []string{"firing / 5"}},
+				{minute: 1, values: map[string]float64{"/": 15}},
+				{minute: 2, values: map[string]float64{"/": 25}, want: []string{"resolved / 25"}},
+			},
+		},
+		{
+			name: "series without sample",
+			expr: "disk.used_percent > 90 for 1m",
+			steps: []step{
+				{minute: 0, values: map[string]float64{"/": 95, "/var": 95}},
+				{minute: 1, values: map[string]float64{"/": 95}, want: []string{"firing / 95"}},
+				// /var was pending, it starts again when it comes back
+				{minute: 2, values: map[string]float64{"/var": 95}, want: []string{"stale / 95"}},
+				{minute: 3, values: map[string]float64{"/var": 95}, want: []string{"firing /var 95"}},
+				{minute: 4, values: map[string]float64{}, want: []string{"stale /var 95"}},
+				{minute: 5, values: map[string]float64{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := NewRule("disk", tt.expr, tt.clear)
+			assert.NoError(t, err)
+			evaluator := NewEvaluator(rule)
+			firing := false
+			for _, s := range tt.steps {
+				now := start.Add(time.Duration(s.minute) * time.Minute)
+				samples := make([]Sample, 0, len(s.values))
+				for mount, value := range s.values {
+					samples = append(samples, Sample{Labels: metrics.Labels{"mount": mount}, Time: now, Value: value})
+				}
+				var got []string
+				for _, transition := range evaluator.Evaluate(samples) {
+					assert.Equal(t, "disk", transition.Rule)
+					got = append(got, describeTransition(transition))
+					firing = transition.Firing
+				}
+				assert.ElementsMatch(t, s.want, got, "minute %d", s.minute)
+			}
+			if firing {
+				assert.Equal(t, 1, evaluator.Firing())
+			} else {
+				assert.Equal(t, 0, evaluator.Firing())
+			}
+		})
+	}
+}
+
+func TestEvaluateSince(t *testing.T) {
+	rule, err := NewRule("disk", "disk.used_percent > 90 for 2m", nil)
+	assert.NoError(t, err)
+	evaluator := NewEvaluator(rule)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	labels := metrics.Labels{"mount": "/"}
+
+	for minute := 0; minute < 2; minute++ {
+		assert.Empty(t, evaluator.Evaluate([]Sample{{Labels: labels, Time: start.Add(time.Duration(minute) * time.Minute), Value: 95}}))
+	}
+	transitions := evaluator.Evaluate([]Sample{{Labels: labels, Time: start.Add(2 * time.Minute), Value: 95}})
+	if assert.Len(t, transitions, 1) {
+		assert.True(t, transitions[0].Firing)
+		assert.Equal(t, start, transitions[0].Since)
+		assert.Equal(t, labels, transitions[0].Labels)
+	}
+	assert.Equal(t, 1, evaluator.Firing())
+
+	transitions = evaluator.Evaluate(nil)
+	if assert.Len(t, transitions, 1) {
+		assert.False(t, transitions[0].Firing)
+		assert.True(t, transitions[0].Stale)
+		assert.Equal(t, start, transitions[0].Since)
+	}
+	assert.Equal(t, 0, evaluator.Firing())
+}
@@ -0,0 +1,229 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const (
+	Running     = "running"
+	Finished    = "finished"
+	Failed      = "failed"
+	NotFinished = "not_finished"
+
+	// file extension of the persisted sessions
+	sessionExt = ".json"
+)
+
+// Step is one persisted step of an investigation
+type Step struct {
+	Tool        string `json:"tool"`
+	ToolInput   string `json:"tool_input"`
+	Log         string `json:"log"`
+	Observation string `json:"observation"`
+}
+
+// Session is an investigation of the agent, persisted after every step so
+// it could be resumed after a restart or when the run hits its budget.
+type Session struct {
+	ID        string            `json:"id"`
+	Monitor   string            `json:"monitor"`
+	Profile   string            `json:"profile"`
+	Tools     []string          `json:"tools"`
+	Inputs    map[string]string `json:"inputs"`
+	Steps     []Step            `json:"steps"`
+	Status    string            `json:"status"`
+	Answer    string            `json:"answer,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// NewSession creates a running session with a new ID
+func NewSession(monitor, profile string, tools []string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:        uuid.New().String(),
+		Monitor:   monitor,
+		Profile:   profile,
+		Tools:     tools,
+		Inputs:    map[string]string{},
+		Status:    Running,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// AgentSteps returns the steps of the session as agent steps
+func (s *Session) AgentSteps() []schema.AgentStep {
+	steps := make([]schema.AgentStep, 0, len(s.Steps))
+	for _, step := range s.Steps {
+		steps = append(steps, schema.AgentStep{
+			Action: schema.AgentAction{
+				Tool:      step.Tool,
+				ToolInput: step.ToolInput,
+				Log:       step.Log,
+			},
+			Observation: step.Observation,
+		})
+	}
+	return steps
+}
+
+// SetAgentSteps replaces the steps of the session
+func (s *Session) SetAgentSteps(steps []schema.AgentStep) {
+	s.Steps = make([]Step, 0, len(steps))
+	for _, step := range steps {
+		s.Steps = append(s.Steps, Step{
+			Tool:        step.Action.Tool,
+			ToolInput:   step.Action.ToolInput,
+			Log:         step.Action.Log,
+			Observation: step.Observation,
+		})
+	}
+}
+
+// AppendHint appends an operator hint to the input of the session
+func (s *Session) AppendHint(key, hint string) {
+	hint = strings.TrimSpace(hint)
+	if hint == "" {
+		return
+	}
+	s.Inputs[key] = strings.TrimSpace(s.Inputs[key]) + "\n\nOperator hint: " + hint
+}
+
+// Store persists the sessions as JSON files in a directory
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save writes the session, the file is replaced atomically so a crash never
+// leaves a truncated session behind.
+func (s *Store) Save(sess *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	sess.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", sess.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, sess.ID+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+	}
+	return os.Rename(tmp.Name(), s.path(sess.ID))
+}
+
+// Load reads the session with the given ID
+func (s *Store) Load(id string) (*Session, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid session id %q", id)
+	}
+
+	data, err := os.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", id, err)
+	}
+
+	sess := &Session{}
+	if err := json.Unmarshal(data, sess); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", id, err)
+	}
+	if sess.Inputs == nil {
+		sess.Inputs = map[string]string{}
+	}
+	return sess, nil
+}
+
+// List returns all the sessions, the most recently updated first
+func (s *Store) List() ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list()
+}
+
+func (s *Store) list() ([]*Session, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make([]*Session, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != sessionExt {
+			continue
+		}
+		sess, err := s.Load(strings.TrimSuffix(entry.Name(), sessionExt))
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Prune removes the sessions not updated since maxAge and the oldest ones
+// beyond the maxCount most recent, it returns the number of removed sessions.
+func (s *Store) Prune(now time.Time, maxAge time.Duration, maxCount int) (int, error) {
+	// the lock is held from the listing to the removals so a session saved
+	// meanwhile is not removed from an outdated listing
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions, err := s.list()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i, sess := range sessions {
+		if i < maxCount && now.Sub(sess.UpdatedAt) <= maxAge {
+			continue
+		}
+		if err := os.Remove(s.path(sess.ID)); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove session %s: %w", sess.ID, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+sessionExt)
+}
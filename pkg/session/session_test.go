@@ -0,0 +1,162 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeSession writes the session as it was last updated at the given time,
+// Save would set it to the current time.
+func writeSession(t *testing.T, store *Store, sess *Session, updatedAt time.Time) {
+	sess.UpdatedAt = updatedAt
+	data, err := json.Marshal(sess)
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(store.dir, os.ModePerm))
+	assert.NoError(t, os.WriteFile(store.path(sess.ID), data, 0o644))
+}
+
+func TestSaveLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "sessions"))
+	sess := NewSession("disk", "triage", []string{"DiskInspector"})
+	sess.Inputs["input"] = "check the disks"
+	sess.Steps = []Step{{Tool: "DiskInspector", ToolInput: "/", Log: "Action: DiskInspector", Observation: "95% used"}}
+	assert.NoError(t, store.Save(sess))
+
+	loaded, err := store.Load(sess.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, sess.ID, loaded.ID)
+	assert.Equal(t, "disk", loaded.Monitor)
+	assert.Equal(t, []string{"DiskInspector"}, loaded.Tools)
+	assert.Equal(t, sess.Inputs, loaded.Inputs)
+	assert.Equal(t, sess.Steps, loaded.Steps)
+	assert.Equal(t, Running, loaded.Status)
+	assert.True(t, sess.UpdatedAt.Equal(loaded.UpdatedAt))
+
+	// saving again replaces the session without leaving a temporary file
+	sess.Status = Finished
+	sess.Answer = "the disk is full"
+	assert.NoError(t, store.Save(sess))
+	loaded, err = store.Load(sess.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, Finished, loaded.Status)
+	assert.Equal(t, "the disk is full", loaded.Answer)
+	entries, err := os.ReadDir(store.dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestLoadErrors(t *testing.T) {
+	store := NewStore(t.TempDir())
+	assert.NoError(t, os.WriteFile(store.path("corrupt"), []byte("{"), 0o644))
+	assert.NoError(t, os.WriteFile(store.path("empty"), []byte("{}"), 0o644))
+
+	tests := []struct {
+		id  string
+		err string
+	}{
+		{id: "", err: "invalid session id"},
+		{id: "../sessions", err: "invalid session id"},
+		{id: "missing", err: "not found"},
+		{id: "corrupt", err: "failed to parse session"},
+	}
+	for _, tt := range tests {
+		_, err := store.Load(tt.id)
+		assert.ErrorContains(t, err, tt.err, tt.id)
+	}
+
+	sess, err := store.Load("empty")
+	assert.NoError(t, err)
+	assert.NotNil(t, sess.Inputs)
+}
+
+func TestList(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "sessions"))
+	sessions, err := store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	now := time.Now()
+	older := NewSession("disk", "triage", nil)
+	newer := NewSession("memory", "escalation", nil)
+	writeSession(t, store, older, now.Add(-time.Hour))
+	writeSession(t, store, newer, now)
+	assert.NoError(t, os.WriteFile(store.path("corrupt"), []byte("{"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(store.dir, "notes.txt"), []byte("notes"), 0o644))
+	assert.NoError(t, os.Mkdir(filepath.Join(store.dir, "dir"+sessionExt), os.ModePerm))
+
+	sessions, err = store.List()
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 2) {
+		assert.Equal(t, newer.ID, sessions[0].ID)
+		assert.Equal(t, older.ID, sessions[1].ID)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		maxCount int
+		// kept are the ages in hours of the sessions kept
+		kept []int
+	}{
+		{name: "nothing to prune", maxAge: 100 * time.Hour, maxCount: 10, kept: []int{1, 2, 3, 30, 60}},
+		{name: "max age", maxAge: 24 * time.Hour, maxCount: 10, kept: []int{1, 2, 3}},
+		{name: "max age is inclusive", maxAge: 30 * time.Hour, maxCount: 10, kept: []int{1, 2, 3, 30}},
+		{name: "max count", maxAge: 100 * time.Hour, maxCount: 2, kept: []int{1, 2}},
+		{name: "max age and max count", maxAge: 2 * time.Hour, maxCount: 3, kept: []int{1, 2}},
+		{name: "zero count", maxAge: 100 * time.Hour, maxCount: 0, kept: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(t.TempDir())
+			ages := map[string]int{}
+			for _, age := range []int{3, 60, 1, 30, 2} {
+				sess := NewSession("disk", "triage", nil)
+				writeSession(t, store, sess, now.Add(-time.Duration(age)*time.Hour))
+				ages[sess.ID] = age
+			}
+
+			removed, err := store.Prune(now, tt.maxAge, tt.maxCount)
+			assert.NoError(t, err)
+			assert.Equal(t, len(ages)-len(tt.kept), removed)
+			sessions, err := store.List()
+			assert.NoError(t, err)
+			kept := make([]int, 0, len(sessions))
+			for _, sess := range sessions {
+				kept = append(kept, ages[sess.ID])
+			}
+			assert.Equal(t, tt.kept, kept)
+		})
+	}
+}
+
+func TestPruneConcurrentSaves(t *testing.T) {
+	store := NewStore(t.TempDir())
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, store.Save(NewSession("disk", "triage", nil)))
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := store.Prune(time.Now(), time.Hour, 100)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// the recent sessions are all kept
+	sessions, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 20)
+}
@@ -4,16 +4,33 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/disk"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
 )
 
+// SystemInfo are the facts about the host given to the agent in its prompt
 type SystemInfo struct {
-	OS             OSInfo   `json:"os"`
-	Arch           string   `json:"arch"`
-	CPU            int      `json:"cpu"`
-	Memory         string   `json:"memory"`
+	Hostname string `json:"hostname,omitempty"`
+	OS       OSInfo `json:"os"`
+	Kernel   string `json:"kernel,omitempty"`
+	Arch     string `json:"arch"`
+	Uptime   string `json:"uptime,omitempty"`
+	// Virtualization is the hypervisor of the host, none on bare metal
+	Virtualization string `json:"virtualization,omitempty"`
+	// Container is the container the monitor runs in, with its limits
+	Container  *ContainerInfo  `json:"container,omitempty"`
+	CPU        CPUInfo         `json:"cpu"`
+	Memory     MemoryInfo      `json:"memory"`
+	Mounts     []MountInfo     `json:"mounts,omitempty"`
+	Interfaces []InterfaceInfo `json:"interfaces,omitempty"`
+	// AvailableTools are the installed diagnostic tools with their versions
 	AvailableTools []string `json:"available_tools"`
 }
 
@@ -23,16 +40,62 @@ type OSInfo struct {
 	ID      string `json:"id"`
 }
 
+// CPUInfo is the model and the number of the CPUs usable by the monitor
+type CPUInfo struct {
+	Model string `json:"model,omitempty"`
+	CPUs  int    `json:"cpus"`
+}
+
+// MemoryInfo is the memory and the swap from /proc/meminfo
+type MemoryInfo struct {
+	Total     string `json:"total"`
+	Available string `json:"available"`
+	SwapTotal string `json:"swap_total"`
+	SwapFree  string `json:"swap_free"`
+}
+
+// MountInfo is the usage of a mounted filesystem
+type MountInfo struct {
+	MountPoint  string  `json:"mount_point"`
+	Device      string  `json:"device"`
+	FSType      string  `json:"fs_type"`
+	Size        string  `json:"size"`
+	UsedPercent float64 `json:"used_percent"`
+	ReadOnly    bool    `json:"read_only,omitempty"`
+}
+
+// InterfaceInfo is a network interface with its addresses
+type InterfaceInfo struct {
+	Name      string   `json:"name"`
+	State     string   `json:"state"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// GetSystemInfo reads the facts about the live host, the facts which could
+// not be read are left empty.
 func GetSystemInfo() SystemInfo {
+	fs := procfs.DefaultFS()
 	return SystemInfo{
+		Hostname:       getHostname(),
 		OS:             getOSInfo(),
+		Kernel:         readTrimmed(fs.ProcPath("sys", "kernel", "osrelease")),
 		Arch:           runtime.GOARCH,
-		CPU:            runtime.NumCPU(),
-		Memory:         fmt.Sprintf("%d MB", runtime.MemStats{}.Sys),
+		Uptime:         getUptime(fs),
+		Virtualization: detectVirtualization(fs),
+		Container:      detectContainer(fs),
+		CPU:            CPUInfo{Model: getCPUModel(fs), CPUs: runtime.NumCPU()},
+		Memory:         getMemoryInfo(fs),
+		Mounts:         getMounts(fs),
+		Interfaces:     getInterfaces(),
 		AvailableTools: getAvailableTools(),
 	}
 }
 
+func getHostname() string {
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
 func getOSInfo() OSInfo {
 	osInfo := OSInfo{
 		Name:    runtime.GOOS,
@@ -69,42 +132,131 @@ func getOSInfo() OSInfo {
 	return osInfo
 }
 
-func getAvailableTools() []string {
-	directories := []string{"/usr/bin", "/usr/sbin", "/bin", "/sbin"}
-	toolSet := make(map[string]bool)
+// getUptime returns the time since the boot from /proc/uptime
+func getUptime(fs procfs.FS) string {
+	fields := strings.Fields(readTrimmed(fs.ProcPath("uptime")))
+	if len(fields) == 0 {
+		return ""
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return ""
+	}
+	uptime := time.Duration(seconds) * time.Second
+	days := int(uptime / (24 * time.Hour))
+	return fmt.Sprintf("%d days %02d:%02d", days, int(uptime%(24*time.Hour)/time.Hour), int(uptime%time.Hour/time.Minute))
+}
 
-	for _, dir := range directories {
-		entries, err := os.ReadDir(dir)
-		if err != nil {
+// getCPUModel returns the model name of the first CPU of /proc/cpuinfo, the
+// arm kernels name it Hardware or Model
+func getCPUModel(fs procfs.FS) string {
+	file, err := os.Open(fs.ProcPath("cpuinfo"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	model := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
 			continue
 		}
+		switch strings.TrimSpace(key) {
+		case "model name":
+			return strings.TrimSpace(value)
+		case "Hardware", "Model":
+			model = strings.TrimSpace(value)
+		}
+	}
+	return model
+}
 
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
-			}
+func getMemoryInfo(fs procfs.FS) MemoryInfo {
+	meminfo, err := fs.MemInfo()
+	if err != nil {
+		return MemoryInfo{}
+	}
+	// /proc/meminfo is in kB
+	return MemoryInfo{
+		Total:     formatBytes(meminfo["MemTotal"] * 1024),
+		Available: formatBytes(meminfo["MemAvailable"] * 1024),
+		SwapTotal: formatBytes(meminfo["SwapTotal"] * 1024),
+		SwapFree:  formatBytes(meminfo["SwapFree"] * 1024),
+	}
+}
 
-			info, err := entry.Info()
-			if err != nil {
-				continue
-			}
+// getMounts returns the filesystems with disk space, without the pseudo
+// filesystems and the repeated mounts
+func getMounts(fs procfs.FS) []MountInfo {
+	usages, err := disk.Usage(fs)
+	if err != nil {
+		return nil
+	}
+	mounts := make([]MountInfo, 0, len(usages))
+	for _, usage := range usages {
+		if usage.Error != "" {
+			continue
+		}
+		mounts = append(mounts, MountInfo{
+			MountPoint:  usage.MountPoint,
+			Device:      usage.Device,
+			FSType:      usage.FSType,
+			Size:        formatBytes(usage.SizeBytes),
+			UsedPercent: usage.UsedPercent,
+			ReadOnly:    usage.ReadOnly,
+		})
+	}
+	return mounts
+}
 
-			if info.Mode()&0111 != 0 { // 检查是否有执行权限
-				toolSet[entry.Name()] = true
+// getInterfaces returns the network interfaces but the loopback
+func getInterfaces() []InterfaceInfo {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var infos []InterfaceInfo
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		info := InterfaceInfo{Name: iface.Name, State: "down"}
+		if iface.Flags&net.FlagUp != 0 {
+			info.State = "up"
+		}
+		if addrs, err := iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				info.Addresses = append(info.Addresses, addr.String())
 			}
 		}
+		infos = append(infos, info)
 	}
+	return infos
+}
 
-	var availableTools []string
-	for tool := range toolSet {
-		availableTools = append(availableTools, tool)
+// readTrimmed returns the content of a small file, empty when it could not be read
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
 	}
-
-	return availableTools
+	return strings.TrimSpace(string(data))
 }
 
-func GetAvailableTools() string {
-	return strings.Join(getAvailableTools(), ", ")
+// formatBytes formats a size with binary units like 15.6 GiB
+func formatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exp := float64(size), 0
+	for value >= unit && exp < 5 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[exp-1])
 }
 
 // 新增函数：将 SystemInfo 转换为 JSON 字符串
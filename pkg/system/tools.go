@@ -0,0 +1,126 @@
+package system
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maximal time waited for the version of a tool
+	versionTimeout = 2 * time.Second
+)
+
+// diagnosticTool is a tool worth telling the agent about, with the arguments
+// printing its version
+type diagnosticTool struct {
+	name        string
+	versionArgs []string
+}
+
+// diagnosticTools are the tools relevant to the diagnosis of a host, the
+// other binaries of the PATH only cost tokens in the prompt
+var diagnosticTools = []diagnosticTool{
+	// processes and memory
+	{"ps", []string{"-V"}},
+	{"top", []string{"-V"}},
+	{"htop", []string{"--version"}},
+	{"free", []string{"-V"}},
+	{"vmstat", []string{"-V"}},
+	{"pidstat", []string{"-V"}},
+	{"mpstat", []string{"-V"}},
+	{"sar", []string{"-V"}},
+	{"lsof", []string{"-v"}},
+	{"strace", []string{"-V"}},
+	{"ltrace", []string{"-V"}},
+	{"perf", []string{"--version"}},
+	{"bpftrace", []string{"--version"}},
+	{"gdb", []string{"--version"}},
+	// disks
+	{"iostat", []string{"-V"}},
+	{"iotop", []string{"--version"}},
+	{"df", []string{"--version"}},
+	{"du", []string{"--version"}},
+	{"lsblk", []string{"--version"}},
+	{"smartctl", []string{"--version"}},
+	{"xfs_info", []string{"-V"}},
+	{"tune2fs", nil},
+	// network
+	{"ss", []string{"-V"}},
+	{"netstat", []string{"--version"}},
+	{"ip", []string{"-V"}},
+	{"ethtool", []string{"--version"}},
+	{"tcpdump", []string{"--version"}},
+	{"dig", []string{"-v"}},
+	{"curl", []string{"--version"}},
+	{"nc", nil},
+	{"nft", []string{"--version"}},
+	{"iptables", []string{"--version"}},
+	// logs and services
+	{"journalctl", []string{"--version"}},
+	{"systemctl", []string{"--version"}},
+	{"dmesg", []string{"--version"}},
+	// containers
+	{"docker", []string{"--version"}},
+	{"podman", []string{"--version"}},
+	{"crictl", []string{"--version"}},
+	{"kubectl", []string{"version", "--client"}},
+}
+
+var (
+	versionRegexp = regexp.MustCompile(`\d+\.\d+(?:\.\d+)*`)
+
+	availableToolsOnce sync.Once
+	availableTools     []string
+)
+
+// getAvailableTools returns the installed diagnostic tools like "ss 6.1.0",
+// the versions are read once since the prompt of every run includes them.
+func getAvailableTools() []string {
+	availableToolsOnce.Do(func() {
+		found := make([]string, len(diagnosticTools))
+		var wg sync.WaitGroup
+		for i, tool := range diagnosticTools {
+			path, err := exec.LookPath(tool.name)
+			if err != nil {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, tool diagnosticTool, path string) {
+				defer wg.Done()
+				found[i] = tool.name
+				if version := toolVersion(path, tool.versionArgs); version != "" {
+					found[i] += " " + version
+				}
+			}(i, tool, path)
+		}
+		wg.Wait()
+		for _, tool := range found {
+			if tool != "" {
+				availableTools = append(availableTools, tool)
+			}
+		}
+	})
+	return availableTools
+}
+
+// toolVersion returns the first version number printed by the tool, empty
+// when the tool has no version option or does not print it in time
+func toolVersion(path string, args []string) string {
+	if args == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	// the tools print their version on stdout or stderr, some exit with an error
+	output, _ := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	return versionRegexp.FindString(string(output))
+}
+
+// GetAvailableTools returns the installed diagnostic tools with their versions
+func GetAvailableTools() string {
+	return strings.Join(getAvailableTools(), ", ")
+}
@@ -0,0 +1,127 @@
+package system
+
+import (
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/darmenliu/ai-agentic-monitor/pkg/cgroup"
+	"github.com/darmenliu/ai-agentic-monitor/pkg/procfs"
+)
+
+// ContainerInfo is the container the monitor runs in, the memory and the
+// processes of the host are not all visible from it.
+type ContainerInfo struct {
+	// Runtime is docker, podman, containerd, cri-o, lxc, systemd-nspawn or
+	// container when the runtime is unknown
+	Runtime    string `json:"runtime"`
+	Kubernetes bool   `json:"kubernetes,omitempty"`
+	ID         string `json:"id,omitempty"`
+	// MemoryLimit and CPULimit are the limits of the cgroup of the monitor
+	MemoryLimit string   `json:"memory_limit,omitempty"`
+	CPULimit    *float64 `json:"cpu_limit,omitempty"`
+}
+
+// dmiVendors maps the DMI vendors and products to the hypervisors
+var dmiVendors = []struct {
+	match      string
+	hypervisor string
+}{
+	{"KVM", "kvm"},
+	{"QEMU", "qemu"},
+	{"VMware", "vmware"},
+	{"VirtualBox", "virtualbox"},
+	{"innotek", "virtualbox"},
+	{"Xen", "xen"},
+	{"Microsoft Corporation Virtual Machine", "hyperv"},
+	{"Amazon EC2", "amazon"},
+	{"Google Compute Engine", "google"},
+	{"OpenStack", "openstack"},
+	{"Parallels", "parallels"},
+	{"Bochs", "bochs"},
+	{"BHYVE", "bhyve"},
+}
+
+// detectVirtualization guesses the hypervisor from the DMI tables, a CPU
+// with the hypervisor flag but without DMI, like firecracker, is a vm.
+func detectVirtualization(fs procfs.FS) string {
+	dmi := readTrimmed(fs.SysPath("class", "dmi", "id", "sys_vendor")) + " " +
+		readTrimmed(fs.SysPath("class", "dmi", "id", "product_name")) + " " +
+		readTrimmed(fs.SysPath("class", "dmi", "id", "bios_vendor"))
+	for _, vendor := range dmiVendors {
+		if strings.Contains(dmi, vendor.match) {
+			return vendor.hypervisor
+		}
+	}
+	if hypervisor := readTrimmed(fs.SysPath("hypervisor", "type")); hypervisor != "" {
+		return hypervisor
+	}
+	cpuinfo, err := os.ReadFile(fs.ProcPath("cpuinfo"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range bytes.Split(cpuinfo, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("flags")) {
+			if bytes.Contains(line, []byte(" hypervisor")) {
+				return "vm"
+			}
+			return "none"
+		}
+	}
+	return ""
+}
+
+// detectContainer finds the container the monitor runs in from the files
+// the runtimes leave, the environment and the cgroup of the process, nil
+// when it does not run in a container.
+func detectContainer(fs procfs.FS) *ContainerInfo {
+	info := &ContainerInfo{}
+	switch {
+	case fileExists("/.dockerenv"):
+		info.Runtime = "docker"
+	case fileExists("/run/.containerenv"):
+		info.Runtime = "podman"
+	}
+	// systemd-nspawn, lxc and podman set the container variable of the init process
+	if environ, err := os.ReadFile(fs.ProcPath("1", "environ")); err == nil {
+		for _, variable := range bytes.Split(environ, []byte{0}) {
+			if value, ok := bytes.CutPrefix(variable, []byte("container=")); ok && info.Runtime == "" {
+				info.Runtime = string(value)
+			}
+		}
+	}
+	info.Kubernetes = os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+
+	path, err := cgroup.PIDGroup(fs, os.Getpid())
+	if err == nil {
+		identity := cgroup.Identify(path)
+		info.ID = identity.ContainerID
+		if info.Runtime == "" && identity.ContainerID != "" {
+			info.Runtime = identity.Runtime
+		}
+		info.Kubernetes = info.Kubernetes || identity.PodUID != ""
+	}
+	if info.Runtime == "" && info.Kubernetes {
+		info.Runtime = "container"
+	}
+	if info.Runtime == "" {
+		return nil
+	}
+
+	if hierarchy, err := cgroup.NewHierarchy(fs); err == nil && path != "" {
+		if group, err := hierarchy.Group(path); err == nil {
+			if group.Memory != nil && group.Memory.Max != nil {
+				info.MemoryLimit = formatBytes(*group.Memory.Max)
+			}
+			if group.CPU != nil {
+				info.CPULimit = group.CPU.MaxCPUs
+			}
+		}
+	}
+	return info
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}